@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// Input formats for decode.
+const (
+	decodeAuto    = "auto"
+	decodeHex     = "hex"
+	decodeBinary  = "binary"
+	decodeCapture = "capture"
+)
+
+// decodeRawBytes is how many bytes of a command are shown before the rest are elided.
+const decodeRawBytes = 8
+
+// shades draws greyscale pixels from off to full brightness.
+const shades = ".:-=+*#%@"
+
+// decodedStream is a run of bytes sent to one module.
+type decodedStream struct {
+	label string // Where the bytes came from, or empty for plain input
+	data  []byte
+}
+
+// decodeCommand reads a LED matrix byte stream from a file, or standard input, and prints each
+// command in it. It returns an error if any bytes could not be decoded.
+func decodeCommand(stdin io.Reader, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ContinueOnError)
+	format := fs.String("format", decodeAuto, "Input format: auto, hex, binary or capture")
+	pixels := fs.Bool("pixels", true, "Draw the pixels of DrawBW, StageCol and FlushCols commands")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: decode [-format FORMAT] [-pixels=false] [file]")
+	}
+
+	input := stdin
+
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open input: %w", err)
+		}
+
+		defer f.Close() //nolint:errcheck // read-only file
+
+		input = f
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	streams, err := parseDecodeInput(data, *format)
+	if err != nil {
+		return err
+	}
+
+	malformed := 0
+
+	for i, stream := range streams {
+		if stream.label != "" {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+
+			fmt.Fprintf(w, "== %s ==\n", stream.label)
+		}
+
+		malformed += printFrames(w, matrix.DecodeStream(stream.data), *pixels)
+	}
+
+	if malformed > 0 {
+		return fmt.Errorf("found %d malformed frames", malformed)
+	}
+
+	return nil
+}
+
+// parseDecodeInput splits input into the byte streams to decode. Hex input may separate bytes
+// with spaces, commas or colons and prefix them with 0x. Captures are decoded per port, using only
+// what was sent to the module.
+func parseDecodeInput(data []byte, format string) ([]decodedStream, error) {
+	if format == decodeAuto {
+		format = detectDecodeFormat(data)
+	}
+
+	switch format {
+	case decodeBinary:
+		return []decodedStream{{data: data}}, nil
+	case decodeHex:
+		decoded, err := parseHex(string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		return []decodedStream{{data: decoded}}, nil
+	case decodeCapture:
+		records, err := matrix.ReadCapture(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var streams []decodedStream
+
+		index := make(map[string]int)
+
+		for _, record := range records {
+			if record.Direction != matrix.DirectionSent {
+				continue
+			}
+
+			i, ok := index[record.Port]
+			if !ok {
+				i = len(streams)
+				index[record.Port] = i
+				streams = append(streams, decodedStream{label: "sent to " + record.Port})
+			}
+
+			streams[i].data = append(streams[i].data, record.Data...)
+		}
+
+		return streams, nil
+	default:
+		return nil, fmt.Errorf("unknown input format %q (want auto, hex, binary or capture)", format)
+	}
+}
+
+// detectDecodeFormat guesses whether data is a capture, hex text or raw bytes.
+func detectDecodeFormat(data []byte) string {
+	if matrix.IsCapture(data) {
+		return decodeCapture
+	}
+
+	for _, b := range bytes.TrimSpace(data) {
+		if !strings.ContainsRune("0123456789abcdefABCDEFxX,: \t\r\n", rune(b)) {
+			return decodeBinary
+		}
+	}
+
+	return decodeHex
+}
+
+func parseHex(text string) ([]byte, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == ',' || r == ':'
+	})
+
+	var digits strings.Builder
+
+	for _, field := range fields {
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		if len(field)%2 == 1 {
+			// A lone digit such as the "5" in "0x5" is a whole byte
+			field = "0" + field
+		}
+
+		digits.WriteString(field)
+	}
+
+	decoded, err := hex.DecodeString(digits.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hex input: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// printFrames prints one line per frame, followed by the pixels of drawing commands if pixels is
+// set, and returns how many frames were malformed. Columns staged by StageCol are kept so that
+// FlushCols can draw the frame the module shows.
+func printFrames(w io.Writer, frames []matrix.DecodedFrame, pixels bool) int {
+	var staged [matrix.FramebufferHeight][matrix.FramebufferWidth]byte
+
+	malformed := 0
+
+	for _, frame := range frames {
+		if frame.Err != nil {
+			malformed++
+
+			fmt.Fprintf(w, "%06d  ! %v (%d bytes)  %s\n", frame.Offset, frame.Err, len(frame.Raw), formatRaw(frame.Raw))
+
+			continue
+		}
+
+		cmd := frame.Command
+		fmt.Fprintf(w, "%06d  %-32s  %s\n", frame.Offset, cmd.Describe(), formatRaw(frame.Raw))
+
+		switch cmd.ID {
+		case matrix.CmdDrawBW:
+			if pixels {
+				bitmap := matrix.UnpackBitmap([matrix.DrawBWSize]byte(cmd.Params))
+				for _, row := range bitmap {
+					var values [matrix.FramebufferWidth]byte
+
+					for x, lit := range row {
+						if lit {
+							values[x] = 0xFF
+						}
+					}
+
+					printPixelRow(w, values[:])
+				}
+			}
+		case matrix.CmdStageCol:
+			col := int(cmd.Params[0])
+			if col >= matrix.FramebufferHeight {
+				fmt.Fprintf(w, "        column %d is past the last column, %d\n", col, matrix.FramebufferHeight-1)
+
+				continue
+			}
+
+			copy(staged[col][:], cmd.Params[1:])
+
+			if pixels {
+				printPixelRow(w, staged[col][:])
+			}
+		case matrix.CmdFlushCols:
+			if pixels {
+				for _, row := range staged {
+					printPixelRow(w, row[:])
+				}
+			}
+		}
+	}
+
+	return malformed
+}
+
+// printPixelRow draws one row of the daemon's 34x9 frame with a character per LED.
+func printPixelRow(w io.Writer, values []byte) {
+	row := make([]byte, len(values))
+	for i, v := range values {
+		row[i] = shades[(int(v)*(len(shades)-1)+254)/255]
+	}
+
+	fmt.Fprintf(w, "        %s\n", row)
+}
+
+// formatRaw shows the first bytes of a frame in hex.
+func formatRaw(raw []byte) string {
+	if len(raw) <= decodeRawBytes {
+		return fmt.Sprintf("% X", raw)
+	}
+
+	return fmt.Sprintf("% X …", raw[:decodeRawBytes])
+}
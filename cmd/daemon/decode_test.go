@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func TestDecodeHexInput(t *testing.T) {
+	var pixels [34]byte
+	pixels[0] = 0xFF
+	pixels[33] = 0x40
+
+	var stream []byte
+	stream = append(stream, matrix.BrightnessCommand(80).ToBytes()...)
+	stream = append(stream, matrix.StageColCommand(1, pixels).ToBytes()...)
+	stream = append(stream, matrix.FlushColsCommand().ToBytes()...)
+
+	input := "0x" + hex.EncodeToString(stream)
+
+	var out bytes.Buffer
+	if err := decodeCommand(strings.NewReader(input), &out, nil); err != nil {
+		t.Fatalf("decodeCommand() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"000000  Brightness 80",
+		"000004  StageCol column 1",
+		"        @................................=\n",
+		"000042  FlushCols",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q:\n%s", want, got)
+		}
+	}
+
+	// The flush draws all nine rows, with the staged column as the second
+	lines := strings.Split(strings.TrimSpace(got[strings.Index(got, "FlushCols"):]), "\n")
+	if len(lines) != 1+matrix.FramebufferHeight || strings.TrimSpace(lines[2]) != "@................................=" {
+		t.Errorf("FlushCols drew:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestDecodeFlagsMalformedFrames(t *testing.T) {
+	input := "32 ac 00 50, de ad, 32 ac 7f, 32 ac 07 02 00"
+
+	var out bytes.Buffer
+
+	err := decodeCommand(strings.NewReader(input), &out, []string{"-pixels=false"})
+	if err == nil || !strings.Contains(err.Error(), "3 malformed frames") {
+		t.Errorf("decodeCommand() error = %v, want 3 malformed frames", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"! invalid magic bytes 0xDE 0xAD",
+		"! unknown command ID 0x7F",
+		"! command truncated: StageCol needs 35 parameter bytes, has 2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDecodeDrawBW(t *testing.T) {
+	var bitmap matrix.Bitmap
+	bitmap[0][0] = true
+	bitmap[8][33] = true
+
+	stream := matrix.DrawBWCommand(matrix.PackBitmap(bitmap)).ToBytes()
+
+	var out bytes.Buffer
+	if err := decodeCommand(bytes.NewReader(stream), &out, []string{"-format", "binary"}); err != nil {
+		t.Fatalf("decodeCommand() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1+matrix.FramebufferHeight {
+		t.Fatalf("decodeCommand() wrote %d lines, want a command and 9 rows:\n%s", len(lines), out.String())
+	}
+
+	if !strings.Contains(lines[0], "DrawBW 2 pixels lit") || strings.TrimSpace(lines[1])[0] != '@' ||
+		!strings.HasSuffix(lines[9], "@") {
+		t.Errorf("decodeCommand() drew:\n%s", out.String())
+	}
+}
+
+func TestDecodeCapture(t *testing.T) {
+	var buf bytes.Buffer
+
+	capture, _ := matrix.NewCapture(&buf, matrix.CaptureJSONL)
+	_ = capture.Record("/dev/ttyACM0", matrix.DirectionSent, matrix.VersionCommand().ToBytes())
+	_ = capture.Record("/dev/ttyACM0", matrix.DirectionReceived, []byte{0, 0x19, 0})
+	_ = capture.Record("/dev/ttyACM1", matrix.DirectionSent, matrix.SleepCommand(true).ToBytes())
+
+	var out bytes.Buffer
+	if err := decodeCommand(&buf, &out, nil); err != nil {
+		t.Fatalf("decodeCommand() error = %v", err)
+	}
+
+	want := "== sent to /dev/ttyACM0 ==\n" +
+		"000000  Version query                     32 AC 20\n" +
+		"\n" +
+		"== sent to /dev/ttyACM1 ==\n" +
+		"000000  Sleep on                          32 AC 03 01\n"
+	if out.String() != want {
+		t.Errorf("decodeCommand() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestDetectDecodeFormat(t *testing.T) {
+	tests := map[string]string{
+		"32ac0050":              decodeHex,
+		"0x32, 0xAC, 0x00\n":    decodeHex,
+		"\x32\xac\x00\x50":      decodeBinary,
+		`{"time":"2026-03-01"}`: decodeCapture,
+		"FLMCAP\x01\n":          decodeCapture,
+	}
+
+	for input, want := range tests {
+		if got := detectDecodeFormat([]byte(input)); got != want {
+			t.Errorf("detectDecodeFormat(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
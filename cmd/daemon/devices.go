@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// listDevices prints every USB serial port and, for the ones discovery would use, whether a
+// module on it answers a firmware version request.
+func listDevices(w io.Writer, cfg *config.Config) error {
+	devices, err := matrix.ListDevices()
+	if err != nil {
+		return err
+	}
+
+	printDevices(w, devices, cfg.Matrix.DiscoveryFallback, probeDevice)
+
+	return nil
+}
+
+// printDevices writes a table of devices. Devices that aren't Framework LED matrices are only
+// probed when fallback is set, since probing sends matrix commands to them.
+func printDevices(w io.Writer, devices []matrix.Device, fallback bool, probe func(portName string) string) {
+	if len(devices) == 0 {
+		fmt.Fprintln(w, "No USB serial ports found")
+
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PORT\tVID\tPID\tSERIAL NUMBER\tPRODUCT\tLOCATION\tLED MATRIX\tVERSION PROBE")
+
+	for _, device := range devices {
+		result := "skipped (not an LED matrix)"
+		if device.IsLEDMatrix() || fallback {
+			result = probe(device.Name)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", device.Name, orDash(device.VID), orDash(device.PID),
+			orDash(device.SerialNumber), orDash(device.Product), orDash(device.Location),
+			yesNo(device.IsLEDMatrix()), result)
+	}
+
+	_ = tw.Flush() //nolint:errcheck // output errors are not actionable here
+}
+
+// probeDevice opens a port, asks for the firmware version and describes the answer.
+func probeDevice(portName string) string {
+	client := matrix.NewClient()
+	if err := client.Connect(portName); err != nil {
+		return "failed: " + err.Error()
+	}
+
+	defer client.Disconnect() //nolint:errcheck // best-effort cleanup
+
+	version, err := client.GetFirmwareVersion()
+	if err != nil {
+		return "no answer: " + err.Error()
+	}
+
+	return "firmware " + version.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func TestPrintDevices(t *testing.T) {
+	devices := []matrix.Device{
+		{
+			PortDetails: enumerator.PortDetails{
+				Name: "/dev/ttyACM0", IsUSB: true, VID: "32ac", PID: "0020",
+				SerialNumber: "FRAKDEBZ0100000000", Product: "LED Matrix Input Module",
+			},
+			Location: "3-4.1",
+		},
+		{PortDetails: enumerator.PortDetails{Name: "/dev/ttyUSB0", IsUSB: true, VID: "1a86", PID: "7523"}},
+	}
+
+	var probed []string
+
+	probe := func(portName string) string {
+		probed = append(probed, portName)
+
+		return "firmware 0.1.9"
+	}
+
+	var out bytes.Buffer
+	printDevices(&out, devices, false, probe)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("printDevices() wrote %d lines, want a header and two devices:\n%s", len(lines), out.String())
+	}
+
+	for _, want := range []string{"/dev/ttyACM0", "32ac", "FRAKDEBZ0100000000", "LED Matrix Input Module", "3-4.1", "yes", "firmware 0.1.9"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("matrix line %q should contain %q", lines[1], want)
+		}
+	}
+
+	if !strings.Contains(lines[2], "skipped") {
+		t.Errorf("other device line %q should show it was not probed", lines[2])
+	}
+
+	if len(probed) != 1 || probed[0] != "/dev/ttyACM0" {
+		t.Errorf("probed %v, want only the LED matrix", probed)
+	}
+
+	// With the fallback enabled, every device is probed
+	probed = nil
+
+	printDevices(&bytes.Buffer{}, devices, true, probe)
+
+	if len(probed) != 2 {
+		t.Errorf("probed %v with fallback, want every device", probed)
+	}
+}
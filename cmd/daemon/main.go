@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/daemon"
@@ -27,8 +29,9 @@ var (
 	logLevel      = flag.String("log-level", "", "Set log level (debug, info, warn, error)")
 	matrixPort    = flag.String("port", "", "Serial port for LED matrix")
 	brightness    = flag.Int("brightness", -1, "LED brightness (0-255)")
-	displayMode   = flag.String("mode", "", "Display mode (percentage, gradient, activity, status)")
+	displayMode   = flag.String("mode", "", "Display mode (percentage, gradient, activity, status, custom, history, cores, zones)")
 	primaryMetric = flag.String("metric", "", "Primary metric to display (cpu, memory, disk, network)")
+	captureFile   = flag.String("capture", "", "Record all LED matrix traffic to this file")
 )
 
 func main() {
@@ -120,6 +123,26 @@ func main() {
 		fmt.Println(status)
 	case "config":
 		showConfiguration(cfg)
+	case "devices":
+		if err := listDevices(os.Stdout, cfg); err != nil {
+			logging.Error("failed to list devices", "error", err)
+			os.Exit(1)
+		}
+	case "replay":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := replayCommand(ctx, os.Stdout, cfg, args[1:])
+
+		stop()
+
+		if err != nil {
+			logging.Error("replay failed", "error", err)
+			os.Exit(1)
+		}
+	case "decode":
+		if err := decodeCommand(os.Stdin, os.Stdout, args[1:]); err != nil {
+			logging.Error("decode failed", "error", err)
+			os.Exit(1)
+		}
 	case "test":
 		if err := testConnection(cfg); err != nil {
 			logging.Error("connection test failed", "error", err)
@@ -169,6 +192,10 @@ func applyCommandLineOverrides(cfg *config.Config) {
 	if *logLevel != "" {
 		cfg.Logging.Level = *logLevel
 	}
+
+	if *captureFile != "" {
+		cfg.Matrix.Capture.File = *captureFile
+	}
 }
 
 func showUsage() {
@@ -186,14 +213,18 @@ COMMANDS:
     status              Show the daemon service status
     config              Show current configuration
     test                Test connection to LED matrix
+    devices             List USB serial ports and probe LED matrices for their firmware
+    replay <file>       Play a capture back to a LED matrix (-speed N, -from PORT)
+    decode [file]       Print the commands in a hex, binary or captured byte stream
 
 OPTIONS:
     -config string      Path to configuration file
     -port string        Serial port for LED matrix
     -brightness int     LED brightness (0-255)
-    -mode string        Display mode (percentage, gradient, activity, status)
+    -mode string        Display mode (percentage, gradient, activity, status, custom, history, cores, zones)
     -metric string      Primary metric to display (cpu, memory, disk, network)
     -log-level string   Set log level (debug, info, warn, error)
+    -capture string     Record all LED matrix traffic to this file
     -version           Show version information
     -help              Show this help message
 
@@ -204,6 +235,10 @@ EXAMPLES:
     %s install                               # Install as system service
     %s start                                 # Start system service
     %s test                                  # Test LED matrix connection
+    %s devices                               # Find LED matrix ports
+    %s -capture led.jsonl run                # Record LED matrix traffic
+    %s -port /dev/pts/3 replay led.jsonl     # Replay it to the simulator
+    %s decode led.jsonl                      # Show what was sent
 
 CONFIGURATION:
     The daemon looks for configuration files in the following order:
@@ -213,7 +248,7 @@ CONFIGURATION:
     4. /etc/framework-led-daemon/config.yaml
     5. ./configs/config.yaml
 
-`, name, name, name, name, name, name, name, name)
+`, name, name, name, name, name, name, name, name, name, name, name, name)
 }
 
 func showConfiguration(cfg *config.Config) {
@@ -222,7 +257,11 @@ func showConfiguration(cfg *config.Config) {
 	fmt.Printf("    Port: %s\n", cfg.Matrix.Port)
 	fmt.Printf("    Baud Rate: %d\n", cfg.Matrix.BaudRate)
 	fmt.Printf("    Auto Discover: %t\n", cfg.Matrix.AutoDiscover)
+	fmt.Printf("    Discovery Fallback: %t\n", cfg.Matrix.DiscoveryFallback)
 	fmt.Printf("    Brightness: %d\n", cfg.Matrix.Brightness)
+	fmt.Printf("    Capture File: %s\n", cfg.Matrix.Capture.File)
+	fmt.Printf("    Schedule Windows: %d\n", len(cfg.Matrix.Schedule.Windows))
+	fmt.Printf("    Idle Sleep: %t\n", cfg.Matrix.Idle.Enabled)
 	fmt.Printf("  Display:\n")
 	fmt.Printf("    Mode: %s\n", cfg.Display.Mode)
 	fmt.Printf("    Primary Metric: %s\n", cfg.Display.PrimaryMetric)
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"go.bug.st/serial"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// replayDrainInterval is how long the replay waits between polls when the module has no reply to read.
+const replayDrainInterval = 10 * time.Millisecond
+
+// replayCommand plays the commands in a capture back to a module, which can be real or the
+// simulator's emulated module on a pseudo-terminal.
+func replayCommand(ctx context.Context, w io.Writer, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	speed := fs.Float64("speed", 1, "Playback speed multiplier; 0 replays as fast as possible")
+	from := fs.String("from", "", "Port whose recorded traffic to replay, if the capture has several")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: replay [-speed N] [-from PORT] <capture file>")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open capture: %w", err)
+	}
+
+	records, err := matrix.ReadCapture(f)
+	_ = f.Close() //nolint:errcheck // read-only file
+
+	if err != nil {
+		return err
+	}
+
+	records, err = selectCapturedPort(records, *from)
+	if err != nil {
+		return err
+	}
+
+	portName := cfg.Matrix.Port
+	if portName == "" {
+		client := matrix.NewClient()
+		client.SetDiscoveryFallback(cfg.Matrix.DiscoveryFallback)
+
+		if portName, err = client.DiscoverPort(); err != nil {
+			return fmt.Errorf("failed to discover port: %w", err)
+		}
+	}
+
+	open := matrix.SerialOpener(&serial.Mode{BaudRate: cfg.Matrix.BaudRate})
+
+	return replayTo(ctx, w, records, portName, open, *speed)
+}
+
+// selectCapturedPort returns the records of the given port. If port is empty, the capture must
+// only have traffic for one port.
+func selectCapturedPort(records []matrix.CaptureRecord, port string) ([]matrix.CaptureRecord, error) {
+	var ports []string
+
+	for _, record := range records {
+		if !slices.Contains(ports, record.Port) {
+			ports = append(ports, record.Port)
+		}
+	}
+
+	if port == "" {
+		if len(ports) > 1 {
+			return nil, fmt.Errorf("capture has traffic for several ports %v; choose one with -from", ports)
+		}
+
+		return records, nil
+	}
+
+	if !slices.Contains(ports, port) {
+		return nil, fmt.Errorf("capture has no traffic for port %s (it has %v)", port, ports)
+	}
+
+	return slices.DeleteFunc(slices.Clone(records), func(record matrix.CaptureRecord) bool {
+		return record.Port != port
+	}), nil
+}
+
+// replayTo opens portName and writes the recorded commands to it. Replies from the module are
+// read and discarded, so it never blocks on a full buffer.
+func replayTo(ctx context.Context, w io.Writer, records []matrix.CaptureRecord, portName string,
+	open matrix.Opener, speed float64,
+) error {
+	port, err := open(portName)
+	if err != nil {
+		return fmt.Errorf("failed to open port %s: %w", portName, err)
+	}
+
+	defer port.Close() //nolint:errcheck // best-effort cleanup
+
+	if err := port.SetReadTimeout(replayDrainInterval); err != nil {
+		return fmt.Errorf("failed to set read timeout: %w", err)
+	}
+
+	drainCtx, stopDrain := context.WithCancel(ctx)
+	defer stopDrain()
+
+	go drainReplies(drainCtx, port)
+
+	fmt.Fprintf(w, "Replaying %s to %s at %gx speed\n", describeCapture(records), portName, speed)
+
+	writes := 0
+	err = matrix.Replay(ctx, records, speed, func(record matrix.CaptureRecord) error {
+		if _, err := port.Write(record.Data); err != nil {
+			return err
+		}
+
+		writes++
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Replayed %d writes\n", writes)
+
+	return nil
+}
+
+// describeCapture describes how much traffic a capture holds.
+func describeCapture(records []matrix.CaptureRecord) string {
+	if len(records) == 0 {
+		return "an empty capture"
+	}
+
+	duration := records[len(records)-1].Time.Sub(records[0].Time).Round(time.Millisecond)
+
+	return fmt.Sprintf("%d records spanning %s", len(records), duration)
+}
+
+// drainReplies reads and discards whatever the module sends until ctx is done or reading fails.
+func drainReplies(ctx context.Context, port matrix.Transport) {
+	buf := make([]byte, 256)
+
+	for ctx.Err() == nil {
+		n, err := port.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if n == 0 {
+			// Reads may return at once when there is nothing to read
+			select {
+			case <-ctx.Done():
+			case <-time.After(replayDrainInterval):
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func TestReplayToVirtualMatrix(t *testing.T) {
+	// Record a session against one emulated module
+	var buf bytes.Buffer
+
+	capture, err := matrix.NewCapture(&buf, matrix.CaptureBinary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := matrix.NewVirtualMatrix()
+	client := matrix.NewClient()
+	client.SetOpener(matrix.VirtualOpener(map[string]*matrix.VirtualMatrix{"virtual0": recorded}))
+	client.SetCapture(capture)
+
+	if err := client.Connect("virtual0"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	frame := matrix.NewFramebuffer()
+	frame.SetPixel(3, 7, 200)
+
+	_ = client.SetBrightness(42)
+	_ = frame.Push(client)
+	_, _ = client.GetVersion()
+	_ = client.Disconnect()
+
+	records, err := matrix.ReadCapture(&buf)
+	if err != nil {
+		t.Fatalf("ReadCapture() error = %v", err)
+	}
+
+	// Replaying it to a fresh module leaves it showing the same thing
+	replayed := matrix.NewVirtualMatrix()
+	open := matrix.VirtualOpener(map[string]*matrix.VirtualMatrix{"virtual1": replayed})
+
+	var out bytes.Buffer
+	if err := replayTo(context.Background(), &out, records, "virtual1", open, 0); err != nil {
+		t.Fatalf("replayTo() error = %v", err)
+	}
+
+	if replayed.Brightness() != 42 {
+		t.Errorf("replayed brightness = %d, want 42", replayed.Brightness())
+	}
+
+	if got := replayed.Frame().Pixel(3, 7); got != 200 {
+		t.Errorf("replayed pixel = %d, want 200", got)
+	}
+
+	if replayed.Commands() != recorded.Commands() {
+		t.Errorf("replayed %d commands, want %d", replayed.Commands(), recorded.Commands())
+	}
+
+	if !strings.Contains(out.String(), "Replayed") {
+		t.Errorf("output = %q, want a summary", out.String())
+	}
+}
+
+func TestSelectCapturedPort(t *testing.T) {
+	records := []matrix.CaptureRecord{
+		{Time: time.Unix(0, 0), Port: "/dev/ttyACM0", Direction: matrix.DirectionSent, Data: []byte{1}},
+		{Time: time.Unix(1, 0), Port: "/dev/ttyACM1", Direction: matrix.DirectionSent, Data: []byte{2}},
+		{Time: time.Unix(2, 0), Port: "/dev/ttyACM0", Direction: matrix.DirectionSent, Data: []byte{3}},
+	}
+
+	if _, err := selectCapturedPort(records, ""); err == nil {
+		t.Error("selectCapturedPort() succeeded for a capture with two ports and no -from, want an error")
+	}
+
+	if _, err := selectCapturedPort(records, "/dev/ttyACM9"); err == nil {
+		t.Error("selectCapturedPort() succeeded for a port not in the capture, want an error")
+	}
+
+	selected, err := selectCapturedPort(records, "/dev/ttyACM0")
+	if err != nil {
+		t.Fatalf("selectCapturedPort() error = %v", err)
+	}
+
+	if len(selected) != 2 || selected[0].Data[0] != 1 || selected[1].Data[0] != 3 {
+		t.Errorf("selectCapturedPort() = %+v, want the two ttyACM0 records", selected)
+	}
+
+	if len(records) != 3 {
+		t.Error("selectCapturedPort() modified the records it was given")
+	}
+
+	single, err := selectCapturedPort(records[:1], "")
+	if err != nil || len(single) != 1 {
+		t.Errorf("selectCapturedPort() = %v, %v for a single-port capture", single, err)
+	}
+}
@@ -22,6 +22,7 @@ type GUIApp struct {
 	dashboard  *Dashboard
 	ledPreview *LEDPreview
 	settings   *Settings
+	calibrate  *Calibration
 	health     *HealthView
 	statusBar  *widget.Label
 	ctx        context.Context
@@ -51,12 +52,14 @@ func NewGUIApp(fyneApp fyne.App, client *api.Client) *GUIApp {
 	g.dashboard = NewDashboard()
 	g.ledPreview = NewLEDPreview()
 	g.settings = NewSettings(client)
+	g.calibrate = NewCalibration(client)
 	g.health = NewHealthView()
 
 	tabs := container.NewAppTabs(
 		container.NewTabItem("Dashboard", g.dashboard.Container()),
 		container.NewTabItem("LED Preview", g.ledPreview.Container()),
 		container.NewTabItem("Settings", g.settings.Container()),
+		container.NewTabItem("Calibration", g.calibrate.Container()),
 		container.NewTabItem("Health", g.health.Container()),
 	)
 
@@ -174,6 +177,7 @@ func (g *GUIApp) fetchAndUpdate() error {
 		}
 		isDual := matrixMode != "single"
 		g.ledPreview.SetDualMode(isDual, matrixMode)
+		g.ledPreview.SetDisplayMode(status.DisplayMode)
 		g.ledPreview.UpdateFromMetrics(metrics)
 
 		g.statusBar.SetText("Connected | Mode: " + status.DisplayMode +
@@ -181,6 +185,7 @@ func (g *GUIApp) fetchAndUpdate() error {
 			" | Matrix: " + matrixMode)
 		g.settings.UpdateFromStatus(status)
 		g.settings.UpdateMatrixInfo(status)
+		g.calibrate.UpdateFromStatus(status)
 		g.ledPreview.SetBrightnessDisplay(status.Brightness)
 
 		g.health.Update(health)
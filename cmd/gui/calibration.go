@@ -0,0 +1,199 @@
+//go:build gui
+
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/api"
+)
+
+// defaultCalibration is the matrix choice that edits the calibration shared by every matrix.
+const defaultCalibration = "All matrices (default)"
+
+// calibrationSlider is a labelled slider for one calibration setting.
+type calibrationSlider struct {
+	label  *widget.Label
+	slider *widget.Slider
+	name   string
+	format string
+}
+
+func newCalibrationSlider(name, format string, minimum, maximum, step float64, onEnded func()) *calibrationSlider {
+	c := &calibrationSlider{
+		label:  widget.NewLabel(""),
+		slider: widget.NewSlider(minimum, maximum),
+		name:   name,
+		format: format,
+	}
+
+	c.slider.Step = step
+	c.slider.OnChanged = func(float64) { c.updateLabel() }
+	c.slider.OnChangeEnded = func(float64) { onEnded() }
+	c.updateLabel()
+
+	return c
+}
+
+func (c *calibrationSlider) set(value float64) {
+	c.slider.SetValue(value)
+	c.updateLabel()
+}
+
+func (c *calibrationSlider) updateLabel() {
+	c.label.SetText(fmt.Sprintf("%s: "+c.format, c.name, c.slider.Value))
+}
+
+// Calibration adjusts the gamma and brightness calibration of each matrix, so matrices side by
+// side can be matched by eye. Changes are sent as soon as a slider is released.
+type Calibration struct {
+	client       *api.Client
+	matrixSelect *widget.Select
+	gamma        *calibrationSlider
+	scale        *calibrationSlider
+	offset       *calibrationSlider
+	maxLevel     *calibrationSlider
+	statusLabel  *widget.Label
+	container    *fyne.Container
+
+	// Last calibration reported by the daemon for each matrix; "" is the default
+	calibrations map[string]api.Calibration
+	selected     string
+
+	// Once the user moves a slider, daemon polls stop overwriting it until another matrix is chosen
+	userEdited bool
+}
+
+// NewCalibration creates the calibration screen.
+func NewCalibration(client *api.Client) *Calibration {
+	c := &Calibration{
+		client:       client,
+		statusLabel:  widget.NewLabel(""),
+		calibrations: make(map[string]api.Calibration),
+	}
+
+	c.gamma = newCalibrationSlider("Gamma", "%.1f", 1, 3, 0.1, c.apply)
+	c.scale = newCalibrationSlider("Brightness scale", "%.2f", 0.25, 2, 0.05, c.apply)
+	c.offset = newCalibrationSlider("Brightness offset", "%+.0f", -64, 64, 1, c.apply)
+	c.maxLevel = newCalibrationSlider("Maximum brightness", "%.0f", 1, 255, 1, c.apply)
+
+	c.matrixSelect = widget.NewSelect([]string{defaultCalibration}, func(choice string) {
+		c.selected = ""
+		if choice != defaultCalibration {
+			c.selected = choice
+		}
+
+		c.userEdited = false
+		c.show(c.calibrations[c.selected])
+	})
+	c.matrixSelect.SetSelected(defaultCalibration)
+
+	resetButton := widget.NewButton("Reset", func() {
+		c.show(api.Calibration{})
+		c.apply()
+	})
+
+	sliders := container.NewVBox()
+	for _, s := range []*calibrationSlider{c.gamma, c.scale, c.offset, c.maxLevel} {
+		sliders.Add(s.label)
+		sliders.Add(s.slider)
+	}
+
+	c.container = container.NewVBox(
+		widget.NewLabelWithStyle("Calibration", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewSeparator(),
+		widget.NewLabel("Matrix:"),
+		c.matrixSelect,
+		widget.NewLabel("Gamma evens out greyscale steps; scale, offset and maximum match brightness between matrices."),
+		sliders,
+		resetButton,
+		widget.NewSeparator(),
+		c.statusLabel,
+	)
+
+	return c
+}
+
+// Container returns the calibration screen's Fyne container.
+func (c *Calibration) Container() *fyne.Container {
+	return c.container
+}
+
+// UpdateFromStatus refreshes the matrix list and the calibration shown from the daemon.
+func (c *Calibration) UpdateFromStatus(status *api.StatusResult) {
+	if status == nil {
+		return
+	}
+
+	options := []string{defaultCalibration}
+	c.calibrations[""] = status.Calibration
+
+	for _, m := range status.Matrices {
+		if m.Name == "" {
+			continue
+		}
+
+		options = append(options, m.Name)
+		c.calibrations[m.Name] = m.Calibration
+	}
+
+	if !slices.Equal(options, c.matrixSelect.Options) {
+		c.matrixSelect.SetOptions(options)
+	}
+
+	if !c.userEdited {
+		c.show(c.calibrations[c.selected])
+	}
+}
+
+// show sets the sliders to a calibration, showing unset values as the identity they stand for.
+func (c *Calibration) show(calibration api.Calibration) {
+	gamma := calibration.Gamma
+	if gamma == 0 {
+		gamma = 1
+	}
+
+	scale := calibration.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	maxLevel := calibration.Max
+	if maxLevel == 0 {
+		maxLevel = 255
+	}
+
+	c.gamma.set(gamma)
+	c.scale.set(scale)
+	c.offset.set(float64(calibration.Offset))
+	c.maxLevel.set(float64(maxLevel))
+}
+
+// apply sends the calibration on the sliders to the daemon.
+func (c *Calibration) apply() {
+	c.userEdited = true
+
+	calibration := api.Calibration{
+		Gamma:  c.gamma.slider.Value,
+		Scale:  c.scale.slider.Value,
+		Offset: int(c.offset.slider.Value),
+		Max:    int(c.maxLevel.slider.Value),
+	}
+
+	name := c.selected
+	if name == "" {
+		name = "all matrices"
+	}
+
+	if err := c.client.SetCalibration(c.selected, calibration); err != nil {
+		c.statusLabel.SetText("Error: " + err.Error())
+	} else {
+		c.calibrations[c.selected] = calibration
+		c.statusLabel.SetText("Calibration updated for " + name)
+	}
+}
@@ -140,6 +140,9 @@ func (d *Dashboard) UpdateMatrixInfo(status *api.StatusResult) {
 	if mode == "" {
 		mode = "single"
 	}
+	if status.Firmware != "" {
+		mode += ", firmware " + status.Firmware
+	}
 	d.matrixModeLabel.SetText(fmt.Sprintf("Matrix Mode: %s", mode))
 
 	// Rebuild per-matrix info
@@ -149,6 +152,9 @@ func (d *Dashboard) UpdateMatrixInfo(status *api.StatusResult) {
 	if len(status.Matrices) > 0 {
 		for _, m := range status.Matrices {
 			info := fmt.Sprintf("  %s (%s)", m.Name, m.Role)
+			if m.Firmware != "" {
+				info += " firmware " + m.Firmware
+			}
 			if len(m.Metrics) > 0 {
 				info += " — metrics: "
 				for i, metric := range m.Metrics {
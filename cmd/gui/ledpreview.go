@@ -14,6 +14,8 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/api"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/visualizer"
 )
 
 const (
@@ -93,6 +95,28 @@ func (mg *MatrixGrid) renderPercentageBar(percentage float64) {
 	}
 }
 
+// renderFramebuffer lights each cell with the greyscale level of the matching framebuffer pixel.
+func (mg *MatrixGrid) renderFramebuffer(fb *matrix.Framebuffer) {
+	for row := 0; row < matrixRows; row++ {
+		for col := 0; col < matrixCols; col++ {
+			if level := fb.Pixel(col, row); level > 0 {
+				mg.cells[row][col].FillColor = color.NRGBA{R: level, G: level, B: level, A: 255}
+			} else {
+				mg.cells[row][col].FillColor = defaultCellColor
+			}
+
+			mg.cells[row][col].Refresh()
+		}
+	}
+}
+
+// renderCores draws one bar per logical core, laid out as the daemon's cores mode does.
+func (mg *MatrixGrid) renderCores(loads []float64) {
+	fb := matrix.NewFramebuffer()
+	visualizer.RenderCores(fb, loads, 255)
+	mg.renderFramebuffer(fb)
+}
+
 func (mg *MatrixGrid) clear() {
 	for row := 0; row < matrixRows; row++ {
 		for col := 0; col < matrixCols; col++ {
@@ -112,6 +136,7 @@ type LEDPreview struct {
 	container   *fyne.Container
 	dualMode    bool
 	matrixMode  string
+	displayMode string
 }
 
 // NewLEDPreview creates a new LED matrix preview widget.
@@ -185,6 +210,11 @@ func (l *LEDPreview) SetDualMode(isDual bool, mode string) {
 	l.gridArea.Refresh()
 }
 
+// SetDisplayMode sets the daemon display mode the preview imitates.
+func (l *LEDPreview) SetDisplayMode(mode string) {
+	l.displayMode = mode
+}
+
 // UpdateFromMetrics generates LED patterns based on current metrics.
 func (l *LEDPreview) UpdateFromMetrics(m *api.MetricsResult) {
 	if m == nil {
@@ -193,6 +223,15 @@ func (l *LEDPreview) UpdateFromMetrics(m *api.MetricsResult) {
 
 	l.modeLabel.SetText(fmt.Sprintf("Mode: %s", m.Status))
 
+	if l.displayMode == "cores" {
+		l.primary.renderCores(m.PerCoreUsage)
+		if l.dualMode && l.secondary != nil {
+			l.secondary.renderCores(m.PerCoreUsage)
+		}
+
+		return
+	}
+
 	if l.dualMode && l.secondary != nil {
 		switch strings.ToLower(l.matrixMode) {
 		case "split":
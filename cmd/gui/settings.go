@@ -47,7 +47,7 @@ func NewSettings(client *api.Client) *Settings {
 
 	// Display mode
 	s.modeSelect = widget.NewSelect(
-		[]string{"percentage", "gradient", "activity", "status"},
+		[]string{"percentage", "gradient", "activity", "status", "history", "cores", "zones"},
 		func(mode string) {
 			s.userEditedMode = true
 			if err := client.SetDisplayMode(mode); err != nil {
@@ -227,6 +227,9 @@ func (s *Settings) UpdateMatrixInfo(status *api.StatusResult) {
 
 		info := fmt.Sprintf("%s (%s) — brightness: %d, metrics: %s",
 			name, m.Role, m.Brightness, metricsStr)
+		if m.Firmware != "" {
+			info += ", firmware: " + m.Firmware
+		}
 		s.matrixInfoContainer.Add(widget.NewLabel(info))
 	}
 
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// deviceRefreshInterval is how often the emulated module's LEDs are redrawn when they change.
+const deviceRefreshInterval = 100 * time.Millisecond
+
+// runDevice acts as a Framework LED matrix on a new pseudo-terminal for the given duration,
+// drawing whatever the daemon shows on it.
+func runDevice(duration time.Duration) error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+
+	defer master.Close() //nolint:errcheck // best-effort cleanup
+	defer slave.Close()  //nolint:errcheck // best-effort cleanup
+
+	vm := matrix.NewVirtualMatrix()
+
+	fmt.Printf("Emulating a Framework LED matrix on %s\n", slave.Name())
+	fmt.Printf("Drive it with: framework-led-daemon -port %s run\n", slave.Name())
+
+	go func() {
+		if err := serveDevice(master, vm); err != nil {
+			log.Printf("Device stopped: %v", err)
+		}
+	}()
+
+	ticker := time.NewTicker(deviceRefreshInterval)
+	defer ticker.Stop()
+
+	deadline := time.After(duration)
+	drawn := -1
+
+	for {
+		select {
+		case <-deadline:
+			fmt.Println("\nSimulation completed!")
+
+			return nil
+		case <-ticker.C:
+			if n := vm.Commands(); n != drawn {
+				drawn = n
+
+				printDevice(os.Stdout, vm, slave.Name())
+			}
+		}
+	}
+}
+
+// serveDevice feeds the bytes read from rw to vm and writes back any replies, until reading fails.
+func serveDevice(rw io.ReadWriter, vm *matrix.VirtualMatrix) error {
+	buf := make([]byte, 4096)
+	reply := make([]byte, 64)
+
+	for {
+		n, err := rw.Read(buf)
+		if n > 0 {
+			if _, err := vm.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to apply commands: %w", err)
+			}
+
+			for {
+				m, _ := vm.Read(reply)
+				if m == 0 {
+					break
+				}
+
+				if _, err := rw.Write(reply[:m]); err != nil {
+					return fmt.Errorf("failed to write reply: %w", err)
+				}
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read from device: %w", err)
+		}
+	}
+}
+
+// printDevice draws the LEDs the emulated module is showing, with its other state.
+func printDevice(w io.Writer, vm *matrix.VirtualMatrix, port string) {
+	fmt.Fprintf(w, "\r\033[2J\033[H") // Clear screen
+	fmt.Fprintf(w, "📟 Virtual LED matrix on %s | Commands: %d\n", port, vm.Commands())
+	fmt.Fprintf(w, "💡 Brightness: %d/255 | Animate: %t | Sleeping: %t\n\n",
+		vm.Brightness(), vm.Animating(), vm.Sleeping())
+
+	if pattern := vm.Pattern(); pattern != nil {
+		fmt.Fprintf(w, "Pattern: 0x%02X %v\n", pattern[0], pattern[1:])
+	}
+
+	renderPattern(w, createBitmapPattern(vm.Frame().Threshold(1)))
+}
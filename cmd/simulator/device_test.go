@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func TestServeDeviceAnswersVersion(t *testing.T) {
+	daemonSide, deviceSide := net.Pipe()
+	vm := matrix.NewVirtualMatrix()
+
+	done := make(chan error, 1)
+
+	go func() { done <- serveDevice(deviceSide, vm) }()
+
+	if _, err := daemonSide.Write(append(matrix.BrightnessCommand(40).ToBytes(), matrix.VersionCommand().ToBytes()...)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	version := make([]byte, matrix.VersionResponseSize)
+	if _, err := io.ReadFull(daemonSide, version); err != nil {
+		t.Fatalf("reading version reply error = %v", err)
+	}
+
+	if vm.Brightness() != 40 {
+		t.Errorf("brightness = %d, want 40", vm.Brightness())
+	}
+
+	daemonSide.Close()
+
+	if err := <-done; err == nil {
+		t.Error("serveDevice() should return an error once the other side closes")
+	}
+}
+
+func TestPrintDevice(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	_, _ = vm.Write(matrix.PercentageCommand(50).ToBytes())
+
+	var out bytes.Buffer
+	printDevice(&out, vm, "/dev/pts/7")
+
+	text := out.String()
+	if !strings.Contains(text, "/dev/pts/7") || !strings.Contains(text, "Brightness: 255/255") {
+		t.Errorf("printDevice() header missing port or brightness:\n%s", text)
+	}
+
+	// Half of each row lit, on the right
+	row := "│" + strings.Repeat("░", 17) + strings.Repeat("█", 17) + "│"
+	if strings.Count(text, row) != LEDHeight {
+		t.Errorf("printDevice() should draw %d half-lit rows:\n%s", LEDHeight, text)
+	}
+}
+
+func TestPTYDevice(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminal devices are only supported on Linux")
+	}
+
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("no pseudo-terminal available: %v", err)
+	}
+
+	defer master.Close()
+	defer slave.Close()
+
+	vm := matrix.NewVirtualMatrix()
+
+	go func() { _ = serveDevice(master, vm) }()
+
+	client := matrix.NewClient()
+	if err := client.Connect(slave.Name()); err != nil {
+		t.Fatalf("Connect(%s) error = %v", slave.Name(), err)
+	}
+	defer client.Disconnect()
+
+	if err := client.ShowFullBright(); err != nil {
+		t.Fatalf("ShowFullBright() error = %v", err)
+	}
+
+	version, err := client.GetVersion()
+	if err != nil || len(version) != matrix.VersionResponseSize {
+		t.Fatalf("GetVersion() = %v, %v, want a version reply over the pseudo-terminal", version, err)
+	}
+
+	if got := vm.Pattern(); len(got) != 1 || got[0] != matrix.PatternFullBright {
+		t.Errorf("pattern = %v, want full bright", got)
+	}
+}
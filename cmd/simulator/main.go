@@ -3,11 +3,13 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/visualizer"
 )
@@ -26,11 +28,12 @@ var (
 func main() {
 	var (
 		configPath  = flag.String("config", "", "Path to configuration file")
-		mode        = flag.String("mode", "percentage", "Display mode: percentage, gradient, activity, status")
+		mode        = flag.String("mode", "percentage", "Display mode: percentage, gradient, activity, status, history, cores, zones")
 		metric      = flag.String("metric", "cpu", "Primary metric: cpu, memory, disk, network")
 		duration    = flag.Duration("duration", 30*time.Second, "How long to run simulation")
 		interval    = flag.Duration("interval", 2*time.Second, "Update interval")
 		showVersion = flag.Bool("version", false, "Show version information")
+		pty         = flag.Bool("pty", false, "Act as a Framework LED matrix on a new pseudo-terminal for the daemon to drive")
 	)
 
 	flag.Parse()
@@ -54,6 +57,15 @@ func main() {
 
 	fmt.Println("🔥 Framework LED Matrix Simulator")
 	fmt.Println("=================================")
+
+	if *pty {
+		if err := runDevice(*duration); err != nil {
+			log.Fatalf("Failed to emulate device: %v", err)
+		}
+
+		return
+	}
+
 	fmt.Printf("Mode: %s | Metric: %s | Duration: %v\n\n", *mode, *metric, *duration)
 
 	// Load configuration
@@ -75,6 +87,8 @@ func main() {
 	// Initialize components
 	collector := stats.NewCollector(time.Second)
 	viz := visualizer.NewVisualizer(&MockDisplayManager{}, cfg)
+	history := stats.NewHistory(cfg.Display.History.Window)
+	viz.SetHistory(history)
 
 	fmt.Println("Starting simulation...")
 	fmt.Println("Press Ctrl+C to stop")
@@ -104,6 +118,8 @@ func main() {
 				continue
 			}
 
+			history.Add(*summary)
+
 			// Update display
 			err = viz.UpdateDisplay(summary)
 			if err != nil {
@@ -113,7 +129,7 @@ func main() {
 			}
 
 			// Print current state
-			printSimulatedDisplay(summary, cfg)
+			printSimulatedDisplay(summary, cfg, history)
 
 		default:
 			if time.Since(start) > *duration {
@@ -174,6 +190,20 @@ func (m *MockDisplayManager) SetBrightness(level byte) error {
 	return nil
 }
 
+func (m *MockDisplayManager) DrawBitmap(pixels [39]byte) error {
+	m.currentPattern = createBitmapPattern(matrix.UnpackBitmap(pixels))
+	m.lastUpdate = time.Now()
+
+	return nil
+}
+
+func (m *MockDisplayManager) DrawFrame(fb *matrix.Framebuffer) error {
+	m.currentPattern = createBitmapPattern(fb.Threshold(1))
+	m.lastUpdate = time.Now()
+
+	return nil
+}
+
 func (m *MockDisplayManager) GetCurrentState() map[string]interface{} {
 	return map[string]interface{}{
 		"brightness":   m.brightness,
@@ -237,6 +267,75 @@ func createSolidPattern() []byte {
 	return pattern
 }
 
+// createHistoryPattern graphs the CPU or memory history the way the daemon's history mode does.
+func createHistoryPattern(history *stats.History, cfg *config.Config) []byte {
+	metric := cfg.Display.History.Metric
+	if metric == "" {
+		metric = cfg.Display.PrimaryMetric
+	}
+
+	var values []float64
+
+	if history != nil {
+		for _, sample := range history.Samples() {
+			switch metric {
+			case "memory":
+				values = append(values, sample.MemoryUsage)
+			case "disk":
+				values = append(values, (sample.DiskActivity/(10*1024*1024))*100)
+			case "network":
+				values = append(values, (sample.NetworkActivity/(10*1024*1024))*100)
+			default:
+				values = append(values, sample.CPUUsage)
+			}
+		}
+	}
+
+	fb := matrix.NewFramebuffer()
+	visualizer.RenderHistory(fb, values, 255)
+
+	return createBitmapPattern(fb.Threshold(1))
+}
+
+// createZonesPattern draws the configured zones the way the daemon's zones mode does. Each call
+// starts a new layout, so sparkline zones only show the current sample.
+func createZonesPattern(summary *stats.StatsSummary, cfg *config.Config) []byte {
+	layout, err := visualizer.NewLayout(cfg.Display.Zones, cfg.Display.PrimaryMetric, LEDWidth, LEDHeight)
+	if err != nil {
+		return createGradientPattern()
+	}
+
+	fb := matrix.NewFramebuffer()
+	layout.Render(fb, func(metric string) float64 {
+		switch metric {
+		case "memory":
+			return summary.MemoryUsage
+		case "disk":
+			return (summary.DiskActivity / (10 * 1024 * 1024)) * 100
+		case "network":
+			return (summary.NetworkActivity / (10 * 1024 * 1024)) * 100
+		default:
+			return summary.CPUUsage
+		}
+	}, 255)
+
+	return createBitmapPattern(fb.Threshold(1))
+}
+
+func createBitmapPattern(bitmap matrix.Bitmap) []byte {
+	pattern := make([]byte, LEDWidth*LEDHeight)
+
+	for row := 0; row < LEDHeight; row++ {
+		for col := 0; col < LEDWidth; col++ {
+			if bitmap[row][col] {
+				pattern[row*LEDWidth+col] = 1
+			}
+		}
+	}
+
+	return pattern
+}
+
 func abs(x int) int {
 	if x < 0 {
 		return -x
@@ -245,8 +344,29 @@ func abs(x int) int {
 	return x
 }
 
+// renderPattern draws a pattern of lit and unlit LEDs inside a box.
+func renderPattern(w io.Writer, pattern []byte) {
+	fmt.Fprintln(w, "┌──────────────────────────────────┐")
+
+	for row := 0; row < LEDHeight; row++ {
+		fmt.Fprint(w, "│")
+
+		for col := 0; col < LEDWidth; col++ {
+			if pattern[row*LEDWidth+col] == 1 {
+				fmt.Fprint(w, "█")
+			} else {
+				fmt.Fprint(w, "░")
+			}
+		}
+
+		fmt.Fprintln(w, "│")
+	}
+
+	fmt.Fprintln(w, "└──────────────────────────────────┘")
+}
+
 // Print ASCII representation of the LED matrix.
-func printSimulatedDisplay(summary *stats.StatsSummary, cfg *config.Config) {
+func printSimulatedDisplay(summary *stats.StatsSummary, cfg *config.Config, history *stats.History) {
 	fmt.Printf("\r\033[2J\033[H") // Clear screen
 
 	fmt.Printf("⏰ %s | Mode: %s | Metric: %s\n",
@@ -259,7 +379,6 @@ func printSimulatedDisplay(summary *stats.StatsSummary, cfg *config.Config) {
 
 	// Simulate the LED matrix display
 	fmt.Println("🔲 LED Matrix Simulation (34x9):")
-	fmt.Println("┌──────────────────────────────────┐")
 
 	var pattern []byte
 
@@ -302,26 +421,22 @@ func printSimulatedDisplay(summary *stats.StatsSummary, cfg *config.Config) {
 			pattern = createSolidPattern()
 		}
 
-	default: // gradient
-		pattern = createGradientPattern()
-	}
+	case "history":
+		pattern = createHistoryPattern(history, cfg)
 
-	// Render the pattern
-	for row := 0; row < LEDHeight; row++ {
-		fmt.Print("│")
+	case "cores":
+		fb := matrix.NewFramebuffer()
+		visualizer.RenderCores(fb, summary.PerCoreUsage, 255)
+		pattern = createBitmapPattern(fb.Threshold(1))
 
-		for col := 0; col < LEDWidth; col++ {
-			if pattern[row*LEDWidth+col] == 1 {
-				fmt.Print("█")
-			} else {
-				fmt.Print("░")
-			}
-		}
+	case "zones":
+		pattern = createZonesPattern(summary, cfg)
 
-		fmt.Println("│")
+	default: // gradient
+		pattern = createGradientPattern()
 	}
 
-	fmt.Println("└──────────────────────────────────┘")
+	renderPattern(os.Stdout, pattern)
 	fmt.Printf("\n💡 Brightness: %d/255 | Updates: %s\n",
 		cfg.Matrix.Brightness, cfg.Display.UpdateRate)
 }
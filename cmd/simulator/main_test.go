@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
 )
 
@@ -103,6 +104,35 @@ func TestMockDisplayManager(t *testing.T) {
 		}
 	})
 
+	t.Run("DrawBitmap", func(t *testing.T) {
+		var bitmap matrix.Bitmap
+
+		bitmap[2][5] = true
+
+		err := manager.DrawBitmap(matrix.PackBitmap(bitmap))
+		if err != nil {
+			t.Errorf("DrawBitmap failed: %v", err)
+		}
+
+		if manager.currentPattern[2*LEDWidth+5] != 1 {
+			t.Error("Expected bitmap pixel to be lit in currentPattern")
+		}
+	})
+
+	t.Run("DrawFrame", func(t *testing.T) {
+		fb := matrix.NewFramebuffer()
+		fb.SetPixel(7, 4, 64)
+
+		err := manager.DrawFrame(fb)
+		if err != nil {
+			t.Errorf("DrawFrame failed: %v", err)
+		}
+
+		if manager.currentPattern[4*LEDWidth+7] != 1 {
+			t.Error("Expected frame pixel to be lit in currentPattern")
+		}
+	})
+
 	t.Run("SetUpdateRate", func(t *testing.T) {
 		// Should not panic
 		manager.SetUpdateRate(time.Second)
@@ -210,11 +240,14 @@ func TestPrintSimulatedDisplay(t *testing.T) {
 		MemoryUsage:     70.0,
 		DiskActivity:    1024 * 1024,
 		NetworkActivity: 512 * 1024,
+		PerCoreUsage:    []float64{10, 90, 50, 0},
 		Status:          stats.StatusNormal,
 	}
 
 	// Test different display modes
-	modes := []string{"percentage", "activity", "status", "gradient"}
+	modes := []string{"percentage", "activity", "status", "gradient", "history", "cores", "zones"}
+	history := stats.NewHistory(cfg.Display.History.Window)
+	history.Add(*summary)
 	metrics := []string{"cpu", "memory", "disk", "network"}
 
 	for _, mode := range modes {
@@ -230,7 +263,7 @@ func TestPrintSimulatedDisplay(t *testing.T) {
 					}
 				}()
 
-				printSimulatedDisplay(summary, cfg)
+				printSimulatedDisplay(summary, cfg, history)
 			})
 		}
 	}
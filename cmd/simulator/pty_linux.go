@@ -0,0 +1,77 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openPTY creates a pseudo-terminal and returns its master side and its slave side, whose name
+// is the port to hand to the daemon. The slave is put in raw mode so protocol bytes pass through
+// unchanged, and is kept open so that the master stays readable while the daemon reconnects.
+func openPTY() (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal: %w", err)
+	}
+
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK,
+		uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		_ = master.Close() //nolint:errcheck // best-effort cleanup
+
+		return nil, nil, fmt.Errorf("failed to unlock pseudo-terminal: %w", errno)
+	}
+
+	var number uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN,
+		uintptr(unsafe.Pointer(&number))); errno != 0 {
+		_ = master.Close() //nolint:errcheck // best-effort cleanup
+
+		return nil, nil, fmt.Errorf("failed to get pseudo-terminal number: %w", errno)
+	}
+
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		_ = master.Close() //nolint:errcheck // best-effort cleanup
+
+		return nil, nil, fmt.Errorf("failed to open pseudo-terminal slave: %w", err)
+	}
+
+	if err := makeRaw(slave); err != nil {
+		_ = slave.Close()  //nolint:errcheck // best-effort cleanup
+		_ = master.Close() //nolint:errcheck // best-effort cleanup
+
+		return nil, nil, err
+	}
+
+	return master, slave, nil
+}
+
+// makeRaw disables all input and output processing on a terminal, like cfmakeraw(3).
+func makeRaw(f *os.File) error {
+	var t syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCGETS,
+		uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return fmt.Errorf("failed to get terminal attributes: %w", errno)
+	}
+
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	t.Oflag &^= syscall.OPOST
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.Cflag &^= syscall.CSIZE | syscall.PARENB
+	t.Cflag |= syscall.CS8
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS,
+		uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return fmt.Errorf("failed to set terminal attributes: %w", errno)
+	}
+
+	return nil
+}
@@ -357,3 +357,18 @@ func (c *Client) SetDualMode(mode string) error {
 
 	return nil
 }
+
+// SetCalibration changes the calibration of the named matrix on the daemon, or the default
+// calibration if matrix is empty.
+func (c *Client) SetCalibration(matrix string, calibration Calibration) error {
+	resp, err := c.Call(MethodMatrixSetCalib, SetCalibrationParams{Matrix: matrix, Calibration: calibration})
+	if err != nil {
+		return err
+	}
+
+	if resp.Error != nil {
+		return fmt.Errorf("API error: %s", resp.Error.Message)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
@@ -32,6 +34,7 @@ func (s *Server) handleMetricsGet(req Request) Response {
 
 	result := MetricsResult{
 		CPUUsage:        summary.CPUUsage,
+		PerCoreUsage:    summary.PerCoreUsage,
 		MemoryUsage:     summary.MemoryUsage,
 		DiskActivity:    summary.DiskActivity,
 		NetworkActivity: summary.NetworkActivity,
@@ -351,10 +354,16 @@ func (s *Server) handleStatusGet(req Request) Response {
 		Connected: s.display != nil,
 	}
 
+	var firmware map[string]string
+	if s.display != nil {
+		firmware = s.display.FirmwareVersions()
+	}
+
 	if cfg != nil {
 		result.DisplayMode = cfg.Display.Mode
 		result.PrimaryMetric = cfg.Display.PrimaryMetric
 		result.Brightness = int(cfg.Matrix.Brightness)
+		result.Calibration = calibrationResult(cfg.Matrix.Calibration)
 
 		if cfg.Matrix.DualMode != "" {
 			result.MatrixMode = cfg.Matrix.DualMode
@@ -363,15 +372,18 @@ func (s *Server) handleStatusGet(req Request) Response {
 			matrices := cfg.ConvertMatrices()
 			for _, m := range matrices {
 				result.Matrices = append(result.Matrices, MatrixInfo{
-					Name:       m.Name,
-					Role:       m.Role,
-					Metrics:    m.Metrics,
-					Brightness: int(m.Brightness),
-					Connected:  s.display != nil,
+					Name:        m.Name,
+					Role:        m.Role,
+					Firmware:    firmware[m.Name],
+					Metrics:     m.Metrics,
+					Calibration: calibrationResult(m.Calibration),
+					Brightness:  int(m.Brightness),
+					Connected:   s.display != nil,
 				})
 			}
 		} else {
 			result.MatrixMode = MatrixModeSingle
+			result.Firmware = firmware["single"]
 		}
 	}
 
@@ -450,3 +462,85 @@ func (s *Server) handleMatrixSetDualMode(req Request) Response {
 
 	return okResponse(req.ID)
 }
+
+// handleMatrixSetCalibration changes the calibration of one matrix, or the default calibration.
+func (s *Server) handleMatrixSetCalibration(req Request) Response {
+	var params SetCalibrationParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return Response{
+			ID:    req.ID,
+			Error: &ErrorInfo{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)},
+		}
+	}
+
+	if params.Calibration.Max < 0 || params.Calibration.Max > 255 {
+		return Response{
+			ID:    req.ID,
+			Error: &ErrorInfo{Code: ErrCodeInvalidParams, Message: "calibration max must be 0-255"},
+		}
+	}
+
+	cfg := s.getConfig()
+	if cfg == nil {
+		return Response{
+			ID:    req.ID,
+			Error: &ErrorInfo{Code: ErrCodeInternal, Message: "config not available"},
+		}
+	}
+
+	// Change a copy, so the running config is only replaced once the new one is valid
+	updated := *cfg
+	calibration := params.Calibration
+
+	if params.Matrix == "" {
+		updated.Matrix.Calibration = config.CalibrationConfig{
+			Gamma:  calibration.Gamma,
+			Scale:  calibration.Scale,
+			Offset: calibration.Offset,
+			Max:    byte(calibration.Max), //nolint:gosec // G115: max validated 0-255 above
+		}
+	} else {
+		index := slices.IndexFunc(cfg.Matrix.Matrices, func(m map[string]interface{}) bool {
+			return m["name"] == params.Matrix
+		})
+		if index < 0 {
+			return Response{
+				ID:    req.ID,
+				Error: &ErrorInfo{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("unknown matrix: %s", params.Matrix)},
+			}
+		}
+
+		matrix := maps.Clone(cfg.Matrix.Matrices[index])
+		matrix["calibration"] = map[string]interface{}{
+			"gamma":  calibration.Gamma,
+			"scale":  calibration.Scale,
+			"offset": calibration.Offset,
+			"max":    calibration.Max,
+		}
+
+		updated.Matrix.Matrices = slices.Clone(cfg.Matrix.Matrices)
+		updated.Matrix.Matrices[index] = matrix
+	}
+
+	if err := updated.Validate(); err != nil {
+		return Response{
+			ID:    req.ID,
+			Error: &ErrorInfo{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("validation failed: %v", err)},
+		}
+	}
+
+	s.configMu.Lock()
+	s.config = &updated
+	s.configMu.Unlock()
+
+	if s.ConfigUpdateFunc != nil {
+		s.ConfigUpdateFunc(&updated)
+	}
+
+	return okResponse(req.ID)
+}
+
+// calibrationResult converts a matrix calibration for the API.
+func calibrationResult(c config.CalibrationConfig) Calibration {
+	return Calibration{Gamma: c.Gamma, Scale: c.Scale, Offset: c.Offset, Max: int(c.Max)}
+}
@@ -474,3 +474,71 @@ func TestHandleMatrixSetDualModeUpdatesConfig(t *testing.T) {
 		t.Errorf("expected DualMode empty after single, got %q", got.Matrix.DualMode)
 	}
 }
+
+func TestHandleMatrixSetCalibration(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Matrix.DualMode = "mirror"
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "role": "primary"},
+		{"name": "right", "role": "secondary"},
+	}
+
+	server, client := setupTestServer(t, ServerConfig{
+		Config:  cfg,
+		Display: &mockDisplayController{},
+	})
+
+	var updated *config.Config
+
+	server.ConfigUpdateFunc = func(cfg *config.Config) { updated = cfg }
+
+	if err := client.SetCalibration("", Calibration{Gamma: 2.2}); err != nil {
+		t.Fatalf("SetCalibration() for the default error = %v", err)
+	}
+
+	if err := client.SetCalibration("right", Calibration{Scale: 0.8, Max: 200}); err != nil {
+		t.Fatalf("SetCalibration() for a matrix error = %v", err)
+	}
+
+	if updated == nil {
+		t.Fatal("expected ConfigUpdateFunc to be called")
+	}
+
+	matrices := updated.ConvertMatrices()
+	if matrices[0].Calibration.Gamma != 2.2 || matrices[1].Calibration.Scale != 0.8 || matrices[1].Calibration.Max != 200 {
+		t.Errorf("calibrations = %+v, %+v; want the default gamma on the left and the override on the right",
+			matrices[0].Calibration, matrices[1].Calibration)
+	}
+
+	if _, ok := cfg.Matrix.Matrices[1]["calibration"]; ok {
+		t.Error("SetCalibration() changed the old config in place")
+	}
+
+	status, err := client.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+
+	if status.Calibration.Gamma != 2.2 || status.Matrices[1].Calibration.Max != 200 {
+		t.Errorf("status calibration = %+v, %+v", status.Calibration, status.Matrices[1].Calibration)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		matrix string
+		cal    Calibration
+	}{
+		{"unknown matrix", "middle", Calibration{}},
+		{"gamma out of range", "", Calibration{Gamma: 10}},
+		{"max out of range", "left", Calibration{Max: 300}},
+	} {
+		resp, err := client.Call(MethodMatrixSetCalib, SetCalibrationParams{Matrix: tt.matrix, Calibration: tt.cal})
+		if err != nil {
+			t.Fatalf("%s: failed to call: %v", tt.name, err)
+		}
+
+		if resp.Error == nil || resp.Error.Code != ErrCodeInvalidParams {
+			t.Errorf("%s: error = %+v, want invalid params", tt.name, resp.Error)
+		}
+	}
+}
@@ -17,6 +17,7 @@ const (
 	MethodStatusGet         = "status.get"
 	MethodMatrixGetState    = "matrix.get_state"
 	MethodMatrixSetDualMode = "matrix.set_dual_mode"
+	MethodMatrixSetCalib    = "matrix.set_calibration"
 )
 
 // Matrix mode constants.
@@ -54,21 +55,32 @@ const (
 
 // MetricsResult contains a snapshot of system metrics.
 type MetricsResult struct {
-	Status          string  `json:"status"`
-	Timestamp       string  `json:"timestamp"`
-	CPUUsage        float64 `json:"cpu_usage"`
-	MemoryUsage     float64 `json:"memory_usage"`
-	DiskActivity    float64 `json:"disk_activity"`
-	NetworkActivity float64 `json:"network_activity"`
+	Status          string    `json:"status"`
+	Timestamp       string    `json:"timestamp"`
+	PerCoreUsage    []float64 `json:"per_core_usage,omitempty"`
+	CPUUsage        float64   `json:"cpu_usage"`
+	MemoryUsage     float64   `json:"memory_usage"`
+	DiskActivity    float64   `json:"disk_activity"`
+	NetworkActivity float64   `json:"network_activity"`
 }
 
 // MatrixInfo describes a single matrix in a dual-matrix setup.
 type MatrixInfo struct {
-	Name       string   `json:"name"`
-	Role       string   `json:"role"`
-	Metrics    []string `json:"metrics,omitempty"`
-	Brightness int      `json:"brightness"`
-	Connected  bool     `json:"connected"`
+	Name        string      `json:"name"`
+	Role        string      `json:"role"`
+	Firmware    string      `json:"firmware,omitempty"`
+	Metrics     []string    `json:"metrics,omitempty"`
+	Calibration Calibration `json:"calibration"`
+	Brightness  int         `json:"brightness"`
+	Connected   bool        `json:"connected"`
+}
+
+// Calibration is the brightness and gamma correction for a matrix. Zero values change nothing.
+type Calibration struct {
+	Gamma  float64 `json:"gamma"`
+	Scale  float64 `json:"scale"`
+	Offset int     `json:"offset"`
+	Max    int     `json:"max"`
 }
 
 // StatusResult contains daemon status information.
@@ -77,7 +89,9 @@ type StatusResult struct {
 	DisplayMode   string       `json:"display_mode"`
 	PrimaryMetric string       `json:"primary_metric"`
 	MatrixMode    string       `json:"matrix_mode"`
+	Firmware      string       `json:"firmware,omitempty"`
 	Matrices      []MatrixInfo `json:"matrices,omitempty"`
+	Calibration   Calibration  `json:"calibration"` // Default for every matrix
 	Brightness    int          `json:"brightness"`
 	Connected     bool         `json:"connected"`
 }
@@ -116,3 +130,10 @@ type SubscribeParams struct {
 type SetDualModeParams struct {
 	Mode string `json:"mode"`
 }
+
+// SetCalibrationParams contains parameters for matrix.set_calibration. An empty Matrix sets the
+// default calibration, which also applies in single matrix mode.
+type SetCalibrationParams struct {
+	Matrix      string      `json:"matrix,omitempty"`
+	Calibration Calibration `json:"calibration"`
+}
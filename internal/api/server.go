@@ -27,6 +27,7 @@ type DisplayController interface {
 	SetPrimaryMetric(metric string) error
 	GetDisplayState() map[string]interface{}
 	IsMultiMatrix() bool
+	FirmwareVersions() map[string]string
 }
 
 // ServerConfig holds the configuration for the API server.
@@ -288,6 +289,8 @@ func (s *Server) handleRequest(_ context.Context, _ net.Conn, req Request) Respo
 		return s.handleStatusGet(req)
 	case MethodMatrixGetState:
 		return s.handleMatrixGetState(req)
+	case MethodMatrixSetCalib:
+		return s.handleMatrixSetCalibration(req)
 	case MethodMatrixSetDualMode:
 		return s.handleMatrixSetDualMode(req)
 	default:
@@ -15,6 +15,7 @@ import (
 type mockDisplayController struct {
 	mode       string
 	metric     string
+	firmware   map[string]string
 	brightness byte
 }
 
@@ -48,6 +49,10 @@ func (m *mockDisplayController) IsMultiMatrix() bool {
 	return false
 }
 
+func (m *mockDisplayController) FirmwareVersions() map[string]string {
+	return m.firmware
+}
+
 // waitForSocket polls until the Unix socket at path is connectable or 5 seconds elapses.
 func waitForSocket(t *testing.T, path string) {
 	t.Helper()
@@ -109,7 +114,12 @@ func TestServerClientRoundTrip(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "test-api-roundtrip.sock")
 
 	cfg := config.DefaultConfig()
-	display := &mockDisplayController{mode: "percentage", brightness: 100, metric: "cpu"}
+	display := &mockDisplayController{
+		mode:       "percentage",
+		brightness: 100,
+		metric:     "cpu",
+		firmware:   map[string]string{"single": "0.1.9"},
+	}
 
 	server := NewServer(ServerConfig{
 		SocketPath: socketPath,
@@ -143,6 +153,10 @@ func TestServerClientRoundTrip(t *testing.T) {
 		if !status.Connected {
 			t.Error("expected connected=true")
 		}
+
+		if status.Firmware != "0.1.9" {
+			t.Errorf("expected firmware '0.1.9', got %q", status.Firmware)
+		}
 	})
 
 	t.Run("ConfigGet", func(t *testing.T) {
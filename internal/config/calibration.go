@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CalibrationConfig corrects the output of a module so modules side by side look alike. Gamma
+// curves the brightness and greyscale pixel values so equal steps look equal; Scale, Offset and
+// Max then adjust the brightness sent to the module. The zero value changes nothing.
+type CalibrationConfig struct {
+	Gamma  float64 `yaml:"gamma"`  // 0 or 1 is linear; about 2.2 looks perceptually even
+	Scale  float64 `yaml:"scale"`  // Multiplies the brightness; 0 leaves it as is
+	Offset int     `yaml:"offset"` // Added to the brightness after scaling
+	Max    byte    `yaml:"max"`    // Highest brightness sent; 0 is 255
+}
+
+// Calibration limits.
+const (
+	MaxCalibrationGamma = 4.0
+	MaxCalibrationScale = 4.0
+)
+
+// calibrationField is a setting accepted under matrix.matrices[].calibration.
+type calibrationField struct {
+	set      func(float64)
+	min, max float64
+}
+
+// calibrationFields maps the keys accepted under matrix.matrices[].calibration to the fields they set.
+func calibrationFields(c *CalibrationConfig) map[string]calibrationField {
+	return map[string]calibrationField{
+		"gamma":  {func(v float64) { c.Gamma = v }, 0, MaxCalibrationGamma},
+		"scale":  {func(v float64) { c.Scale = v }, 0, MaxCalibrationScale},
+		"offset": {func(v float64) { c.Offset = int(v) }, -255, 255},
+		"max":    {func(v float64) { c.Max = byte(v) }, 0, 255}, // #nosec G115 - range checked before set is called
+	}
+}
+
+// matrixCalibration returns the global calibration with any per-matrix overrides applied.
+// Overrides with an unknown key or a value out of range are ignored.
+func (c *Config) matrixCalibration(overrides map[string]interface{}) CalibrationConfig {
+	calibration := c.Matrix.Calibration
+	fields := calibrationFields(&calibration)
+
+	for key, value := range overrides {
+		if field, ok := fields[key]; ok {
+			if v, ok := toFloat(value); ok && v >= field.min && v <= field.max {
+				field.set(v)
+			}
+		}
+	}
+
+	return calibration
+}
+
+// validateCalibration checks the global calibration and the overrides of every matrix.
+func (c *Config) validateCalibration() []ValidationError {
+	var errors []ValidationError
+
+	global := c.Matrix.Calibration
+
+	checks := []struct {
+		field    string
+		value    float64
+		min, max float64
+	}{
+		{"gamma", global.Gamma, 0, MaxCalibrationGamma},
+		{"scale", global.Scale, 0, MaxCalibrationScale},
+		{"offset", float64(global.Offset), -255, 255},
+	}
+
+	for _, check := range checks {
+		if check.value < check.min || check.value > check.max {
+			errors = append(errors, ValidationError{
+				Field:   "matrix.calibration." + check.field,
+				Value:   check.value,
+				Message: fmt.Sprintf("must be between %g and %g", check.min, check.max),
+			})
+		}
+	}
+
+	for i, m := range c.Matrix.Matrices {
+		raw, ok := m["calibration"]
+		if !ok {
+			continue
+		}
+
+		field := fmt.Sprintf("matrix.matrices[%d].calibration", i)
+
+		overrides, ok := raw.(map[string]interface{})
+		if !ok {
+			errors = append(errors, ValidationError{
+				Field:   field,
+				Value:   raw,
+				Message: "must be a map with gamma, scale, offset or max",
+			})
+
+			continue
+		}
+
+		keys := make([]string, 0, len(overrides))
+		for key := range overrides {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		fields := calibrationFields(&CalibrationConfig{})
+
+		for _, key := range keys {
+			value := overrides[key]
+
+			spec, known := fields[key]
+			if !known {
+				errors = append(errors, ValidationError{
+					Field:   field + "." + key,
+					Value:   value,
+					Message: "unknown calibration setting (valid: gamma, scale, offset, max)",
+				})
+
+				continue
+			}
+
+			if v, isNumber := toFloat(value); !isNumber || v < spec.min || v > spec.max {
+				errors = append(errors, ValidationError{
+					Field:   field + "." + key,
+					Value:   value,
+					Message: fmt.Sprintf("must be a number between %g and %g", spec.min, spec.max),
+				})
+			}
+		}
+	}
+
+	return errors
+}
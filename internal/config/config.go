@@ -25,6 +25,12 @@ const (
 	stringGradient = "gradient"
 )
 
+// validCaptureFormats are the matrix capture formats; empty means jsonl.
+var validCaptureFormats = map[string]bool{"": true, "jsonl": true, "binary": true}
+
+// validEasings are the display transition easing curves; empty means ease-in-out.
+var validEasings = map[string]bool{"": true, "linear": true, "ease-in": true, "ease-out": true, "ease-in-out": true}
+
 // Config represents the main configuration structure for the Framework LED Matrix daemon.
 // It contains all configuration sections including display, daemon, matrix, logging, stats, and API settings.
 type Config struct {
@@ -45,14 +51,30 @@ type APIConfig struct {
 
 // MatrixConfig holds configuration settings for LED matrix hardware communication.
 // It includes serial port settings, dual matrix support, and device discovery options.
+//
+// Discovery only picks modules with the Framework LED matrix USB VID and PID unless
+// DiscoveryFallback is set, in which case any USB serial port is used when none is found.
 type MatrixConfig struct {
-	Port         string                   `yaml:"port"`
-	DualMode     string                   `yaml:"dual_mode"`
-	Matrices     []map[string]interface{} `yaml:"matrices"`
-	BaudRate     int                      `yaml:"baud_rate"`
-	Timeout      time.Duration            `yaml:"timeout"`
-	AutoDiscover bool                     `yaml:"auto_discover"`
-	Brightness   byte                     `yaml:"brightness"`
+	Port              string                   `yaml:"port"`
+	DualMode          string                   `yaml:"dual_mode"`
+	Layout            string                   `yaml:"layout"`
+	Matrices          []map[string]interface{} `yaml:"matrices"`
+	BaudRate          int                      `yaml:"baud_rate"`
+	Timeout           time.Duration            `yaml:"timeout"`
+	AutoDiscover      bool                     `yaml:"auto_discover"`
+	Capture           CaptureConfig            `yaml:"capture"`
+	Schedule          ScheduleConfig           `yaml:"schedule"`
+	Idle              IdleConfig               `yaml:"idle"`
+	Calibration       CalibrationConfig        `yaml:"calibration"` // Default for every matrix
+	DiscoveryFallback bool                     `yaml:"discovery_fallback"`
+	Brightness        byte                     `yaml:"brightness"`
+}
+
+// CaptureConfig enables recording of every byte sent to and received from the LED matrices,
+// for reproducing display problems with the replay command. Recording is off when File is empty.
+type CaptureConfig struct {
+	File   string `yaml:"file"`
+	Format string `yaml:"format"` // "jsonl" or "binary"
 }
 
 // StatsConfig defines system statistics collection settings.
@@ -81,13 +103,51 @@ type Thresholds struct {
 // It defines display modes, update rates, and custom pattern configurations.
 type DisplayConfig struct {
 	CustomPatterns  map[string]PatternConfig `yaml:"custom_patterns"`
+	History         HistoryConfig            `yaml:"history"`
+	Transition      TransitionConfig         `yaml:"transition"`
+	Zones           []ZoneConfig             `yaml:"zones"`
 	Mode            string                   `yaml:"mode"`
+	CustomPattern   string                   `yaml:"custom_pattern"`
 	PrimaryMetric   string                   `yaml:"primary_metric"`
+	Alerts          AlertConfig              `yaml:"alerts"`
 	UpdateRate      time.Duration            `yaml:"update_rate"`
 	ShowActivity    bool                     `yaml:"show_activity"`
 	EnableAnimation bool                     `yaml:"enable_animation"`
 }
 
+// HistoryConfig controls the "history" display mode, which graphs recent samples of one metric.
+type HistoryConfig struct {
+	Metric string `yaml:"metric"` // Metric to graph; empty uses display.primary_metric
+	Window int    `yaml:"window"` // Number of samples kept, one per column
+}
+
+// TransitionConfig controls how brightness changes, status pattern switches and frames are
+// animated. A zero duration shows every change at once.
+type TransitionConfig struct {
+	Easing   string        `yaml:"easing"`   // linear, ease-in, ease-out or ease-in-out (default)
+	Duration time.Duration `yaml:"duration"` // Length of each fade; 0 disables transitions
+}
+
+// AlertConfig controls the blinking border drawn over the display mode when the system status
+// becomes critical.
+type AlertConfig struct {
+	Duration time.Duration `yaml:"duration"` // How long the alert is shown; 0 shows it until the status recovers
+	Enabled  bool          `yaml:"enabled"`
+}
+
+// MaxHistoryWindow is the largest history window, one sample per column of the 34x9 matrix.
+const MaxHistoryWindow = 34
+
+// historyWindowLimit returns the largest history window for the configured displays. The
+// extended side-by-side canvas is twice as wide as one matrix.
+func (c *Config) historyWindowLimit() int {
+	if c.Matrix.DualMode == "extended" && c.Matrix.Layout != "vertical" {
+		return 2 * MaxHistoryWindow
+	}
+
+	return MaxHistoryWindow
+}
+
 // PatternConfig defines a custom LED display pattern with its parameters.
 // Patterns can be customized through the parameters map for different visual effects.
 type PatternConfig struct {
@@ -132,6 +192,14 @@ func DefaultConfig() *Config {
 			AutoDiscover: true,
 			Timeout:      1 * time.Second,
 			Brightness:   100,
+			Capture:      CaptureConfig{Format: "jsonl"},
+			Idle: IdleConfig{
+				Input:           true,
+				DimAfter:        2 * time.Minute,
+				DimBrightness:   20,
+				SleepAfter:      10 * time.Minute,
+				SleepWhenLocked: true,
+			},
 
 			// Multi-matrix defaults - empty by default, user can configure
 			DualMode: "",
@@ -159,6 +227,17 @@ func DefaultConfig() *Config {
 			ShowActivity:    true,
 			EnableAnimation: false,
 			CustomPatterns:  make(map[string]PatternConfig),
+			History: HistoryConfig{
+				Window: MaxHistoryWindow,
+			},
+			Transition: TransitionConfig{
+				Easing: "ease-in-out",
+			},
+			Alerts: AlertConfig{
+				Enabled:  true,
+				Duration: 10 * time.Second,
+			},
+			Zones: DefaultZones(),
 		},
 		Daemon: DaemonConfig{
 			Name:        "framework-led-daemon",
@@ -249,6 +328,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("matrix baud_rate must be positive")
 	}
 
+	if !validCaptureFormats[c.Matrix.Capture.Format] {
+		return fmt.Errorf("invalid matrix capture format: %s", c.Matrix.Capture.Format)
+	}
+
 	if c.Stats.CollectInterval <= 0 {
 		return fmt.Errorf("stats collect_interval must be positive")
 	}
@@ -263,6 +346,9 @@ func (c *Config) Validate() error {
 		"activity":   true,
 		"status":     true,
 		"custom":     true,
+		"history":    true,
+		"cores":      true,
+		"zones":      true,
 	}
 	if !validModes[c.Display.Mode] {
 		return fmt.Errorf("invalid display mode: %s", c.Display.Mode)
@@ -278,6 +364,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid primary metric: %s", c.Display.PrimaryMetric)
 	}
 
+	if c.Display.History.Metric != "" && !validMetrics[c.Display.History.Metric] {
+		return fmt.Errorf("invalid history metric: %s", c.Display.History.Metric)
+	}
+
+	if limit := c.historyWindowLimit(); c.Display.History.Window < 1 || c.Display.History.Window > limit {
+		return fmt.Errorf("history window must be between 1 and %d", limit)
+	}
+
+	if c.Display.Transition.Duration < 0 {
+		return fmt.Errorf("display transition duration must not be negative")
+	}
+
+	if !validEasings[c.Display.Transition.Easing] {
+		return fmt.Errorf("invalid display transition easing: %s", c.Display.Transition.Easing)
+	}
+
+	if c.Display.Alerts.Duration < 0 {
+		return fmt.Errorf("display alert duration must not be negative")
+	}
+
 	if c.Stats.Thresholds.CPUWarning >= c.Stats.Thresholds.CPUCritical {
 		return fmt.Errorf("cpu_warning threshold must be less than cpu_critical")
 	}
@@ -301,23 +407,39 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid dual_mode: %s", c.Matrix.DualMode)
 	}
 
+	if c.Matrix.Layout != "" && c.Matrix.Layout != "horizontal" && c.Matrix.Layout != "vertical" {
+		return fmt.Errorf("invalid layout: %s", c.Matrix.Layout)
+	}
+
 	// Validate individual matrix configurations
 	for i, matrix := range c.Matrix.Matrices {
-		if role, ok := matrix["role"].(string); ok && role != "" {
-			if role != "primary" && role != "secondary" {
-				return fmt.Errorf("matrix[%d] invalid role: %s", i, role)
-			}
+		if matrixErrors := c.validateMatrixOverrides(i, matrix, validModes, validMetrics); len(matrixErrors) > 0 {
+			return matrixErrors[0]
 		}
+	}
 
-		if metrics, ok := matrix["metrics"].([]interface{}); ok {
-			for _, metric := range metrics {
-				if metricStr, ok := metric.(string); ok {
-					if !validMetrics[metricStr] {
-						return fmt.Errorf("matrix[%d] invalid metric: %s", i, metricStr)
-					}
-				}
-			}
-		}
+	if identityErrors := c.validateMatrixIdentities(); len(identityErrors) > 0 {
+		return identityErrors[0]
+	}
+
+	if scheduleErrors := c.validateSchedule(validModes); len(scheduleErrors) > 0 {
+		return scheduleErrors[0]
+	}
+
+	if idleErrors := c.validateIdle(); len(idleErrors) > 0 {
+		return idleErrors[0]
+	}
+
+	if calibrationErrors := c.validateCalibration(); len(calibrationErrors) > 0 {
+		return calibrationErrors[0]
+	}
+
+	if zoneErrors := c.validateZones(validMetrics); len(zoneErrors) > 0 {
+		return zoneErrors[0]
+	}
+
+	if patternErrors := c.validateCustomPatterns(); len(patternErrors) > 0 {
+		return patternErrors[0]
 	}
 
 	// Validate logging configuration
@@ -382,6 +504,14 @@ func (c *Config) ConvertMatrices() []SingleMatrixConfig {
 			matrix.Port = port
 		}
 
+		if serialNumber, ok := m["serial_number"].(string); ok {
+			matrix.SerialNumber = serialNumber
+		}
+
+		if location, ok := m["location"].(string); ok {
+			matrix.Location = location
+		}
+
 		if role, ok := m["role"].(string); ok {
 			matrix.Role = role
 		}
@@ -403,6 +533,30 @@ func (c *Config) ConvertMatrices() []SingleMatrixConfig {
 			}
 		}
 
+		if mode, ok := m["mode"].(string); ok {
+			matrix.Mode = mode
+		}
+
+		if metric, ok := m["primary_metric"].(string); ok {
+			matrix.PrimaryMetric = metric
+		}
+
+		if pattern, ok := m["custom_pattern"].(string); ok {
+			matrix.CustomPattern = pattern
+		}
+
+		if rate, ok := parseDuration(m["update_rate"]); ok {
+			matrix.UpdateRate = rate
+		}
+
+		if overrides, ok := m["thresholds"].(map[string]interface{}); ok {
+			thresholds := c.matrixThresholds(overrides)
+			matrix.Thresholds = &thresholds
+		}
+
+		overrides, _ := m["calibration"].(map[string]interface{})
+		matrix.Calibration = c.matrixCalibration(overrides)
+
 		matrices = append(matrices, matrix)
 	}
 
@@ -411,12 +565,28 @@ func (c *Config) ConvertMatrices() []SingleMatrixConfig {
 
 // SingleMatrixConfig represents configuration for a single matrix
 // This is a separate type to avoid import cycles with the matrix package.
+//
+// SerialNumber and Location bind the matrix to a module by USB serial number or USB topology
+// path when Port is empty.
+//
+// Mode, PrimaryMetric, UpdateRate, CustomPattern and Thresholds are used by independent dual mode;
+// empty values inherit the global display and stats settings.
+//
+// Calibration is matrix.calibration with any keys set under the matrix's calibration applied.
 type SingleMatrixConfig struct {
-	Name       string   `yaml:"name"`
-	Port       string   `yaml:"port"`
-	Role       string   `yaml:"role"`
-	Metrics    []string `yaml:"metrics"`
-	Brightness byte     `yaml:"brightness"`
+	Thresholds    *Thresholds       `yaml:"thresholds"`
+	Calibration   CalibrationConfig `yaml:"calibration"`
+	Name          string            `yaml:"name"`
+	Port          string            `yaml:"port"`
+	SerialNumber  string            `yaml:"serial_number"`
+	Location      string            `yaml:"location"`
+	Role          string            `yaml:"role"`
+	Mode          string            `yaml:"mode"`
+	PrimaryMetric string            `yaml:"primary_metric"`
+	CustomPattern string            `yaml:"custom_pattern"`
+	Metrics       []string          `yaml:"metrics"`
+	UpdateRate    time.Duration     `yaml:"update_rate"`
+	Brightness    byte              `yaml:"brightness"`
 }
 
 func getDefaultConfigPath() string {
@@ -496,6 +666,14 @@ func (c *Config) ValidateDetailed() []ValidationError {
 		})
 	}
 
+	if !validCaptureFormats[c.Matrix.Capture.Format] {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.capture.format",
+			Value:   c.Matrix.Capture.Format,
+			Message: "must be jsonl or binary",
+		})
+	}
+
 	// Stats configuration validation
 	if c.Stats.CollectInterval <= 0 {
 		errors = append(errors, ValidationError{
@@ -536,12 +714,15 @@ func (c *Config) ValidateDetailed() []ValidationError {
 		"activity":   true,
 		"status":     true,
 		"custom":     true,
+		"history":    true,
+		"cores":      true,
+		"zones":      true,
 	}
 	if !validModes[c.Display.Mode] {
 		errors = append(errors, ValidationError{
 			Field:   "display.mode",
 			Value:   c.Display.Mode,
-			Message: "must be one of: percentage, gradient, activity, status, custom",
+			Message: "must be one of: percentage, gradient, activity, status, custom, history, cores, zones",
 		})
 	}
 
@@ -559,6 +740,46 @@ func (c *Config) ValidateDetailed() []ValidationError {
 		})
 	}
 
+	if c.Display.History.Metric != "" && !validMetrics[c.Display.History.Metric] {
+		errors = append(errors, ValidationError{
+			Field:   "display.history.metric",
+			Value:   c.Display.History.Metric,
+			Message: "must be one of: cpu, memory, disk, network",
+		})
+	}
+
+	if limit := c.historyWindowLimit(); c.Display.History.Window < 1 || c.Display.History.Window > limit {
+		errors = append(errors, ValidationError{
+			Field:   "display.history.window",
+			Value:   c.Display.History.Window,
+			Message: fmt.Sprintf("must be between 1 and %d (one sample per column)", limit),
+		})
+	}
+
+	if c.Display.Transition.Duration < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "display.transition.duration",
+			Value:   c.Display.Transition.Duration,
+			Message: "must not be negative (0 disables transitions)",
+		})
+	}
+
+	if !validEasings[c.Display.Transition.Easing] {
+		errors = append(errors, ValidationError{
+			Field:   "display.transition.easing",
+			Value:   c.Display.Transition.Easing,
+			Message: "must be linear, ease-in, ease-out or ease-in-out",
+		})
+	}
+
+	if c.Display.Alerts.Duration < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "display.alerts.duration",
+			Value:   c.Display.Alerts.Duration,
+			Message: "must not be negative (0 shows alerts until the status recovers)",
+		})
+	}
+
 	// Threshold validation with cross-field checks
 	if c.Stats.Thresholds.CPUWarning < 0 || c.Stats.Thresholds.CPUWarning > 100 {
 		errors = append(errors, ValidationError{
@@ -647,17 +868,17 @@ func (c *Config) ValidateDetailed() []ValidationError {
 		})
 	}
 
+	if c.Matrix.Layout != "" && c.Matrix.Layout != "horizontal" && c.Matrix.Layout != "vertical" {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.layout",
+			Value:   c.Matrix.Layout,
+			Message: "must be either 'horizontal' (68x9) or 'vertical' (34x18)",
+		})
+	}
+
 	// Individual matrix validation
 	for i, matrix := range c.Matrix.Matrices {
-		if role, ok := matrix["role"].(string); ok && role != "" {
-			if role != "primary" && role != "secondary" {
-				errors = append(errors, ValidationError{
-					Field:   fmt.Sprintf("matrix.matrices[%d].role", i),
-					Value:   role,
-					Message: "must be either 'primary' or 'secondary'",
-				})
-			}
-		}
+		errors = append(errors, c.validateMatrixOverrides(i, matrix, validModes, validMetrics)...)
 
 		if brightness, ok := matrix["brightness"]; ok {
 			var brightnessVal float64
@@ -685,28 +906,15 @@ func (c *Config) ValidateDetailed() []ValidationError {
 				})
 			}
 		}
-
-		if metrics, ok := matrix["metrics"].([]interface{}); ok {
-			for j, metric := range metrics {
-				if metricStr, ok := metric.(string); ok {
-					if !validMetrics[metricStr] {
-						errors = append(errors, ValidationError{
-							Field:   fmt.Sprintf("matrix.matrices[%d].metrics[%d]", i, j),
-							Value:   metricStr,
-							Message: "must be one of: cpu, memory, disk, network",
-						})
-					}
-				} else {
-					errors = append(errors, ValidationError{
-						Field:   fmt.Sprintf("matrix.matrices[%d].metrics[%d]", i, j),
-						Value:   metric,
-						Message: "must be a string metric name",
-					})
-				}
-			}
-		}
 	}
 
+	errors = append(errors, c.validateMatrixIdentities()...)
+	errors = append(errors, c.validateSchedule(validModes)...)
+	errors = append(errors, c.validateIdle()...)
+	errors = append(errors, c.validateCalibration()...)
+	errors = append(errors, c.validateZones(validMetrics)...)
+	errors = append(errors, c.validateCustomPatterns()...)
+
 	// Daemon configuration validation
 	if c.Daemon.Name == "" {
 		errors = append(errors, ValidationError{
@@ -753,6 +961,10 @@ func (c *Config) ApplyEnvironmentOverrides() {
 			}
 		},
 		"FRAMEWORK_LED_AUTO_DISCOVER": func(v string) { c.Matrix.AutoDiscover = strings.ToLower(v) == stringTrue },
+		"FRAMEWORK_LED_DISCOVERY_FALLBACK": func(v string) {
+			c.Matrix.DiscoveryFallback = strings.ToLower(v) == stringTrue
+		},
+		"FRAMEWORK_LED_CAPTURE_FILE": func(v string) { c.Matrix.Capture.File = v },
 		"FRAMEWORK_LED_BRIGHTNESS": func(v string) {
 			if i, err := strconv.Atoi(v); err == nil && i >= 0 && i <= 255 {
 				c.Matrix.Brightness = byte(i)
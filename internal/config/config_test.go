@@ -83,6 +83,17 @@ func TestConfigValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "matrix baud_rate must be positive",
 		},
+		{
+			name: "invalid capture format",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Capture = CaptureConfig{File: "/tmp/led.pcap", Format: "pcap"}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "invalid matrix capture format: pcap",
+		},
 		{
 			name: "invalid collect interval",
 			config: func() *Config {
@@ -94,6 +105,98 @@ func TestConfigValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "stats collect_interval must be positive",
 		},
+		{
+			name: "invalid schedule time",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Schedule.Windows = []ScheduleWindow{{Start: "22:00", End: "7am", Brightness: 30}}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "validation error for field 'matrix.schedule.windows[0].end' (value: 7am): must be a 24-hour time such as 22:00",
+		},
+		{
+			name: "empty schedule window",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Schedule.Windows = []ScheduleWindow{{Start: "09:00", End: "09:00"}}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "validation error for field 'matrix.schedule.windows[0].end' (value: 09:00): must differ from start",
+		},
+		{
+			name: "invalid schedule mode",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Schedule.Windows = []ScheduleWindow{{Start: "22:00", End: "07:00", Mode: "dark"}}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg: "validation error for field 'matrix.schedule.windows[0].mode' (value: dark): " +
+				"must be a display mode (percentage, gradient, activity, status, custom, history, cores, zones)",
+		},
+		{
+			name: "idle without a source",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Idle.Enabled = true
+				cfg.Matrix.Idle.Input = false
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "validation error for field 'matrix.idle' (value: true): needs input or file to watch for activity",
+		},
+		{
+			name: "idle sleeps before dimming",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Idle.Enabled = true
+				cfg.Matrix.Idle.DimAfter = 5 * time.Minute
+				cfg.Matrix.Idle.SleepAfter = time.Minute
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "validation error for field 'matrix.idle.sleep_after' (value: 1m0s): must be longer than dim_after",
+		},
+		{
+			name: "negative transition duration",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Display.Transition.Duration = -time.Second
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "display transition duration must not be negative",
+		},
+		{
+			name: "invalid transition easing",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Display.Transition = TransitionConfig{Duration: time.Second, Easing: "bounce"}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "invalid display transition easing: bounce",
+		},
+		{
+			name: "negative alert duration",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Display.Alerts.Duration = -time.Second
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "display alert duration must not be negative",
+		},
 		{
 			name: "invalid display mode",
 			config: func() *Config {
@@ -152,6 +255,17 @@ func TestConfigValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "disk_warning threshold must be less than disk_critical",
 		},
+		{
+			name: "malformed matrix thresholds",
+			config: func() *Config {
+				cfg := DefaultConfig()
+				cfg.Matrix.Matrices = []map[string]interface{}{{"name": "left", "thresholds": "high"}}
+
+				return cfg
+			}(),
+			wantErr: true,
+			errMsg:  "validation error for field 'matrix.matrices[0].thresholds' (value: high): must be a map of threshold names to percentages",
+		},
 	}
 
 	for _, tt := range tests {
@@ -411,7 +525,9 @@ func TestConfigApplyEnvironmentOverrides(t *testing.T) {
 	t.Setenv("FRAMEWORK_LED_PORT", "/dev/ttyACM1")
 	t.Setenv("FRAMEWORK_LED_BAUD_RATE", "9600")
 	t.Setenv("FRAMEWORK_LED_AUTO_DISCOVER", "false")
+	t.Setenv("FRAMEWORK_LED_DISCOVERY_FALLBACK", "true")
 	t.Setenv("FRAMEWORK_LED_BRIGHTNESS", "255")
+	t.Setenv("FRAMEWORK_LED_CAPTURE_FILE", "/tmp/led.jsonl")
 	t.Setenv("FRAMEWORK_LED_DUAL_MODE", "split")
 	t.Setenv("FRAMEWORK_LED_COLLECT_INTERVAL", "10s")
 	t.Setenv("FRAMEWORK_LED_ENABLE_CPU", "false")
@@ -444,6 +560,14 @@ func TestConfigApplyEnvironmentOverrides(t *testing.T) {
 		t.Errorf("Expected auto discover to be false, got %t", cfg.Matrix.AutoDiscover)
 	}
 
+	if !cfg.Matrix.DiscoveryFallback {
+		t.Error("Expected discovery fallback to be true")
+	}
+
+	if cfg.Matrix.Capture.File != "/tmp/led.jsonl" {
+		t.Errorf("Expected capture file /tmp/led.jsonl, got %s", cfg.Matrix.Capture.File)
+	}
+
 	if cfg.Matrix.Brightness != 255 {
 		t.Errorf("Expected brightness 255, got %d", cfg.Matrix.Brightness)
 	}
@@ -724,6 +848,209 @@ func TestConfigValidateDetailed(t *testing.T) {
 			expectedCount:  4, // baud_rate + 2 collect_interval + display_mode
 			expectedFields: []string{"matrix.baud_rate", "stats.collect_interval", "display.mode"},
 		},
+		{
+			name: "valid custom patterns",
+			modifyConfig: func(c *Config) {
+				c.Display.Mode = "custom"
+				c.Display.CustomPattern = "cpu_bar"
+				c.Display.CustomPatterns = map[string]PatternConfig{
+					"cpu_bar": {Pattern: "bar", Parameters: map[string]interface{}{"max": 100, "orientation": "vertical"}},
+					"alert": {Pattern: "icon", Parameters: map[string]interface{}{
+						"pixels":    []interface{}{"#.#", ".#."},
+						"threshold": 90.5,
+					}},
+				}
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "invalid extended layout",
+			modifyConfig: func(c *Config) {
+				c.Matrix.Layout = "diagonal"
+			},
+			expectedCount:  1,
+			expectedFields: []string{"matrix.layout"},
+		},
+		{
+			name: "valid history mode",
+			modifyConfig: func(c *Config) {
+				c.Display.Mode = "history"
+				c.Display.History = HistoryConfig{Metric: "network", Window: 20}
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "invalid history settings",
+			modifyConfig: func(c *Config) {
+				c.Display.History = HistoryConfig{Metric: "gpu", Window: MaxHistoryWindow + 1}
+			},
+			expectedCount:  2,
+			expectedFields: []string{"display.history.metric", "display.history.window"},
+		},
+		{
+			name: "history window across an extended canvas",
+			modifyConfig: func(c *Config) {
+				c.Matrix.DualMode = "extended"
+				c.Matrix.Layout = "horizontal"
+				c.Display.History.Window = 2 * MaxHistoryWindow
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "valid independent matrix overrides",
+			modifyConfig: func(c *Config) {
+				c.Matrix.DualMode = "independent"
+				c.Matrix.Matrices = []map[string]interface{}{{
+					"name":           "left",
+					"mode":           "status",
+					"primary_metric": "memory",
+					"update_rate":    "500ms",
+					"thresholds":     map[string]interface{}{"cpu_warning": 50, "cpu_critical": 60.5},
+				}}
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "invalid independent matrix overrides",
+			modifyConfig: func(c *Config) {
+				c.Matrix.Matrices = []map[string]interface{}{{
+					"name":           "left",
+					"mode":           "sparkle",
+					"primary_metric": 3,
+					"update_rate":    "10ms",
+					"custom_pattern": "missing",
+					"thresholds":     map[string]interface{}{"gpu_warning": 50, "memory_warning": 99},
+				}}
+			},
+			expectedCount: 6,
+			expectedFields: []string{
+				"matrix.matrices[0].mode",
+				"matrix.matrices[0].primary_metric",
+				"matrix.matrices[0].update_rate",
+				"matrix.matrices[0].custom_pattern",
+				"matrix.matrices[0].thresholds.gpu_warning",
+				"matrix.matrices[0].thresholds.memory_warning",
+			},
+		},
+		{
+			name: "invalid calibration",
+			modifyConfig: func(c *Config) {
+				c.Matrix.Calibration.Gamma = 5
+				c.Matrix.Matrices = []map[string]interface{}{{
+					"name":        "left",
+					"calibration": map[string]interface{}{"contrast": 2, "max": 300, "offset": 10},
+				}}
+			},
+			expectedCount: 3,
+			expectedFields: []string{
+				"matrix.calibration.gamma",
+				"matrix.matrices[0].calibration.contrast",
+				"matrix.matrices[0].calibration.max",
+			},
+		},
+		{
+			name: "valid zones mode",
+			modifyConfig: func(c *Config) {
+				c.Display.Mode = "zones"
+				c.Display.Zones = []ZoneConfig{
+					{Metric: "cpu", Renderer: "number", Rows: "0-4", Label: "C"},
+					{Metric: "disk", Renderer: "icon", Rows: "5-8", Columns: "30-33", Pixels: []string{"##"}},
+				}
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "zones across an extended canvas",
+			modifyConfig: func(c *Config) {
+				c.Matrix.DualMode = "extended"
+				c.Matrix.Layout = "vertical"
+				c.Display.Mode = "zones"
+				c.Display.Zones = []ZoneConfig{{Metric: "cpu", Rows: "9-17"}}
+			},
+			expectedCount:  0,
+			expectedFields: []string{},
+		},
+		{
+			name: "invalid zones",
+			modifyConfig: func(c *Config) {
+				c.Display.Zones = []ZoneConfig{
+					{Metric: "gpu", Renderer: "dial", Rows: "5-3", Columns: "0-34", Label: "CPU"},
+					{Renderer: "icon"},
+				}
+			},
+			expectedCount: 6,
+			expectedFields: []string{
+				"display.zones[0].renderer",
+				"display.zones[0].metric",
+				"display.zones[0].rows",
+				"display.zones[0].columns",
+				"display.zones[0].label",
+				"display.zones[1].pixels",
+			},
+		},
+		{
+			name: "zones mode without zones",
+			modifyConfig: func(c *Config) {
+				c.Display.Mode = "zones"
+				c.Display.Zones = nil
+			},
+			expectedCount:  1,
+			expectedFields: []string{"display.zones"},
+		},
+		{
+			name: "unknown custom pattern type",
+			modifyConfig: func(c *Config) {
+				c.Display.CustomPatterns = map[string]PatternConfig{"spin": {Pattern: "spiral"}}
+			},
+			expectedCount:  1,
+			expectedFields: []string{"display.custom_patterns.spin.pattern"},
+		},
+		{
+			name: "custom pattern parameter errors",
+			modifyConfig: func(c *Config) {
+				c.Display.CustomPatterns = map[string]PatternConfig{
+					"label": {Pattern: "text", Parameters: map[string]interface{}{
+						"text":   42,
+						"font":   "8x8",
+						"speed":  "fast",
+						"metric": "gpu",
+						"color":  "red",
+					}},
+					"icon": {Pattern: "icon", Parameters: map[string]interface{}{"brightness": 300}},
+				}
+			},
+			expectedCount: 7,
+			expectedFields: []string{
+				"display.custom_patterns.label.parameters.text",
+				"display.custom_patterns.label.parameters.font",
+				"display.custom_patterns.label.parameters.speed",
+				"display.custom_patterns.label.parameters.metric",
+				"display.custom_patterns.label.parameters.color",
+				"display.custom_patterns.icon.parameters.pixels",
+				"display.custom_patterns.icon.parameters.brightness",
+			},
+		},
+		{
+			name: "custom mode without a selected pattern",
+			modifyConfig: func(c *Config) {
+				c.Display.Mode = "custom"
+			},
+			expectedCount:  1,
+			expectedFields: []string{"display.custom_pattern"},
+		},
+		{
+			name: "custom pattern selection not configured",
+			modifyConfig: func(c *Config) {
+				c.Display.CustomPattern = "missing"
+			},
+			expectedCount:  1,
+			expectedFields: []string{"display.custom_pattern"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -804,3 +1131,200 @@ display:
 		LoadConfig(tmpFile.Name())
 	}
 }
+
+func TestPatternConfigAccessors(t *testing.T) {
+	p := PatternConfig{Parameters: map[string]interface{}{
+		"text":   "hello",
+		"int":    3,
+		"float":  2.5,
+		"flag":   true,
+		"pixels": []interface{}{"#.", ".#"},
+		"mixed":  []interface{}{"#", 1},
+	}}
+
+	if got := p.String("text", "x"); got != "hello" {
+		t.Errorf("String() = %q, want hello", got)
+	}
+
+	if got := p.String("int", "x"); got != "x" {
+		t.Errorf("String() with wrong type = %q, want default", got)
+	}
+
+	if got := p.Number("int", 0); got != 3 {
+		t.Errorf("Number() int = %v, want 3", got)
+	}
+
+	if got := p.Number("float", 0); got != 2.5 {
+		t.Errorf("Number() float = %v, want 2.5", got)
+	}
+
+	if got := p.Number("missing", 7); got != 7 {
+		t.Errorf("Number() missing = %v, want default 7", got)
+	}
+
+	if !p.Bool("flag", false) {
+		t.Error("Bool() = false, want true")
+	}
+
+	if got := p.StringList("pixels"); len(got) != 2 || got[1] != ".#" {
+		t.Errorf("StringList() = %v, want [#. .#]", got)
+	}
+
+	if got := p.StringList("mixed"); got != nil {
+		t.Errorf("StringList() with non-string items = %v, want nil", got)
+	}
+}
+
+func TestDisplayConfigActiveCustomPattern(t *testing.T) {
+	d := DisplayConfig{CustomPatterns: map[string]PatternConfig{"only": {Pattern: "bar"}}}
+
+	if name, _, ok := d.ActiveCustomPattern(); !ok || name != "only" {
+		t.Errorf("ActiveCustomPattern() = %q, %v; want the only pattern", name, ok)
+	}
+
+	d.CustomPatterns["other"] = PatternConfig{Pattern: "text"}
+
+	if _, _, ok := d.ActiveCustomPattern(); ok {
+		t.Error("ActiveCustomPattern() should be ambiguous with two patterns and no selection")
+	}
+
+	d.CustomPattern = "other"
+
+	if name, p, ok := d.ActiveCustomPattern(); !ok || name != "other" || p.Pattern != "text" {
+		t.Errorf("ActiveCustomPattern() = %q, %v, %v; want other", name, p, ok)
+	}
+}
+
+func TestConfigConvertMatricesIndependentSettings(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Matrix.Matrices = []map[string]interface{}{{
+		"name":           "right",
+		"mode":           "custom",
+		"primary_metric": "disk",
+		"custom_pattern": "label",
+		"update_rate":    "250ms",
+		"thresholds":     map[string]interface{}{"cpu_warning": 40, "disk_critical": 99.5},
+	}}
+
+	matrices := cfg.ConvertMatrices()
+	if len(matrices) != 1 {
+		t.Fatalf("ConvertMatrices() returned %d matrices, want 1", len(matrices))
+	}
+
+	m := matrices[0]
+	if m.Mode != "custom" || m.PrimaryMetric != "disk" || m.CustomPattern != "label" {
+		t.Errorf("ConvertMatrices() = %+v, want mode, metric and pattern overrides", m)
+	}
+
+	if m.UpdateRate != 250*time.Millisecond {
+		t.Errorf("ConvertMatrices() UpdateRate = %v, want 250ms", m.UpdateRate)
+	}
+
+	if m.Thresholds == nil {
+		t.Fatal("ConvertMatrices() Thresholds = nil, want merged thresholds")
+	}
+
+	want := cfg.Stats.Thresholds
+	want.CPUWarning = 40
+	want.DiskCritical = 99.5
+
+	if *m.Thresholds != want {
+		t.Errorf("ConvertMatrices() Thresholds = %+v, want %+v", *m.Thresholds, want)
+	}
+
+	display := cfg.DisplayConfigFor(m)
+	if display.Mode != "custom" || display.PrimaryMetric != "disk" || display.UpdateRate != 250*time.Millisecond ||
+		display.CustomPattern != "label" {
+		t.Errorf("DisplayConfigFor() = %+v, want matrix overrides applied", display)
+	}
+
+	if inherited := cfg.DisplayConfigFor(SingleMatrixConfig{Name: "plain"}); inherited.Mode != cfg.Display.Mode {
+		t.Errorf("DisplayConfigFor() without overrides mode = %s, want %s", inherited.Mode, cfg.Display.Mode)
+	}
+}
+
+func TestConfigConvertMatricesCalibration(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Matrix.Calibration = CalibrationConfig{Gamma: 2.2, Max: 240}
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left"},
+		{"name": "right", "calibration": map[string]interface{}{"scale": 0.85, "offset": -5.0}},
+	}
+
+	matrices := cfg.ConvertMatrices()
+
+	if matrices[0].Calibration != cfg.Matrix.Calibration {
+		t.Errorf("left calibration = %+v, want the default %+v", matrices[0].Calibration, cfg.Matrix.Calibration)
+	}
+
+	want := CalibrationConfig{Gamma: 2.2, Scale: 0.85, Offset: -5, Max: 240}
+	if matrices[1].Calibration != want {
+		t.Errorf("right calibration = %+v, want %+v", matrices[1].Calibration, want)
+	}
+}
+
+func TestConfigMatrixIdentities(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "serial_number": "FRAKDEBZ0100000000"},
+		{"name": "right", "location": "3-4.2"},
+	}
+
+	matrices := cfg.ConvertMatrices()
+	if matrices[0].SerialNumber != "FRAKDEBZ0100000000" || matrices[1].Location != "3-4.2" {
+		t.Errorf("ConvertMatrices() = %+v, want serial number and location", matrices)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	cfg.Matrix.Matrices = append(cfg.Matrix.Matrices,
+		map[string]interface{}{"name": "extra", "location": "3-4.2", "serial_number": 7})
+
+	errors := cfg.ValidateDetailed()
+
+	var fields []string
+	for _, e := range errors {
+		fields = append(fields, e.Field)
+	}
+
+	want := []string{"matrix.matrices[2].serial_number", "matrix.matrices[2].location"}
+	if len(fields) != len(want) || fields[0] != want[0] || fields[1] != want[1] {
+		t.Errorf("ValidateDetailed() fields = %v, want %v", fields, want)
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject two matrices with the same location")
+	}
+}
+
+func TestParseZoneRange(t *testing.T) {
+	tests := []struct {
+		value       string
+		first, last int
+		wantErr     bool
+	}{
+		{"", 0, 8, false},
+		{"0-2", 0, 2, false},
+		{"4", 4, 4, false},
+		{" 6 - 8 ", 6, 8, false},
+		{"3-1", 0, 0, true},
+		{"7-9", 0, 0, true},
+		{"-1", 0, 0, true},
+		{"top", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		first, last, err := ParseZoneRange(tt.value, 9)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseZoneRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+
+			continue
+		}
+
+		if first != tt.first || last != tt.last {
+			t.Errorf("ParseZoneRange(%q) = %d, %d, want %d, %d", tt.value, first, last, tt.first, tt.last)
+		}
+	}
+}
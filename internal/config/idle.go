@@ -0,0 +1,60 @@
+package config
+
+import "time"
+
+// IdleConfig dims and then sleeps the matrices while the user is away, and wakes them as soon as
+// there is activity again.
+type IdleConfig struct {
+	File            string        `yaml:"file"`        // File or named pipe a desktop hook writes its state to
+	InputDir        string        `yaml:"input_dir"`   // Where to find input event devices; empty is /dev/input
+	DimAfter        time.Duration `yaml:"dim_after"`   // Dim after this long without activity; 0 never dims
+	SleepAfter      time.Duration `yaml:"sleep_after"` // Sleep after this long without activity; 0 never sleeps
+	Enabled         bool          `yaml:"enabled"`
+	Input           bool          `yaml:"input"`             // Watch input devices for activity
+	SleepWhenLocked bool          `yaml:"sleep_when_locked"` // Sleep as soon as the session is locked
+	DimBrightness   byte          `yaml:"dim_brightness"`    // Brightness while dimmed
+}
+
+// validateIdle checks that the idle timeouts are in order and that there is something to watch.
+func (c *Config) validateIdle() []ValidationError {
+	idle := c.Matrix.Idle
+	if !idle.Enabled {
+		return nil
+	}
+
+	var errors []ValidationError
+
+	if !idle.Input && idle.File == "" {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.idle",
+			Value:   idle.Enabled,
+			Message: "needs input or file to watch for activity",
+		})
+	}
+
+	if idle.DimAfter < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.idle.dim_after",
+			Value:   idle.DimAfter,
+			Message: "must not be negative (0 never dims)",
+		})
+	}
+
+	if idle.SleepAfter < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.idle.sleep_after",
+			Value:   idle.SleepAfter,
+			Message: "must not be negative (0 never sleeps)",
+		})
+	}
+
+	if idle.DimAfter > 0 && idle.SleepAfter > 0 && idle.SleepAfter <= idle.DimAfter {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.idle.sleep_after",
+			Value:   idle.SleepAfter,
+			Message: "must be longer than dim_after",
+		})
+	}
+
+	return errors
+}
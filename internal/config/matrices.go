@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// thresholdFields maps the keys accepted under matrix.matrices[].thresholds to the fields they set.
+func thresholdFields(t *Thresholds) map[string]*float64 {
+	return map[string]*float64{
+		"cpu_warning":     &t.CPUWarning,
+		"cpu_critical":    &t.CPUCritical,
+		"memory_warning":  &t.MemoryWarning,
+		"memory_critical": &t.MemoryCritical,
+		"disk_warning":    &t.DiskWarning,
+		"disk_critical":   &t.DiskCritical,
+	}
+}
+
+// parseDuration accepts a duration string such as "500ms", as written in YAML, or a time.Duration.
+func parseDuration(value interface{}) (time.Duration, bool) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+
+		return d, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// matrixThresholds returns the global thresholds with any per-matrix overrides applied.
+// Overrides with an unknown key or a non-numeric value are ignored.
+func (c *Config) matrixThresholds(overrides map[string]interface{}) Thresholds {
+	thresholds := c.Stats.Thresholds
+	fields := thresholdFields(&thresholds)
+
+	for key, value := range overrides {
+		if field, ok := fields[key]; ok {
+			if v, ok := toFloat(value); ok {
+				*field = v
+			}
+		}
+	}
+
+	return thresholds
+}
+
+// DisplayConfigFor returns the display settings for a matrix in independent mode: the global
+// display settings with the matrix's mode, primary metric, update rate and custom pattern applied.
+func (c *Config) DisplayConfigFor(m SingleMatrixConfig) DisplayConfig {
+	display := c.Display
+
+	if m.Mode != "" {
+		display.Mode = m.Mode
+	}
+
+	if m.PrimaryMetric != "" {
+		display.PrimaryMetric = m.PrimaryMetric
+	}
+
+	if m.UpdateRate > 0 {
+		display.UpdateRate = m.UpdateRate
+	}
+
+	if m.CustomPattern != "" {
+		display.CustomPattern = m.CustomPattern
+	}
+
+	return display
+}
+
+// validateMatrixOverrides checks the role and metrics of a matrix, and the per-matrix display
+// settings used by independent mode.
+func (c *Config) validateMatrixOverrides(i int, m map[string]interface{}, validModes, validMetrics map[string]bool) []ValidationError {
+	var errors []ValidationError
+
+	field := func(name string) string {
+		return fmt.Sprintf("matrix.matrices[%d].%s", i, name)
+	}
+
+	if role, ok := m["role"].(string); ok && role != "" && role != "primary" && role != "secondary" {
+		errors = append(errors, ValidationError{
+			Field:   field("role"),
+			Value:   role,
+			Message: "must be either 'primary' or 'secondary'",
+		})
+	}
+
+	if metrics, ok := m["metrics"].([]interface{}); ok {
+		for j, metric := range metrics {
+			if s, isString := metric.(string); !isString {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("%s[%d]", field("metrics"), j),
+					Value:   metric,
+					Message: "must be a string metric name",
+				})
+			} else if !validMetrics[s] {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("%s[%d]", field("metrics"), j),
+					Value:   s,
+					Message: "must be one of: cpu, memory, disk, network",
+				})
+			}
+		}
+	}
+
+	if mode, ok := m["mode"]; ok {
+		if s, isString := mode.(string); !isString || !validModes[s] {
+			errors = append(errors, ValidationError{
+				Field:   field("mode"),
+				Value:   mode,
+				Message: "must be one of: percentage, gradient, activity, status, custom, history, cores, zones",
+			})
+		}
+	}
+
+	if metric, ok := m["primary_metric"]; ok {
+		if s, isString := metric.(string); !isString || !validMetrics[s] {
+			errors = append(errors, ValidationError{
+				Field:   field("primary_metric"),
+				Value:   metric,
+				Message: "must be one of: cpu, memory, disk, network",
+			})
+		}
+	}
+
+	if rate, ok := m["update_rate"]; ok {
+		if d, valid := parseDuration(rate); !valid || d < 50*time.Millisecond {
+			errors = append(errors, ValidationError{
+				Field:   field("update_rate"),
+				Value:   rate,
+				Message: "must be a duration of at least 50ms (e.g., '500ms', '2s')",
+			})
+		}
+	}
+
+	if pattern, ok := m["custom_pattern"]; ok {
+		if s, isString := pattern.(string); !isString || c.Display.CustomPatterns[s].Pattern == "" {
+			errors = append(errors, ValidationError{
+				Field:   field("custom_pattern"),
+				Value:   pattern,
+				Message: "must name an entry in display.custom_patterns",
+			})
+		}
+	}
+
+	if raw, ok := m["thresholds"]; ok {
+		errors = append(errors, c.validateMatrixThresholds(field("thresholds"), raw)...)
+	}
+
+	return errors
+}
+
+func (c *Config) validateMatrixThresholds(field string, raw interface{}) []ValidationError {
+	overrides, ok := raw.(map[string]interface{})
+	if !ok {
+		return []ValidationError{{
+			Field:   field,
+			Value:   raw,
+			Message: "must be a map of threshold names to percentages",
+		}}
+	}
+
+	var errors []ValidationError
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	fields := thresholdFields(&Thresholds{})
+
+	for _, key := range keys {
+		value := overrides[key]
+
+		if _, known := fields[key]; !known {
+			errors = append(errors, ValidationError{
+				Field:   field + "." + key,
+				Value:   value,
+				Message: "unknown threshold (valid: cpu_warning, cpu_critical, memory_warning, memory_critical, disk_warning, disk_critical)",
+			})
+
+			continue
+		}
+
+		if v, isNumber := toFloat(value); !isNumber || v < 0 || v > 100 {
+			errors = append(errors, ValidationError{
+				Field:   field + "." + key,
+				Value:   value,
+				Message: "must be a number between 0 and 100 (percentage)",
+			})
+		}
+	}
+
+	t := c.matrixThresholds(overrides)
+
+	levels := []struct {
+		name              string
+		warning, critical float64
+	}{
+		{"cpu", t.CPUWarning, t.CPUCritical},
+		{"memory", t.MemoryWarning, t.MemoryCritical},
+		{"disk", t.DiskWarning, t.DiskCritical},
+	}
+
+	for _, level := range levels {
+		if level.warning >= level.critical {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("%s.%s_warning", field, level.name),
+				Value:   level.warning,
+				Message: fmt.Sprintf("must be less than %s_critical (%.1f)", level.name, level.critical),
+			})
+		}
+	}
+
+	return errors
+}
+
+// validateMatrixIdentities checks that serial_number and location are strings and that no two
+// matrices ask for the same module.
+func (c *Config) validateMatrixIdentities() []ValidationError {
+	var errors []ValidationError
+
+	for _, key := range []string{"serial_number", "location"} {
+		seen := make(map[string]int)
+
+		for i, m := range c.Matrix.Matrices {
+			raw, ok := m[key]
+			if !ok {
+				continue
+			}
+
+			field := fmt.Sprintf("matrix.matrices[%d].%s", i, key)
+
+			value, isString := raw.(string)
+			if !isString {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Value:   raw,
+					Message: "must be a string",
+				})
+
+				continue
+			}
+
+			if value == "" {
+				continue
+			}
+
+			if first, duplicate := seen[value]; duplicate {
+				errors = append(errors, ValidationError{
+					Field:   field,
+					Value:   value,
+					Message: fmt.Sprintf("is already used by matrix.matrices[%d]", first),
+				})
+
+				continue
+			}
+
+			seen[value] = i
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Custom pattern types understood by the "custom" display mode.
+const (
+	PatternBar       = "bar"
+	PatternSparkline = "sparkline"
+	PatternText      = "text"
+	PatternIcon      = "icon"
+	PatternHeatmap   = "heatmap"
+)
+
+// ParamKind is the expected type of a custom pattern parameter.
+type ParamKind string
+
+// Parameter kinds accepted in display.custom_patterns.*.parameters.
+const (
+	ParamString     ParamKind = "string"
+	ParamNumber     ParamKind = "number"
+	ParamBool       ParamKind = "bool"
+	ParamStringList ParamKind = "string list"
+)
+
+// PatternParam describes one parameter accepted by a custom pattern type.
+// Choices only applies to string parameters. Min, Max and Positive only apply to number
+// parameters; a zero Min and Max leave the value unbounded.
+type PatternParam struct {
+	Kind     ParamKind
+	Choices  []string
+	Min      float64
+	Max      float64
+	Positive bool
+	Required bool
+}
+
+var (
+	metricParam     = PatternParam{Kind: ParamString, Choices: []string{"cpu", "memory", "disk", "network"}}
+	brightnessParam = PatternParam{Kind: ParamNumber, Min: 0, Max: 255}
+	maxParam        = PatternParam{Kind: ParamNumber, Positive: true}
+)
+
+// PatternSchemas lists the parameters accepted by each custom pattern type. Every pattern can be bound
+// to a metric with the "metric" parameter, which defaults to display.primary_metric.
+var PatternSchemas = map[string]map[string]PatternParam{
+	PatternBar: {
+		"metric":      metricParam,
+		"brightness":  brightnessParam,
+		"max":         maxParam,
+		"orientation": {Kind: ParamString, Choices: []string{"horizontal", "vertical"}},
+	},
+	PatternSparkline: {
+		"metric":     metricParam,
+		"brightness": brightnessParam,
+		"max":        maxParam,
+		"fill":       {Kind: ParamBool},
+	},
+	PatternText: {
+		"metric":     metricParam,
+		"brightness": brightnessParam,
+		"text":       {Kind: ParamString},
+		"font":       {Kind: ParamString, Choices: []string{"3x3", "3x5", "5x7"}},
+		"speed":      {Kind: ParamNumber, Min: 0, Max: 100},
+	},
+	PatternIcon: {
+		"metric":     metricParam,
+		"brightness": brightnessParam,
+		"pixels":     {Kind: ParamStringList, Required: true},
+		"threshold":  {Kind: ParamNumber},
+	},
+	PatternHeatmap: {
+		"metric":     metricParam,
+		"brightness": brightnessParam,
+		"max":        maxParam,
+	},
+}
+
+// PatternTypes returns the names of all custom pattern types in sorted order.
+func PatternTypes() []string {
+	types := make([]string, 0, len(PatternSchemas))
+	for name := range PatternSchemas {
+		types = append(types, name)
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// String returns the string parameter name, or def if it is unset or not a string.
+func (p PatternConfig) String(name, def string) string {
+	if v, ok := p.Parameters[name].(string); ok {
+		return v
+	}
+
+	return def
+}
+
+// Number returns the numeric parameter name, or def if it is unset or not a number.
+func (p PatternConfig) Number(name string, def float64) float64 {
+	if v, ok := toFloat(p.Parameters[name]); ok {
+		return v
+	}
+
+	return def
+}
+
+// Bool returns the boolean parameter name, or def if it is unset or not a boolean.
+func (p PatternConfig) Bool(name string, def bool) bool {
+	if v, ok := p.Parameters[name].(bool); ok {
+		return v
+	}
+
+	return def
+}
+
+// StringList returns the string list parameter name, or nil if it is unset or not a list of strings.
+func (p PatternConfig) StringList(name string) []string {
+	return toStringList(p.Parameters[name])
+}
+
+func toStringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil
+			}
+
+			list = append(list, s)
+		}
+
+		return list
+	default:
+		return nil
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// ActiveCustomPattern returns the custom pattern rendered in "custom" mode: the one named by
+// custom_pattern, or the only configured pattern when custom_pattern is empty.
+func (d DisplayConfig) ActiveCustomPattern() (string, PatternConfig, bool) {
+	if d.CustomPattern != "" {
+		pattern, ok := d.CustomPatterns[d.CustomPattern]
+
+		return d.CustomPattern, pattern, ok
+	}
+
+	if len(d.CustomPatterns) == 1 {
+		for name, pattern := range d.CustomPatterns {
+			return name, pattern, true
+		}
+	}
+
+	return "", PatternConfig{}, false
+}
+
+func (c *Config) validateCustomPatterns() []ValidationError {
+	var errors []ValidationError
+
+	names := make([]string, 0, len(c.Display.CustomPatterns))
+	for name := range c.Display.CustomPatterns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		errors = append(errors, validatePattern(name, c.Display.CustomPatterns[name])...)
+	}
+
+	if c.Display.CustomPattern != "" {
+		if _, ok := c.Display.CustomPatterns[c.Display.CustomPattern]; !ok {
+			errors = append(errors, ValidationError{
+				Field:   "display.custom_pattern",
+				Value:   c.Display.CustomPattern,
+				Message: "must name an entry in display.custom_patterns",
+			})
+		}
+	} else if c.Display.Mode == "custom" {
+		if _, _, ok := c.Display.ActiveCustomPattern(); !ok {
+			errors = append(errors, ValidationError{
+				Field:   "display.custom_pattern",
+				Value:   c.Display.CustomPattern,
+				Message: "must be set when mode is custom and custom_patterns does not contain exactly one pattern",
+			})
+		}
+	}
+
+	return errors
+}
+
+func validatePattern(name string, pattern PatternConfig) []ValidationError {
+	var errors []ValidationError
+
+	field := "display.custom_patterns." + name
+
+	schema, ok := PatternSchemas[pattern.Pattern]
+	if !ok {
+		return []ValidationError{{
+			Field:   field + ".pattern",
+			Value:   pattern.Pattern,
+			Message: "must be one of: " + strings.Join(PatternTypes(), ", "),
+		}}
+	}
+
+	params := make([]string, 0, len(schema))
+	for param := range schema {
+		params = append(params, param)
+	}
+
+	sort.Strings(params)
+
+	for _, param := range params {
+		spec := schema[param]
+
+		value, set := pattern.Parameters[param]
+		if !set {
+			if spec.Required {
+				errors = append(errors, ValidationError{
+					Field:   field + ".parameters." + param,
+					Value:   nil,
+					Message: fmt.Sprintf("is required for %s patterns", pattern.Pattern),
+				})
+			}
+
+			continue
+		}
+
+		if msg := checkParam(spec, value); msg != "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".parameters." + param,
+				Value:   value,
+				Message: msg,
+			})
+		}
+	}
+
+	unknown := make([]string, 0)
+
+	for param := range pattern.Parameters {
+		if _, ok := schema[param]; !ok {
+			unknown = append(unknown, param)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, param := range unknown {
+		errors = append(errors, ValidationError{
+			Field:   field + ".parameters." + param,
+			Value:   pattern.Parameters[param],
+			Message: fmt.Sprintf("unknown parameter for %s patterns", pattern.Pattern),
+		})
+	}
+
+	return errors
+}
+
+func checkParam(spec PatternParam, value interface{}) string {
+	switch spec.Kind {
+	case ParamString:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+
+		if len(spec.Choices) > 0 && !containsString(spec.Choices, s) {
+			return "must be one of: " + strings.Join(spec.Choices, ", ")
+		}
+	case ParamNumber:
+		n, ok := toFloat(value)
+		if !ok {
+			return "must be a number"
+		}
+
+		if spec.Positive && n <= 0 {
+			return "must be greater than 0"
+		}
+
+		if (spec.Min != 0 || spec.Max != 0) && (n < spec.Min || n > spec.Max) {
+			return fmt.Sprintf("must be between %g and %g", spec.Min, spec.Max)
+		}
+	case ParamBool:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case ParamStringList:
+		if toStringList(value) == nil {
+			return "must be a list of strings"
+		}
+	}
+
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduleConfig changes the brightness, and optionally the display mode, by time of day.
+// Outside every window, matrix.brightness and display.mode apply.
+type ScheduleConfig struct {
+	Windows []ScheduleWindow `yaml:"windows"`
+	Fade    time.Duration    `yaml:"fade"` // Ramp the brightness over this long after a window starts or ends
+}
+
+// ScheduleWindow is a daily period with its own brightness. A window whose end is before its
+// start runs past midnight. Where windows overlap, the first one listed applies.
+type ScheduleWindow struct {
+	Name       string `yaml:"name"`
+	Start      string `yaml:"start"` // Local time of day, as HH:MM
+	End        string `yaml:"end"`   // Local time of day, as HH:MM
+	Mode       string `yaml:"mode"`  // Display mode during the window; empty keeps display.mode
+	Brightness byte   `yaml:"brightness"`
+	Off        bool   `yaml:"off"` // Switch the matrices off during the window
+}
+
+// ParseTimeOfDay parses a 24-hour HH:MM time and returns it as minutes after midnight.
+func ParseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (want HH:MM)", value)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validateSchedule checks that every window has valid times and mode.
+func (c *Config) validateSchedule(validModes map[string]bool) []ValidationError {
+	var errors []ValidationError
+
+	if c.Matrix.Schedule.Fade < 0 {
+		errors = append(errors, ValidationError{
+			Field:   "matrix.schedule.fade",
+			Value:   c.Matrix.Schedule.Fade,
+			Message: "must not be negative",
+		})
+	}
+
+	for i, window := range c.Matrix.Schedule.Windows {
+		field := fmt.Sprintf("matrix.schedule.windows[%d]", i)
+
+		start, startErr := ParseTimeOfDay(window.Start)
+		if startErr != nil {
+			errors = append(errors, ValidationError{
+				Field:   field + ".start",
+				Value:   window.Start,
+				Message: "must be a 24-hour time such as 07:30",
+			})
+		}
+
+		end, endErr := ParseTimeOfDay(window.End)
+		if endErr != nil {
+			errors = append(errors, ValidationError{
+				Field:   field + ".end",
+				Value:   window.End,
+				Message: "must be a 24-hour time such as 22:00",
+			})
+		}
+
+		if startErr == nil && endErr == nil && start == end {
+			errors = append(errors, ValidationError{
+				Field:   field + ".end",
+				Value:   window.End,
+				Message: "must differ from start",
+			})
+		}
+
+		if window.Mode != "" && !validModes[window.Mode] {
+			errors = append(errors, ValidationError{
+				Field:   field + ".mode",
+				Value:   window.Mode,
+				Message: "must be a display mode (percentage, gradient, activity, status, custom, history, cores, zones)",
+			})
+		}
+	}
+
+	return errors
+}
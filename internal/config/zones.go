@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Zone renderers understood by the "zones" display mode.
+const (
+	ZoneBar       = "bar"
+	ZoneSparkline = "sparkline"
+	ZoneNumber    = "number"
+	ZoneIcon      = "icon"
+)
+
+// ZoneConfig is one area of the matrix in the "zones" display mode. Rows and Columns are
+// inclusive ranges such as "0-2", or a single index such as "4"; empty spans the whole matrix.
+// In the extended dual mode they refer to the canvas spanning both matrices.
+type ZoneConfig struct {
+	Metric    string   `yaml:"metric"`    // cpu, memory, disk or network; empty uses display.primary_metric
+	Renderer  string   `yaml:"renderer"`  // bar (default), sparkline, number or icon
+	Rows      string   `yaml:"rows"`      // Rows 0-8 covered by the zone (0-17 extended vertically)
+	Columns   string   `yaml:"columns"`   // Columns 0-33 covered by the zone (0-67 extended horizontally)
+	Label     string   `yaml:"label"`     // Optional single character drawn at the left of the zone
+	Pixels    []string `yaml:"pixels"`    // Icon rows of '#' (lit) and '.' (unlit), for the icon renderer
+	Threshold float64  `yaml:"threshold"` // The icon renderer only lights at or above this value
+}
+
+// DefaultZones shows CPU, memory and network as three labelled bars.
+func DefaultZones() []ZoneConfig {
+	return []ZoneConfig{
+		{Metric: "cpu", Renderer: ZoneBar, Rows: "0-2", Label: "C"},
+		{Metric: "memory", Renderer: ZoneBar, Rows: "3-5", Label: "M"},
+		{Metric: "network", Renderer: ZoneBar, Rows: "6-8", Label: "N"},
+	}
+}
+
+// ParseZoneRange parses an inclusive range such as "3-5" or a single index such as "4" within
+// 0..size-1. An empty range spans all of it.
+func ParseZoneRange(value string, size int) (first, last int, err error) {
+	if value == "" {
+		return 0, size - 1, nil
+	}
+
+	from, to, isRange := strings.Cut(value, "-")
+	if !isRange {
+		to = from
+	}
+
+	first, err = strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q (want N or N-M)", value)
+	}
+
+	last, err = strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q (want N or N-M)", value)
+	}
+
+	if first < 0 || last >= size || first > last {
+		return 0, 0, fmt.Errorf("range %q must be ascending and within 0-%d", value, size-1)
+	}
+
+	return first, last, nil
+}
+
+// zoneBounds returns the number of columns and rows zones are placed within: one matrix, or
+// the canvas spanning both in the extended dual mode.
+func (c *Config) zoneBounds() (columns, rows int) {
+	columns, rows = 34, 9
+
+	if c.Matrix.DualMode == "extended" {
+		if c.Matrix.Layout == "vertical" {
+			rows *= 2
+		} else {
+			columns *= 2
+		}
+	}
+
+	return columns, rows
+}
+
+// validateZones checks the renderer, metric and placement of every zone.
+func (c *Config) validateZones(validMetrics map[string]bool) []ValidationError {
+	var errors []ValidationError
+
+	columns, rows := c.zoneBounds()
+
+	if c.Display.Mode == "zones" && len(c.Display.Zones) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   "display.zones",
+			Value:   0,
+			Message: "must define at least one zone for the zones display mode",
+		})
+	}
+
+	for i, zone := range c.Display.Zones {
+		field := fmt.Sprintf("display.zones[%d]", i)
+
+		switch zone.Renderer {
+		case "", ZoneBar, ZoneSparkline, ZoneNumber:
+		case ZoneIcon:
+			if len(zone.Pixels) == 0 {
+				errors = append(errors, ValidationError{
+					Field:   field + ".pixels",
+					Value:   zone.Pixels,
+					Message: "is required by the icon renderer",
+				})
+			}
+		default:
+			errors = append(errors, ValidationError{
+				Field:   field + ".renderer",
+				Value:   zone.Renderer,
+				Message: "must be one of: bar, sparkline, number, icon",
+			})
+		}
+
+		if zone.Metric != "" && !validMetrics[zone.Metric] {
+			errors = append(errors, ValidationError{
+				Field:   field + ".metric",
+				Value:   zone.Metric,
+				Message: "must be one of: cpu, memory, disk, network",
+			})
+		}
+
+		if _, _, err := ParseZoneRange(zone.Rows, rows); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   field + ".rows",
+				Value:   zone.Rows,
+				Message: err.Error(),
+			})
+		}
+
+		if _, _, err := ParseZoneRange(zone.Columns, columns); err != nil {
+			errors = append(errors, ValidationError{
+				Field:   field + ".columns",
+				Value:   zone.Columns,
+				Message: err.Error(),
+			})
+		}
+
+		if utf8.RuneCountInString(zone.Label) > 1 {
+			errors = append(errors, ValidationError{
+				Field:   field + ".label",
+				Value:   zone.Label,
+				Message: "must be a single character",
+			})
+		}
+	}
+
+	return errors
+}
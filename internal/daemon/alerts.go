@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/visualizer"
+)
+
+// statsFailureLimit is how many stats collections in a row may fail before the matrices say so.
+// A single failure is common on a busy system and the next one usually succeeds.
+const statsFailureLimit = 3
+
+// Content ids on the compositor layers.
+const (
+	statsErrorID    = "stats-error"
+	criticalAlertID = "critical"
+)
+
+// alertBlink is how often the critical alert border blinks.
+const alertBlink = time.Second
+
+// compositor returns the compositor in front of the displays, or nil before they are initialized.
+func (s *Service) compositor() *visualizer.Compositor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.usingMultiple && s.multiVisualizer != nil {
+		return s.multiVisualizer.Compositor()
+	}
+
+	if s.visualizer != nil {
+		return s.visualizer.Compositor()
+	}
+
+	return nil
+}
+
+// recordStatsResult shows an error on the system layer once statsFailureLimit collections in
+// a row have failed, and clears it on the next collection that succeeds.
+func (s *Service) recordStatsResult(err error) {
+	c := s.compositor()
+	if c == nil {
+		return
+	}
+
+	if err == nil {
+		s.statsFailures = 0
+
+		if clearErr := c.Clear(visualizer.LayerSystem, statsErrorID); clearErr != nil {
+			s.logCompositorError("failed to clear stats error", clearErr)
+		}
+
+		return
+	}
+
+	s.statsFailures++
+	if s.statsFailures != statsFailureLimit {
+		return
+	}
+
+	text := visualizer.NewTextRenderer(visualizer.Font3x5, "STATS ERROR", visualizer.DefaultScrollSpeed)
+	if submitErr := c.Submit(visualizer.LayerSystem, statsErrorID, visualizer.Content{Render: text.Render}); submitErr != nil {
+		s.logCompositorError("failed to show stats error", submitErr)
+	}
+}
+
+// updateAlert blinks a border over the display mode when the status becomes critical, for
+// display.alerts.duration or until the status recovers.
+func (s *Service) updateAlert(status stats.SystemStatus) {
+	s.mu.Lock()
+	last := s.alertStatus
+	s.alertStatus = status
+	cfg := s.config.Display.Alerts
+	s.mu.Unlock()
+
+	c := s.compositor()
+	if c == nil || status == last {
+		return
+	}
+
+	if status != stats.StatusCritical {
+		if err := c.Clear(visualizer.LayerOverlay, criticalAlertID); err != nil {
+			s.logCompositorError("failed to clear critical alert", err)
+		}
+
+		return
+	}
+
+	if !cfg.Enabled {
+		return
+	}
+
+	border := matrix.NewFramebuffer()
+	border.Rect(0, 0, matrix.FramebufferWidth, matrix.FramebufferHeight, 255)
+
+	if err := c.Submit(visualizer.LayerOverlay, criticalAlertID, visualizer.Content{
+		Frame: border,
+		TTL:   cfg.Duration,
+		Blink: alertBlink,
+		Blend: visualizer.BlendOver,
+	}); err != nil {
+		s.logCompositorError("failed to show critical alert", err)
+	}
+}
+
+// pauseCompositor stops alerts and errors being drawn while the matrices are off or asleep.
+func (s *Service) pauseCompositor() {
+	if c := s.compositor(); c != nil {
+		c.SetPaused(s.displaySuspended())
+	}
+}
+
+func (s *Service) logCompositorError(message string, err error) {
+	mode := "single"
+	if s.usingMultiple {
+		mode = "multi"
+	}
+
+	s.eventLogger.LogMatrix(logging.LevelWarn, message, mode, map[string]interface{}{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/visualizer"
+)
+
+func TestServiceAlertsOverDisplayMode(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Display.UpdateRate = 0
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	t.Cleanup(func() {
+		service.compositor().SetPaused(true)
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	flush := func() {
+		t.Helper()
+
+		if err := service.matrix.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 95}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	service.updateAlert(stats.StatusCritical)
+	flush()
+
+	if frame := vm.Frame(); frame.Pixel(0, 0) == 0 || frame.Pixel(33, 8) == 0 || frame.Pixel(5, 4) != 0 {
+		t.Errorf("critical status should show the alert border, got %v", frame)
+	}
+
+	service.updateAlert(stats.StatusNormal)
+	flush()
+
+	if got := vm.Pattern(); len(got) != 2 || got[0] != matrix.PatternPercentage || got[1] != 95 {
+		t.Errorf("pattern = %v, want the percentage back once the status recovers", got)
+	}
+
+	// Failed collections are only reported once they keep failing
+	c := service.compositor()
+	collectErr := errors.New("cpu stats unavailable")
+
+	for range statsFailureLimit - 1 {
+		service.recordStatsResult(collectErr)
+	}
+
+	if c.Shows(visualizer.LayerSystem, statsErrorID) {
+		t.Error("stats error shown before the failure limit")
+	}
+
+	service.recordStatsResult(collectErr)
+
+	if !c.Shows(visualizer.LayerSystem, statsErrorID) {
+		t.Error("stats error not shown after repeated failures")
+	}
+
+	service.recordStatsResult(nil)
+
+	if c.Shows(visualizer.LayerSystem, statsErrorID) {
+		t.Error("stats error still shown after a successful collection")
+	}
+}
+
+func TestServiceAlertsDisabled(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Display.Alerts.Enabled = false
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	service.updateAlert(stats.StatusCritical)
+
+	if service.compositor().Shows(visualizer.LayerOverlay, criticalAlertID) {
+		t.Error("critical alert shown with display.alerts.enabled off")
+	}
+}
@@ -0,0 +1,249 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/idle"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// idlePollInterval is how often user activity is checked. It bounds how long the matrices take to
+// wake once the user is back.
+const idlePollInterval = 500 * time.Millisecond
+
+// idleState is how far the matrices have been put away while the user is absent.
+type idleState int
+
+const (
+	idleActive idleState = iota
+	idleDimmed
+	idleAsleep
+)
+
+func (st idleState) String() string {
+	switch st {
+	case idleDimmed:
+		return "dimmed"
+	case idleAsleep:
+		return "asleep"
+	default:
+		return "active"
+	}
+}
+
+// idleStateFor returns the state for the user's activity as of now.
+func idleStateFor(cfg config.IdleConfig, activity idle.Activity, now time.Time) idleState {
+	if activity.Locked && cfg.SleepWhenLocked {
+		return idleAsleep
+	}
+
+	away := now.Sub(activity.Last)
+
+	switch {
+	case cfg.SleepAfter > 0 && away >= cfg.SleepAfter:
+		return idleAsleep
+	case cfg.DimAfter > 0 && away >= cfg.DimAfter:
+		return idleDimmed
+	default:
+		return idleActive
+	}
+}
+
+// idleWatcher holds where the service reads user activity from. Only the idle goroutine uses it.
+type idleWatcher struct {
+	source   idle.Source
+	file     *idle.FileSource  // Closed when the source is replaced
+	settings config.IdleConfig // Settings the source was opened with
+	lastErr  string            // Last error reported, so a failing source is only logged once
+	opened   bool              // The source was opened from the settings, not set with SetIdleSource
+}
+
+// sourceFor returns the source of user activity, opening the configured one, or reopening it if
+// its settings changed.
+func (w *idleWatcher) sourceFor(cfg config.IdleConfig) (idle.Source, error) {
+	if w.source != nil && (!w.opened || sameIdleSources(w.settings, cfg)) {
+		return w.source, nil
+	}
+
+	w.close()
+
+	var sources []idle.Source
+
+	if cfg.Input {
+		sources = append(sources, idle.NewInputSource(cfg.InputDir))
+	}
+
+	if cfg.File != "" {
+		file, err := idle.NewFileSource(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+
+		w.file = file
+		sources = append(sources, file)
+	}
+
+	w.source = idle.Merge(sources...)
+	w.settings = cfg
+	w.opened = true
+
+	return w.source, nil
+}
+
+// close closes the configured source, if it was opened.
+func (w *idleWatcher) close() {
+	if w.file != nil {
+		_ = w.file.Close() //nolint:errcheck // best-effort cleanup
+	}
+
+	if w.opened {
+		w.source = nil
+		w.file = nil
+		w.opened = false
+	}
+}
+
+func sameIdleSources(a, b config.IdleConfig) bool {
+	return a.Input == b.Input && a.InputDir == b.InputDir && a.File == b.File
+}
+
+// SetIdleSource replaces where user activity is read from, for example with an idle.Fake to test
+// sleep and wake. It must be called before Start.
+func (s *Service) SetIdleSource(source idle.Source) {
+	s.idleWatch = idleWatcher{source: source}
+}
+
+// runIdle dims and sleeps the matrices while the user is away until the service stops.
+func (s *Service) runIdle() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.checkIdle()
+		}
+	}
+}
+
+// checkIdle reads the user's activity and dims, sleeps or wakes the matrices to match. If the
+// activity can't be read, the matrices are left as they are.
+func (s *Service) checkIdle() {
+	s.mu.RLock()
+	cfg := s.config.Matrix.Idle
+	s.mu.RUnlock()
+
+	if !cfg.Enabled {
+		s.idleWatch.close()
+		s.setIdleState(idleActive)
+
+		return
+	}
+
+	activity, err := s.readActivity(cfg)
+	if err != nil {
+		if err.Error() != s.idleWatch.lastErr {
+			s.idleWatch.lastErr = err.Error()
+			s.eventLogger.LogDaemon(logging.LevelWarn, "failed to read user activity", "idle", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		return
+	}
+
+	s.idleWatch.lastErr = ""
+	s.setIdleState(idleStateFor(cfg, activity, time.Now()))
+}
+
+func (s *Service) readActivity(cfg config.IdleConfig) (idle.Activity, error) {
+	source, err := s.idleWatch.sourceFor(cfg)
+	if err != nil {
+		return idle.Activity{}, fmt.Errorf("failed to open idle source: %w", err)
+	}
+
+	return source.Activity()
+}
+
+// setIdleState dims, sleeps or wakes the matrices on a change of state. Waking skips any
+// brightness transition, so the matrices are back the moment the user is.
+func (s *Service) setIdleState(state idleState) {
+	s.mu.Lock()
+	last := s.idleState
+	s.idleState = state
+	s.mu.Unlock()
+
+	if state == last {
+		return
+	}
+
+	s.eventLogger.LogDaemon(logging.LevelInfo, "matrices "+state.String(), "idle", map[string]interface{}{
+		"from": last.String(),
+	})
+
+	if last == idleAsleep {
+		s.setMatrixSleep(false)
+	}
+
+	switch state {
+	case idleAsleep:
+		// Any command after Sleep wakes the firmware, so stop alerts and finish fades first
+		s.pauseCompositor()
+		s.completeTransitions()
+		s.setMatrixSleep(true)
+	case idleDimmed:
+		s.applyDisplayBrightness()
+	case idleActive:
+		s.applyDisplayBrightness()
+		s.completeTransitions()
+	}
+
+	s.pauseCompositor()
+}
+
+// setMatrixSleep puts every matrix to sleep or wakes it with the firmware sleep command.
+func (s *Service) setMatrixSleep(sleep bool) {
+	supervisors := make(map[string]*matrix.Supervisor)
+
+	s.mu.RLock()
+	if s.usingMultiple && s.multiClient != nil {
+		for name := range s.multiClient.GetClients() {
+			if supervisor := s.multiClient.GetSupervisor(name); supervisor != nil {
+				supervisors[name] = supervisor
+			}
+		}
+	} else if s.matrix != nil {
+		supervisors["single"] = s.matrix
+	}
+	s.mu.RUnlock()
+
+	for name, supervisor := range supervisors {
+		if err := supervisor.SetSleep(sleep); err != nil {
+			s.eventLogger.LogMatrix(logging.LevelWarn, "failed to set matrix sleep", name, map[string]interface{}{
+				"sleep": sleep,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// completeTransitions finishes any running brightness or frame transitions at once.
+func (s *Service) completeTransitions() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.usingMultiple && s.multiDisplay != nil {
+		s.multiDisplay.CompleteTransitions()
+	} else if s.display != nil {
+		s.display.CompleteTransitions()
+	}
+}
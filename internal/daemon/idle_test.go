@@ -0,0 +1,172 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/idle"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+func TestIdleStateFor(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	cfg := config.IdleConfig{DimAfter: 2 * time.Minute, SleepAfter: 10 * time.Minute, SleepWhenLocked: true}
+
+	tests := []struct {
+		name   string
+		cfg    config.IdleConfig
+		away   time.Duration
+		locked bool
+		want   idleState
+	}{
+		{"just used", cfg, 0, false, idleActive},
+		{"briefly away", cfg, time.Minute, false, idleActive},
+		{"away a while", cfg, 3 * time.Minute, false, idleDimmed},
+		{"long gone", cfg, 11 * time.Minute, false, idleAsleep},
+		{"locked", cfg, 0, true, idleAsleep},
+		{"locked without sleeping", config.IdleConfig{DimAfter: 2 * time.Minute}, 0, true, idleActive},
+		{"never dims", config.IdleConfig{SleepAfter: 10 * time.Minute}, 5 * time.Minute, false, idleActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activity := idle.Activity{Last: now.Add(-tt.away), Locked: tt.locked}
+
+			if got := idleStateFor(tt.cfg, activity, now); got != tt.want {
+				t.Errorf("idleStateFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceSleepsWhileIdle(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Matrix.Brightness = 100
+	cfg.Matrix.Idle.Enabled = true
+
+	activity := idle.NewFake()
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	service.SetIdleSource(activity)
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	check := func(touched time.Duration) {
+		t.Helper()
+
+		activity.Touch(time.Now().Add(-touched))
+		service.checkIdle()
+
+		if err := service.matrix.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	check(3 * time.Minute)
+
+	if vm.Brightness() != cfg.Matrix.Idle.DimBrightness || vm.Sleeping() {
+		t.Errorf("brightness = %d, sleeping = %v; want dimmed to %d", vm.Brightness(), vm.Sleeping(),
+			cfg.Matrix.Idle.DimBrightness)
+	}
+
+	check(11 * time.Minute)
+
+	if !vm.Sleeping() || !service.displaySuspended() {
+		t.Error("matrix is awake after sleep_after without activity")
+	}
+
+	check(0)
+
+	if vm.Sleeping() || vm.Brightness() != 100 {
+		t.Errorf("brightness = %d, sleeping = %v; want awake at 100", vm.Brightness(), vm.Sleeping())
+	}
+
+	activity.SetLocked(true)
+	check(0)
+
+	if !vm.Sleeping() {
+		t.Error("matrix is awake with the session locked")
+	}
+
+	// Any command wakes the firmware, so health checks and brightness changes must wait
+	if err := service.checkMatrix(context.Background(), ""); err != nil {
+		t.Errorf("checkMatrix() error = %v, want a sleeping matrix reported healthy", err)
+	}
+
+	if err := service.SetBrightness(60); err != nil {
+		t.Errorf("SetBrightness() error = %v", err)
+	}
+
+	if err := service.matrix.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if !vm.Sleeping() {
+		t.Error("matrix woke for a health check or brightness change")
+	}
+
+	// A source that stops working leaves the matrix as it is
+	activity.SetLocked(false)
+	activity.SetError(errors.New("input devices unavailable"))
+	check(0)
+
+	if !vm.Sleeping() {
+		t.Error("matrix woke while activity could not be read")
+	}
+}
+
+func TestServiceSleepsDuringTransition(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Matrix.Idle.Enabled = true
+	cfg.Display.Mode = "history"
+	cfg.Display.UpdateRate = 0
+	cfg.Display.Transition.Duration = time.Second
+
+	activity := idle.NewFake()
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	service.SetIdleSource(activity)
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	// Start a crossfade, then lock the session while it is still running
+	service.history.Add(stats.StatsSummary{CPUUsage: 100})
+
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 100}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	activity.SetLocked(true)
+	service.checkIdle()
+
+	// Give a fade left running time to send its next steps
+	time.Sleep(200 * time.Millisecond)
+
+	if err := service.matrix.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if !vm.Sleeping() {
+		t.Error("matrix woke from a transition still running when it was put to sleep")
+	}
+}
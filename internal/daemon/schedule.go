@@ -0,0 +1,264 @@
+package daemon
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+)
+
+// scheduleInterval is how often the brightness schedule is checked. Windows start and end on the
+// minute, so this keeps changes within a few seconds of the configured time.
+const scheduleInterval = 5 * time.Second
+
+// scheduleTarget is what the schedule asks the matrices to show.
+type scheduleTarget struct {
+	window     string // Name of the window in force; empty outside every window
+	mode       string // Display mode to switch to; empty keeps the configured mode
+	brightness byte
+	off        bool
+	override   bool // The brightness was set by hand and holds until the next boundary
+}
+
+// scheduleWindow is a configured window with its times as minutes after midnight.
+type scheduleWindow struct {
+	config.ScheduleWindow
+	start, end int
+}
+
+// contains reports whether the window covers the given minute of the day.
+func (w scheduleWindow) contains(minute int) bool {
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+
+	// The window runs past midnight
+	return minute >= w.start || minute < w.end
+}
+
+// brightnessSchedule works out the brightness and display mode for the time of day. It is safe
+// for concurrent use.
+type brightnessSchedule struct {
+	overrideUntil time.Time // A manual brightness holds until then
+	now           func() time.Time
+	windows       []scheduleWindow
+	fade          time.Duration
+	mu            sync.Mutex
+	base          byte // Brightness outside every window
+	override      byte
+}
+
+// newBrightnessSchedule creates a schedule from the configuration.
+func newBrightnessSchedule(cfg *config.Config) *brightnessSchedule {
+	bs := &brightnessSchedule{now: time.Now}
+	bs.update(cfg)
+
+	return bs
+}
+
+// update replaces the windows with those in cfg, keeping any manual override.
+func (bs *brightnessSchedule) update(cfg *config.Config) {
+	windows := make([]scheduleWindow, 0, len(cfg.Matrix.Schedule.Windows))
+
+	for _, w := range cfg.Matrix.Schedule.Windows {
+		// Windows with invalid times were rejected when the config was validated
+		start, startErr := config.ParseTimeOfDay(w.Start)
+		end, endErr := config.ParseTimeOfDay(w.End)
+
+		if startErr != nil || endErr != nil || start == end {
+			continue
+		}
+
+		windows = append(windows, scheduleWindow{ScheduleWindow: w, start: start, end: end})
+	}
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.windows = windows
+	bs.fade = cfg.Matrix.Schedule.Fade
+	bs.base = cfg.Matrix.Brightness
+}
+
+// target returns what the matrices should show now, and false if no windows are configured.
+func (bs *brightnessSchedule) target() (scheduleTarget, bool) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if len(bs.windows) == 0 {
+		return scheduleTarget{}, false
+	}
+
+	now := bs.now()
+	target := bs.targetAtUnsafe(now)
+
+	if now.Before(bs.overrideUntil) {
+		target.brightness = bs.override
+		target.off = false
+		target.override = true
+
+		return target, true
+	}
+
+	// Ramp from the brightness before the last boundary
+	if since := now.Sub(bs.lastBoundaryUnsafe(now)); since < bs.fade {
+		before := bs.targetAtUnsafe(now.Add(-since - time.Second))
+		from, to := float64(before.level()), float64(target.level())
+		target.brightness = byte(math.Round(from + (to-from)*float64(since)/float64(bs.fade)))
+		target.off = target.off && target.brightness == 0
+	}
+
+	return target, true
+}
+
+// overrideBrightness holds level in place of the schedule until the next window starts or ends.
+func (bs *brightnessSchedule) overrideBrightness(level byte) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if len(bs.windows) == 0 {
+		return
+	}
+
+	now := bs.now()
+	bs.override = level
+	bs.overrideUntil = bs.nextBoundaryUnsafe(now)
+}
+
+// targetAtUnsafe returns the window in force at t, without fading or overrides.
+func (bs *brightnessSchedule) targetAtUnsafe(t time.Time) scheduleTarget {
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, w := range bs.windows {
+		if w.contains(minute) {
+			return scheduleTarget{window: w.Name, mode: w.Mode, brightness: w.Brightness, off: w.Off}
+		}
+	}
+
+	return scheduleTarget{brightness: bs.base}
+}
+
+// level returns the brightness the target shows, which is 0 if it is off.
+func (t scheduleTarget) level() byte {
+	if t.off {
+		return 0
+	}
+
+	return t.brightness
+}
+
+// nextBoundaryUnsafe returns the first time after t that a window starts or ends.
+func (bs *brightnessSchedule) nextBoundaryUnsafe(t time.Time) time.Time {
+	var next time.Time
+
+	for _, w := range bs.windows {
+		for _, minute := range []int{w.start, w.end} {
+			at := timeOfDay(t, minute)
+			if !at.After(t) {
+				at = timeOfDay(t.AddDate(0, 0, 1), minute)
+			}
+
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+	}
+
+	return next
+}
+
+// lastBoundaryUnsafe returns the last time at or before t that a window started or ended.
+func (bs *brightnessSchedule) lastBoundaryUnsafe(t time.Time) time.Time {
+	var last time.Time
+
+	for _, w := range bs.windows {
+		for _, minute := range []int{w.start, w.end} {
+			at := timeOfDay(t, minute)
+			if at.After(t) {
+				at = timeOfDay(t.AddDate(0, 0, -1), minute)
+			}
+
+			if at.After(last) {
+				last = at
+			}
+		}
+	}
+
+	return last
+}
+
+// timeOfDay returns the given minute after midnight on the day of t, in t's location.
+func timeOfDay(t time.Time, minute int) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, minute/60, minute%60, 0, 0, t.Location())
+}
+
+// runSchedule applies the brightness schedule until the service stops.
+func (s *Service) runSchedule() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	s.applySchedule()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.applySchedule()
+		}
+	}
+}
+
+// applySchedule sets the brightness and display mode the schedule asks for, if they changed.
+func (s *Service) applySchedule() {
+	target, ok := s.schedule.target()
+
+	s.mu.Lock()
+	last := s.scheduled
+	s.scheduled = nil
+
+	if ok {
+		s.scheduled = &target
+	}
+
+	cfg := s.config
+	s.mu.Unlock()
+
+	s.pauseCompositor()
+
+	if !ok {
+		if last != nil {
+			// The schedule was removed, so go back to the configured brightness and mode
+			s.applyDisplayBrightness()
+			s.updateVisualizers(cfg)
+		}
+
+		return
+	}
+
+	if last == nil || last.window != target.window || last.override != target.override {
+		s.eventLogger.LogDaemon(logging.LevelInfo, "brightness schedule changed", "schedule", map[string]interface{}{
+			"window":     target.window,
+			"brightness": target.brightness,
+			"mode":       target.mode,
+			"off":        target.off,
+			"override":   target.override,
+		})
+	}
+
+	if last == nil || last.level() != target.level() {
+		s.applyDisplayBrightness()
+	}
+
+	if last == nil || last.mode != target.mode {
+		s.updateVisualizers(cfg)
+	}
+}
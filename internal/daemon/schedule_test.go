@@ -0,0 +1,196 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// scheduleConfig returns a config at brightness 100 with a night window from 22:00 to 07:00 and a
+// brighter work window from 09:00 to 17:00.
+func scheduleConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Brightness = 100
+	cfg.Matrix.Schedule.Windows = []config.ScheduleWindow{
+		{Name: "night", Start: "22:00", End: "07:00", Brightness: 30, Mode: "gradient"},
+		{Name: "work", Start: "09:00", End: "17:00", Brightness: 120},
+	}
+
+	return cfg
+}
+
+// at returns a schedule clock reading the given time of day on a fixed date. Hours past 23 are
+// on the following days.
+func at(hour, minute int) func() time.Time {
+	return func() time.Time {
+		return time.Date(2026, 10, 16, hour, minute, 0, 0, time.Local)
+	}
+}
+
+func TestBrightnessScheduleWindows(t *testing.T) {
+	schedule := newBrightnessSchedule(scheduleConfig())
+
+	tests := []struct {
+		name       string
+		window     string
+		mode       string
+		hour       int
+		minute     int
+		brightness byte
+	}{
+		{"late evening", "night", "gradient", 23, 30, 30},
+		{"past midnight", "night", "gradient", 3, 0, 30},
+		{"night ends", "", "", 7, 0, 100},
+		{"between windows", "", "", 8, 0, 100},
+		{"work hours", "work", "", 12, 0, 120},
+		{"work ends", "", "", 17, 0, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule.now = at(tt.hour, tt.minute)
+
+			target, ok := schedule.target()
+			if !ok {
+				t.Fatal("target() reported no schedule")
+			}
+
+			if target.window != tt.window || target.mode != tt.mode || target.brightness != tt.brightness {
+				t.Errorf("target() = %+v, want window %q, mode %q, brightness %d",
+					target, tt.window, tt.mode, tt.brightness)
+			}
+		})
+	}
+}
+
+func TestBrightnessScheduleFades(t *testing.T) {
+	cfg := scheduleConfig()
+	cfg.Matrix.Schedule.Fade = 10 * time.Minute
+	cfg.Matrix.Schedule.Windows[0].Off = true
+
+	schedule := newBrightnessSchedule(cfg)
+
+	schedule.now = at(9, 5)
+	if target, _ := schedule.target(); target.level() != 110 {
+		t.Errorf("brightness 5 minutes into work = %d, want 110 halfway from 100 to 120", target.level())
+	}
+
+	// Fading into an off window keeps the matrix lit until the fade is done
+	schedule.now = at(22, 5)
+	if target, _ := schedule.target(); target.off || target.level() != 50 {
+		t.Errorf("5 minutes into night: off %v, brightness %d; want lit at 50", target.off, target.level())
+	}
+
+	schedule.now = at(22, 10)
+	if target, _ := schedule.target(); !target.off {
+		t.Errorf("target() = %+v, want off once the fade is done", target)
+	}
+
+	schedule.now = at(7, 5)
+	if target, _ := schedule.target(); target.level() != 50 {
+		t.Errorf("5 minutes after night = %d, want 50 halfway from off to 100", target.level())
+	}
+}
+
+func TestBrightnessScheduleOverride(t *testing.T) {
+	schedule := newBrightnessSchedule(scheduleConfig())
+
+	schedule.now = at(12, 0)
+	schedule.overrideBrightness(200)
+
+	schedule.now = at(16, 59)
+	if target, _ := schedule.target(); !target.override || target.brightness != 200 {
+		t.Errorf("target() = %+v, want the override of 200 until the window ends", target)
+	}
+
+	schedule.now = at(17, 0)
+	if target, _ := schedule.target(); target.override || target.brightness != 100 {
+		t.Errorf("target() = %+v, want the schedule back at 100 once the window ends", target)
+	}
+}
+
+func TestBrightnessScheduleEmpty(t *testing.T) {
+	schedule := newBrightnessSchedule(config.DefaultConfig())
+	schedule.overrideBrightness(10)
+
+	if _, ok := schedule.target(); ok {
+		t.Error("target() reported a schedule with no windows configured")
+	}
+}
+
+func TestServiceAppliesSchedule(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := scheduleConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Matrix.Schedule.Windows[0].Off = true
+	cfg.Display.UpdateRate = time.Nanosecond
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	flush := func() {
+		if err := service.matrix.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	service.schedule.now = at(23, 0)
+	service.applySchedule()
+	flush()
+
+	if vm.Brightness() != 0 || !service.displaySuspended() {
+		t.Errorf("brightness = %d, off = %v; want the night window to switch the matrix off",
+			vm.Brightness(), service.displaySuspended())
+	}
+
+	// The night window's display mode replaces the configured percentage mode
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 50}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	flush()
+
+	if got := vm.Pattern(); len(got) == 0 || got[0] != matrix.PatternGradient {
+		t.Errorf("pattern = %v, want the night window's gradient", got)
+	}
+
+	// Brightness set by hand lights the matrix until the window ends
+	if err := service.SetBrightness(60); err != nil {
+		t.Fatalf("SetBrightness() error = %v", err)
+	}
+
+	service.applySchedule()
+	flush()
+
+	if vm.Brightness() != 60 || service.displaySuspended() {
+		t.Errorf("brightness = %d, off = %v; want the manual 60", vm.Brightness(), service.displaySuspended())
+	}
+
+	service.schedule.now = at(24+7, 0)
+	service.applySchedule()
+	flush()
+
+	if vm.Brightness() != 100 {
+		t.Errorf("brightness = %d, want 100 after the night window", vm.Brightness())
+	}
+
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 50}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	flush()
+
+	if got := vm.Pattern(); len(got) == 0 || got[0] != matrix.PatternPercentage {
+		t.Errorf("pattern = %v, want the configured percentage mode back", got)
+	}
+}
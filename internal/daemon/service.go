@@ -3,6 +3,7 @@
 package daemon
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
@@ -33,6 +34,10 @@ type Service struct {
 	appMetrics       *observability.ApplicationMetrics
 	multiDisplay     *matrix.MultiDisplayManager
 	collector        *stats.Collector
+	history          *stats.History // Recent summaries graphed in "history" mode
+	schedule         *brightnessSchedule
+	scheduled        *scheduleTarget // Last applied from the schedule; nil when there is none
+	idleWatch        idleWatcher
 	visualizer       *visualizer.Visualizer
 	multiVisualizer  *visualizer.MultiVisualizer
 	logger           *logging.Logger
@@ -40,7 +45,10 @@ type Service struct {
 	metricsCollector *observability.MetricsCollector
 	multiClient      *matrix.MultiClient
 	healthMonitor    *observability.HealthMonitor
-	matrix           *matrix.Client
+	matrix           *matrix.Supervisor
+	openMatrix       matrix.Opener                     // Opens matrix ports; nil for serial ports
+	capture          *matrix.Capture                   // Records matrix traffic; nil unless enabled
+	firmware         map[string]matrix.FirmwareVersion // Versions read at connect time, by matrix name
 	apiServer        *api.Server
 	apiCancel        context.CancelFunc // Cancels only the API server goroutine
 	apiDone          chan struct{}      // Closed when API server goroutine exits
@@ -50,6 +58,9 @@ type Service struct {
 	wg               sync.WaitGroup
 	stopOnce         sync.Once
 	mu               sync.RWMutex // Protects matrix, multiClient, display, multiDisplay
+	idleState        idleState
+	alertStatus      stats.SystemStatus // Status the critical alert was last updated for
+	statsFailures    int                // Stats collections failed in a row; only used by runSystemLoop
 	usingMultiple    bool
 }
 
@@ -103,22 +114,45 @@ func NewService(cfg *config.Config) (*Service, error) {
 		ctx:              ctx,
 		cancel:           cancel,
 		stopCh:           make(chan struct{}),
+		history:          stats.NewHistory(cfg.Display.History.Window),
+		schedule:         newBrightnessSchedule(cfg),
 	}
 
 	return service, nil
 }
 
+// SetMatrixOpener replaces how matrix ports are opened, for example with matrix.VirtualOpener
+// to run the daemon against emulated modules. It must be called before Initialize or Start.
+func (s *Service) SetMatrixOpener(open matrix.Opener) {
+	s.openMatrix = open
+}
+
 // Initialize sets up the service components including LED matrix connections,
 // system statistics collection, and display management.
 func (s *Service) Initialize() error {
 	s.eventLogger.LogDaemon(logging.LevelInfo, "initializing Framework LED Matrix daemon", "initialize", nil)
 
+	// Create the collector before the health monitor starts checking it
+	s.collector = stats.NewCollector(s.config.Stats.CollectInterval)
+	s.collector.SetThresholds(stats.Thresholds{
+		CPUWarning:     s.config.Stats.Thresholds.CPUWarning,
+		CPUCritical:    s.config.Stats.Thresholds.CPUCritical,
+		MemoryWarning:  s.config.Stats.Thresholds.MemoryWarning,
+		MemoryCritical: s.config.Stats.Thresholds.MemoryCritical,
+		DiskWarning:    s.config.Stats.Thresholds.DiskWarning,
+		DiskCritical:   s.config.Stats.Thresholds.DiskCritical,
+	})
+
 	// Register health checks
 	s.registerHealthChecks()
 
 	// Start health monitoring
 	s.healthMonitor.Start()
 
+	if err := s.openCapture(); err != nil {
+		return err
+	}
+
 	// Determine if we should use multi-matrix mode
 	if len(s.config.Matrix.Matrices) > 0 && s.config.Matrix.DualMode != "" {
 		return s.initializeMultiMatrix()
@@ -127,6 +161,30 @@ func (s *Service) Initialize() error {
 	}
 }
 
+// openCapture starts recording matrix traffic if a capture file is configured.
+func (s *Service) openCapture() error {
+	captureConfig := s.config.Matrix.Capture
+	if captureConfig.File == "" || s.capture != nil {
+		return nil
+	}
+
+	format := cmp.Or(captureConfig.Format, matrix.CaptureJSONL)
+
+	capture, err := matrix.CreateCapture(captureConfig.File, format)
+	if err != nil {
+		return fmt.Errorf("failed to start matrix capture: %w", err)
+	}
+
+	s.capture = capture
+
+	s.eventLogger.LogMatrix(logging.LevelInfo, "recording matrix traffic", "capture", map[string]interface{}{
+		"file":   captureConfig.File,
+		"format": format,
+	})
+
+	return nil
+}
+
 func (s *Service) initializeSingleMatrix() error {
 	timer := s.metricsCollector.StartTimer("matrix_initialization_duration", map[string]string{"mode": "single"})
 	defer timer.Stop()
@@ -134,6 +192,21 @@ func (s *Service) initializeSingleMatrix() error {
 	s.eventLogger.LogDaemon(logging.LevelInfo, "initializing single matrix mode", "initialize_single", nil)
 
 	client := matrix.NewClient()
+	client.SetDiscoveryFallback(s.config.Matrix.DiscoveryFallback)
+	client.SetCapture(s.capture)
+	client.SetQueueHandler(func(event matrix.QueueEvent) {
+		event.Matrix = "single"
+		s.handleQueueEvent(event)
+	})
+
+	if s.config.Matrix.Timeout > 0 {
+		client.SetWriteDeadline(s.config.Matrix.Timeout)
+	}
+
+	if s.openMatrix != nil {
+		client.SetOpener(s.openMatrix)
+	}
+
 	if err := client.Connect(s.config.Matrix.Port); err != nil {
 		s.eventLogger.LogMatrix(logging.LevelError, "failed to connect to LED matrix", "single", map[string]interface{}{
 			"port": s.config.Matrix.Port,
@@ -146,8 +219,32 @@ func (s *Service) initializeSingleMatrix() error {
 		"port": s.config.Matrix.Port,
 	})
 
-	display := matrix.NewDisplayManager(client)
+	// Read the version before anything else is sent, while no reply can be pending
+	version, versionErr := client.GetFirmwareVersion()
+	if versionErr != nil {
+		s.eventLogger.LogMatrix(logging.LevelWarn, "failed to read firmware version", "single", map[string]interface{}{
+			"error": versionErr.Error(),
+		})
+	}
+
+	// Draw through a supervisor so the matrix is reconnected if it is unplugged or the port fails
+	supervisor := matrix.NewSupervisor("single", client)
+	supervisor.SetEventHandler(s.handleConnectionEvent)
+
+	if err := supervisor.SetCalibration(displayCalibration(s.config.Matrix.Calibration)); err != nil {
+		s.eventLogger.LogMatrix(logging.LevelWarn, "failed to calibrate matrix", "single", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	display := matrix.NewDisplayManager(supervisor)
 	display.SetUpdateRate(s.config.Display.UpdateRate)
+	display.SetTransition(displayTransition(s.config.Display.Transition))
+
+	if versionErr == nil {
+		display.SetCapabilities(version.Capabilities())
+		s.recordFirmware("single", version)
+	}
 
 	if err := display.SetBrightness(s.config.Matrix.Brightness); err != nil {
 		s.eventLogger.LogMatrix(logging.LevelWarn, "failed to set brightness", "single", map[string]interface{}{
@@ -158,22 +255,13 @@ func (s *Service) initializeSingleMatrix() error {
 
 	// Safely assign to shared fields protected by mutex
 	s.mu.Lock()
-	s.matrix = client
+	s.matrix = supervisor
 	s.display = display
 	s.usingMultiple = false
 	s.mu.Unlock()
 
-	s.collector = stats.NewCollector(s.config.Stats.CollectInterval)
-	s.collector.SetThresholds(stats.Thresholds{
-		CPUWarning:     s.config.Stats.Thresholds.CPUWarning,
-		CPUCritical:    s.config.Stats.Thresholds.CPUCritical,
-		MemoryWarning:  s.config.Stats.Thresholds.MemoryWarning,
-		MemoryCritical: s.config.Stats.Thresholds.MemoryCritical,
-		DiskWarning:    s.config.Stats.Thresholds.DiskWarning,
-		DiskCritical:   s.config.Stats.Thresholds.DiskCritical,
-	})
-
 	s.visualizer = visualizer.NewVisualizer(display, s.config)
+	s.visualizer.SetHistory(s.history)
 
 	s.eventLogger.LogDaemon(logging.LevelInfo, "single matrix daemon initialized successfully",
 		"initialize_single_complete", nil)
@@ -182,20 +270,20 @@ func (s *Service) initializeSingleMatrix() error {
 }
 
 func (s *Service) registerHealthChecks() {
-	// Matrix health check
-	matrixChecker := observability.NewMatrixHealthChecker("matrix", func(ctx context.Context) error {
-		s.mu.RLock()
-		defer s.mu.RUnlock()
-
-		if s.usingMultiple && s.multiClient != nil {
-			return nil // Multi-client health check would be implemented
-		} else if s.matrix != nil {
-			return nil // Single matrix health check would be implemented
+	// Matrix health checks probe each module's firmware, one checker per configured matrix
+	if len(s.config.Matrix.Matrices) > 0 && s.config.Matrix.DualMode != "" {
+		for _, m := range s.config.ConvertMatrices() {
+			name := m.Name
+			s.healthMonitor.RegisterChecker(observability.NewMatrixHealthChecker("matrix:"+name,
+				func(ctx context.Context) error {
+					return s.checkMatrix(ctx, name)
+				}))
 		}
-
-		return fmt.Errorf("no matrix client initialized")
-	})
-	s.healthMonitor.RegisterChecker(matrixChecker)
+	} else {
+		s.healthMonitor.RegisterChecker(observability.NewMatrixHealthChecker("matrix", func(ctx context.Context) error {
+			return s.checkMatrix(ctx, "")
+		}))
+	}
 
 	// Stats collection health check
 	statsChecker := observability.NewStatsHealthChecker("stats", func(ctx context.Context) error {
@@ -224,6 +312,76 @@ func (s *Service) registerHealthChecks() {
 	s.healthMonitor.RegisterChecker(memoryChecker)
 }
 
+// versionProber is a matrix connection that can be asked for its firmware version.
+type versionProber interface {
+	GetVersion() ([]byte, error)
+}
+
+// checkMatrix probes the named matrix in multi-matrix mode, or the single matrix if name is empty.
+func (s *Service) checkMatrix(ctx context.Context, name string) error {
+	var prober versionProber
+
+	s.mu.RLock()
+	if s.usingMultiple && s.multiClient != nil {
+		if supervisor := s.multiClient.GetSupervisor(name); supervisor != nil {
+			prober = supervisor
+		}
+	} else if s.matrix != nil {
+		// A multi-matrix setup that fell back to one matrix probes it from every checker
+		prober = s.matrix
+	}
+
+	asleep := s.idleState == idleAsleep
+	s.mu.RUnlock()
+
+	if prober == nil {
+		if name != "" {
+			return fmt.Errorf("matrix %s is not connected", name)
+		}
+
+		return fmt.Errorf("no matrix client initialized")
+	}
+
+	// The version request would wake a sleeping module, so it is not probed until it wakes
+	if asleep {
+		return nil
+	}
+
+	return probeMatrixVersion(ctx, prober)
+}
+
+// probeMatrixVersion sends a version request and checks for a complete response before ctx
+// expires, so a module that stops answering is reported unhealthy rather than blocking the check.
+func probeMatrixVersion(ctx context.Context, m versionProber) error {
+	type response struct {
+		err     error
+		version []byte
+	}
+
+	done := make(chan response, 1)
+
+	go func() {
+		version, err := m.GetVersion()
+		done <- response{version: version, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("no response to firmware version request: %w", ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return fmt.Errorf("failed to get firmware version: %w", r.err)
+		}
+
+		if len(r.version) != matrix.VersionResponseSize {
+			return fmt.Errorf("invalid firmware version response: got %d bytes, want %d",
+				len(r.version), matrix.VersionResponseSize)
+		}
+
+		return nil
+	}
+}
+
 func (s *Service) initializeMultiMatrix() error {
 	timer := s.metricsCollector.StartTimer("matrix_initialization_duration", map[string]string{"mode": "multi"})
 	defer timer.Stop()
@@ -237,6 +395,19 @@ func (s *Service) initializeMultiMatrix() error {
 	matrices := s.convertConfigMatrices(s.config.ConvertMatrices())
 
 	multiClient := matrix.NewMultiClient()
+	multiClient.SetEventHandler(s.handleConnectionEvent)
+	multiClient.SetDiscoveryFallback(s.config.Matrix.DiscoveryFallback)
+	multiClient.SetQueueHandler(s.handleQueueEvent)
+	multiClient.SetCapture(s.capture)
+
+	if s.config.Matrix.Timeout > 0 {
+		multiClient.SetWriteDeadline(s.config.Matrix.Timeout)
+	}
+
+	if s.openMatrix != nil {
+		multiClient.SetOpener(s.openMatrix)
+	}
+
 	if err := multiClient.DiscoverAndConnect(matrices, s.config.Matrix.BaudRate); err != nil {
 		// Fallback to single matrix mode if multi-matrix setup fails
 		s.eventLogger.LogMatrix(logging.LevelWarn,
@@ -247,8 +418,16 @@ func (s *Service) initializeMultiMatrix() error {
 		return s.initializeSingleMatrix()
 	}
 
+	for name := range multiClient.GetClients() {
+		if version, ok := multiClient.GetFirmwareVersion(name); ok {
+			s.recordFirmware(name, version)
+		}
+	}
+
 	multiDisplay := matrix.NewMultiDisplayManager(multiClient, s.config.Matrix.DualMode)
 	multiDisplay.SetUpdateRate(s.config.Display.UpdateRate)
+	multiDisplay.SetLayout(s.config.Matrix.Layout)
+	multiDisplay.SetTransition(displayTransition(s.config.Display.Transition))
 
 	// Set brightness for all matrices (will use individual brightness settings from config)
 	if err := multiDisplay.SetBrightness(s.config.Matrix.Brightness); err != nil {
@@ -266,18 +445,9 @@ func (s *Service) initializeMultiMatrix() error {
 	s.usingMultiple = true
 	s.mu.Unlock()
 
-	s.collector = stats.NewCollector(s.config.Stats.CollectInterval)
-	s.collector.SetThresholds(stats.Thresholds{
-		CPUWarning:     s.config.Stats.Thresholds.CPUWarning,
-		CPUCritical:    s.config.Stats.Thresholds.CPUCritical,
-		MemoryWarning:  s.config.Stats.Thresholds.MemoryWarning,
-		MemoryCritical: s.config.Stats.Thresholds.MemoryCritical,
-		DiskWarning:    s.config.Stats.Thresholds.DiskWarning,
-		DiskCritical:   s.config.Stats.Thresholds.DiskCritical,
-	})
-
 	// Create multi-visualizer for dual matrix mode
 	s.multiVisualizer = visualizer.NewMultiVisualizer(multiDisplay, s.config)
+	s.multiVisualizer.SetHistory(s.history)
 
 	s.eventLogger.LogDaemon(logging.LevelInfo, "multi-matrix daemon initialized successfully",
 		"initialize_multi_complete", map[string]interface{}{
@@ -287,17 +457,56 @@ func (s *Service) initializeMultiMatrix() error {
 	return nil
 }
 
+// handleConnectionEvent logs and records a matrix disconnect, reconnect or failed reconnect attempt.
+func (s *Service) handleConnectionEvent(event matrix.ConnectionEvent) {
+	fields := map[string]interface{}{
+		"port":    event.Port,
+		"attempt": event.Attempt,
+	}
+
+	if event.Err != nil {
+		fields["error"] = event.Err.Error()
+	}
+
+	switch event.Kind {
+	case matrix.EventDisconnected:
+		fields["retry_in"] = event.NextAttempt.String()
+		s.eventLogger.LogMatrix(logging.LevelWarn, "lost connection to LED matrix", event.Matrix, fields)
+	case matrix.EventReconnectFailed:
+		fields["retry_in"] = event.NextAttempt.String()
+		s.eventLogger.LogMatrix(logging.LevelWarn, "failed to reconnect to LED matrix", event.Matrix, fields)
+	case matrix.EventReconnected:
+		s.eventLogger.LogMatrix(logging.LevelInfo, "reconnected to LED matrix", event.Matrix, fields)
+
+		if event.Firmware != nil {
+			// Events arrive inside the command that reconnected, which may hold the display's lock
+			go s.applyFirmware(event.Matrix, *event.Firmware)
+		}
+	}
+
+	s.appMetrics.RecordMatrixConnectionEvent(event.Matrix, event.Kind, event.Kind == matrix.EventReconnected)
+}
+
+// handleQueueEvent records changes to a matrix's write queue. It is called with the queue
+// locked, so it only updates metrics.
+func (s *Service) handleQueueEvent(event matrix.QueueEvent) {
+	s.appMetrics.RecordMatrixWriteQueue(event.Matrix, event.Depth, event.Dropped, event.Count)
+}
+
 // convertConfigMatrices converts config.SingleMatrixConfig to matrix.SingleMatrixConfig.
 func (s *Service) convertConfigMatrices(configMatrices []config.SingleMatrixConfig) []matrix.SingleMatrixConfig {
 	matrices := make([]matrix.SingleMatrixConfig, 0, len(configMatrices))
 
 	for _, cm := range configMatrices {
 		matrixConfig := matrix.SingleMatrixConfig{
-			Name:       cm.Name,
-			Port:       cm.Port,
-			Role:       cm.Role,
-			Brightness: cm.Brightness,
-			Metrics:    cm.Metrics,
+			Name:         cm.Name,
+			Port:         cm.Port,
+			SerialNumber: cm.SerialNumber,
+			Location:     cm.Location,
+			Role:         cm.Role,
+			Brightness:   cm.Brightness,
+			Metrics:      cm.Metrics,
+			Calibration:  displayCalibration(cm.Calibration),
 		}
 		matrices = append(matrices, matrixConfig)
 	}
@@ -363,6 +572,14 @@ func (s *Service) Start() error {
 
 	s.wg.Add(1)
 
+	go s.runSchedule()
+
+	s.wg.Add(1)
+
+	go s.runIdle()
+
+	s.wg.Add(1)
+
 	go s.handleSignals()
 
 	// Initialize start time only after successful startup
@@ -395,23 +612,33 @@ func (s *Service) Stop() error {
 
 	s.wg.Wait()
 
+	s.idleWatch.close()
+
 	// Stop observability components
 	s.healthMonitor.Stop()
 	s.metricsCollector.Close()
 
-	// Clear displays before disconnecting
+	// Clear displays before disconnecting, without waiting for a fade out or redrawing alerts
+	if c := s.compositor(); c != nil {
+		c.SetPaused(true)
+	}
+
 	if s.usingMultiple && s.multiDisplay != nil {
 		if err := s.multiDisplay.UpdateStatus("off"); err != nil {
 			s.eventLogger.LogMatrix(logging.LevelWarn, "failed to clear multi-displays", "multi", map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
+
+		s.multiDisplay.CompleteTransitions()
 	} else if s.display != nil {
 		if err := s.display.ShowStatus("off"); err != nil {
 			s.eventLogger.LogMatrix(logging.LevelWarn, "failed to clear display", "single", map[string]interface{}{
 				"error": err.Error(),
 			})
 		}
+
+		s.display.CompleteTransitions()
 	}
 
 	// Disconnect from matrices
@@ -430,6 +657,14 @@ func (s *Service) Stop() error {
 		}
 	}
 
+	if s.capture != nil {
+		if err := s.capture.Close(); err != nil {
+			s.eventLogger.LogMatrix(logging.LevelWarn, "failed to close matrix capture", "capture", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	// Record final uptime metric
 	uptime := time.Since(s.startTime)
 	s.appMetrics.RecordDaemonUptime(uptime)
@@ -483,6 +718,8 @@ func (s *Service) runSystemLoop() {
 			collectedStats, err := s.collector.CollectSystemStats()
 
 			statsDuration := statsTimer.StopWithSuccess(err == nil)
+			s.recordStatsResult(err)
+
 			if err != nil {
 				s.eventLogger.LogStats(logging.LevelWarn, "failed to collect system stats", "system", 0, map[string]interface{}{
 					"error":    err.Error(),
@@ -499,12 +736,10 @@ func (s *Service) runSystemLoop() {
 				s.appMetrics.RecordStatsCollection("disk", float64(collectedStats.Disk.ActivityRate), statsDuration)
 				s.appMetrics.RecordStatsCollection("network", float64(collectedStats.Network.ActivityRate), statsDuration)
 
-				// Immediately update display with fresh stats
-				displayTimer := s.metricsCollector.StartTimer("display_update_duration", nil)
-
 				// Create summary directly from collected stats to avoid double collection
 				summary := &stats.StatsSummary{
 					CPUUsage:        collectedStats.CPU.UsagePercent,
+					PerCoreUsage:    collectedStats.CPU.PerCorePercent,
 					MemoryUsage:     collectedStats.Memory.UsedPercent,
 					DiskActivity:    collectedStats.Disk.ActivityRate,
 					NetworkActivity: collectedStats.Network.ActivityRate,
@@ -522,6 +757,17 @@ func (s *Service) runSystemLoop() {
 					summary.Status = stats.StatusNormal
 				}
 
+				s.history.Add(*summary)
+				s.updateAlert(summary.Status)
+
+				// Keep collecting while the matrices are off or asleep, but leave them dark
+				if s.displaySuspended() {
+					continue
+				}
+
+				// Immediately update display with fresh stats
+				displayTimer := s.metricsCollector.StartTimer("display_update_duration", nil)
+
 				// Use appropriate visualizer based on mode
 				var updateErr error
 
@@ -629,6 +875,8 @@ func (s *Service) reloadConfig() error {
 	s.config = newConfig
 	s.mu.Unlock()
 
+	s.history.Resize(newConfig.Display.History.Window)
+
 	s.collector.SetThresholds(stats.Thresholds{
 		CPUWarning:     newConfig.Stats.Thresholds.CPUWarning,
 		CPUCritical:    newConfig.Stats.Thresholds.CPUCritical,
@@ -638,9 +886,11 @@ func (s *Service) reloadConfig() error {
 		DiskCritical:   newConfig.Stats.Thresholds.DiskCritical,
 	})
 
-	if s.visualizer != nil {
-		s.visualizer.UpdateConfig(newConfig)
-	}
+	s.updateVisualizers(newConfig)
+	s.applyTransition(newConfig.Display.Transition)
+	s.applyCalibration(newConfig)
+	s.schedule.update(newConfig)
+	s.applySchedule()
 
 	// Restart API server if the enabled flag or socket path changed
 	apiSettingsChanged := oldAPIEnabled != newConfig.API.Enabled || oldSocketPath != newConfig.API.SocketPath
@@ -750,6 +1000,9 @@ func (s *Service) SetDisplayMode(mode string) error {
 		"activity":   true,
 		"status":     true,
 		"custom":     true,
+		"history":    true,
+		"cores":      true,
+		"zones":      true,
 	}
 	if !validModes[mode] {
 		return fmt.Errorf("invalid display mode: %s", mode)
@@ -758,22 +1011,87 @@ func (s *Service) SetDisplayMode(mode string) error {
 	s.mu.Lock()
 	s.config.Display.Mode = mode
 	cfg := s.config
-	vis := s.visualizer
-	multiVis := s.multiVisualizer
-	isMulti := s.usingMultiple
 	s.mu.Unlock()
 
-	if isMulti && multiVis != nil {
-		multiVis.UpdateConfig(cfg)
-	} else if vis != nil {
-		vis.UpdateConfig(cfg)
-	}
+	s.updateVisualizers(cfg)
 
 	return nil
 }
 
-// SetBrightness implements api.DisplayController by updating the brightness.
+// SetBrightness implements api.DisplayController by updating the brightness. With a brightness
+// schedule, the level holds until the next window starts or ends.
 func (s *Service) SetBrightness(level byte) error {
+	s.mu.RLock()
+	asleep := s.idleState == idleAsleep
+	s.mu.RUnlock()
+
+	// Any command would wake a sleeping matrix, so the level is only recorded and applied on wake
+	if !asleep {
+		if err := s.setDisplayBrightness(level); err != nil {
+			return err
+		}
+	}
+
+	s.schedule.overrideBrightness(level)
+
+	s.mu.Lock()
+	if s.scheduled != nil {
+		s.scheduled.brightness = level
+		s.scheduled.off = false
+		s.scheduled.override = true
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// applyDisplayBrightness sets the brightness the schedule and idle state ask for, leaving
+// sleeping matrices alone.
+func (s *Service) applyDisplayBrightness() {
+	s.mu.RLock()
+	level := s.displayBrightnessUnsafe()
+	asleep := s.idleState == idleAsleep
+	s.mu.RUnlock()
+
+	if asleep {
+		return
+	}
+
+	if err := s.setDisplayBrightness(level); err != nil {
+		s.eventLogger.LogMatrix(logging.LevelWarn, "failed to set brightness", "schedule", map[string]interface{}{
+			"brightness": level,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// displayBrightnessUnsafe returns the brightness the matrices should be at: the configured level
+// or the schedule's, lowered while the user is away. Asleep, it is 0.
+func (s *Service) displayBrightnessUnsafe() byte {
+	level := s.config.Matrix.Brightness
+	if s.scheduled != nil {
+		level = s.scheduled.level()
+	}
+
+	switch s.idleState {
+	case idleDimmed:
+		level = min(level, s.config.Matrix.Idle.DimBrightness)
+	case idleAsleep:
+		level = 0
+	}
+
+	return level
+}
+
+// displaySuspended reports whether the schedule has switched the matrices off or they are asleep.
+func (s *Service) displaySuspended() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.idleState == idleAsleep || (s.scheduled != nil && s.scheduled.off)
+}
+
+func (s *Service) setDisplayBrightness(level byte) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -801,16 +1119,9 @@ func (s *Service) SetPrimaryMetric(metric string) error {
 	s.mu.Lock()
 	s.config.Display.PrimaryMetric = metric
 	cfg := s.config
-	vis := s.visualizer
-	multiVis := s.multiVisualizer
-	isMulti := s.usingMultiple
 	s.mu.Unlock()
 
-	if isMulti && multiVis != nil {
-		multiVis.UpdateConfig(cfg)
-	} else if vis != nil {
-		vis.UpdateConfig(cfg)
-	}
+	s.updateVisualizers(cfg)
 
 	return nil
 }
@@ -842,15 +1153,112 @@ func (s *Service) IsMultiMatrix() bool {
 	return s.usingMultiple
 }
 
+// FirmwareVersions implements api.DisplayController by returning the firmware version of each
+// connected matrix that reported one, keyed by matrix name.
+func (s *Service) FirmwareVersions() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make(map[string]string, len(s.firmware))
+	for name, version := range s.firmware {
+		versions[name] = version.String()
+	}
+
+	return versions
+}
+
+// frameModes are the display modes drawn as greyscale frames.
+var frameModes = map[string]bool{
+	"custom":  true,
+	"history": true,
+	"cores":   true,
+	"zones":   true,
+}
+
+// applyFirmware adapts the display of a reconnected matrix to the firmware it reported, which may
+// have been updated while the module was away.
+func (s *Service) applyFirmware(name string, version matrix.FirmwareVersion) {
+	var display *matrix.DisplayManager
+
+	s.mu.RLock()
+	if s.usingMultiple && s.multiDisplay != nil {
+		display = s.multiDisplay.GetDisplayManager(name)
+	} else if !s.usingMultiple {
+		display = s.display
+	}
+	s.mu.RUnlock()
+
+	if display != nil {
+		display.SetCapabilities(version.Capabilities())
+	}
+
+	s.recordFirmware(name, version)
+}
+
+// recordFirmware stores the firmware version read from a matrix and warns if the firmware is
+// too old to draw the configured display mode as intended.
+func (s *Service) recordFirmware(name string, version matrix.FirmwareVersion) {
+	s.mu.Lock()
+	if s.firmware == nil {
+		s.firmware = make(map[string]matrix.FirmwareVersion)
+	}
+
+	s.firmware[name] = version
+	s.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"firmware": version.String(),
+	}
+
+	s.eventLogger.LogMatrix(logging.LevelInfo, "read matrix firmware version", name, fields)
+
+	mode := s.config.Display.Mode
+	if frameModes[mode] && !version.Capabilities().Greyscale {
+		fields["mode"] = mode
+		fields["min_firmware"] = matrix.MinGreyscaleFirmware.String()
+		s.eventLogger.LogMatrix(logging.LevelWarn,
+			"firmware too old for greyscale frames; drawing in black and white", name, fields)
+	}
+}
+
 // applyConfigFromAPI is the callback for api.Server.ConfigUpdateFunc.
 // It applies a config update received via the API to the running daemon.
 func (s *Service) applyConfigFromAPI(cfg *config.Config) {
 	s.mu.Lock()
 	s.config = cfg
+	s.mu.Unlock()
+
+	s.history.Resize(cfg.Display.History.Window)
+	s.updateVisualizers(cfg)
+	s.applyTransition(cfg.Display.Transition)
+	s.applyCalibration(cfg)
+	s.schedule.update(cfg)
+	s.applySchedule()
+}
+
+// updateVisualizers passes cfg to the visualizers, with the brightness and display mode the
+// schedule asks for.
+func (s *Service) updateVisualizers(cfg *config.Config) {
+	s.mu.RLock()
 	vis := s.visualizer
 	multiVis := s.multiVisualizer
 	isMulti := s.usingMultiple
-	s.mu.Unlock()
+	scheduled := s.scheduled
+	brightness := s.displayBrightnessUnsafe()
+	away := s.idleState != idleActive
+	s.mu.RUnlock()
+
+	if scheduled != nil || away {
+		// Change a copy, so the configured settings come back when the window ends
+		scheduledConfig := *cfg
+		scheduledConfig.Matrix.Brightness = brightness
+
+		if scheduled != nil {
+			scheduledConfig.Display.Mode = cmp.Or(scheduled.mode, cfg.Display.Mode)
+		}
+
+		cfg = &scheduledConfig
+	}
 
 	if isMulti && multiVis != nil {
 		multiVis.UpdateConfig(cfg)
@@ -858,3 +1266,55 @@ func (s *Service) applyConfigFromAPI(cfg *config.Config) {
 		vis.UpdateConfig(cfg)
 	}
 }
+
+// applyTransition sets how the displays animate changes.
+func (s *Service) applyTransition(cfg config.TransitionConfig) {
+	transition := displayTransition(cfg)
+
+	if s.usingMultiple && s.multiDisplay != nil {
+		s.multiDisplay.SetTransition(transition)
+	} else if s.display != nil {
+		s.display.SetTransition(transition)
+	}
+}
+
+// applyCalibration recalibrates every connected matrix from cfg.
+func (s *Service) applyCalibration(cfg *config.Config) {
+	calibrations := make(map[string]matrix.Calibration)
+	supervisors := make(map[string]*matrix.Supervisor)
+
+	s.mu.RLock()
+	if s.usingMultiple && s.multiClient != nil {
+		for _, m := range cfg.ConvertMatrices() {
+			if supervisor := s.multiClient.GetSupervisor(m.Name); supervisor != nil {
+				supervisors[m.Name] = supervisor
+				calibrations[m.Name] = displayCalibration(m.Calibration)
+			}
+		}
+	} else if s.matrix != nil {
+		supervisors["single"] = s.matrix
+		calibrations["single"] = displayCalibration(cfg.Matrix.Calibration)
+	}
+	s.mu.RUnlock()
+
+	for name, supervisor := range supervisors {
+		if err := supervisor.SetCalibration(calibrations[name]); err != nil {
+			s.eventLogger.LogMatrix(logging.LevelWarn, "failed to calibrate matrix", name, map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// displayCalibration converts the calibration configuration for a matrix.
+func displayCalibration(cfg config.CalibrationConfig) matrix.Calibration {
+	return matrix.Calibration{Gamma: cfg.Gamma, Scale: cfg.Scale, Offset: cfg.Offset, Max: cfg.Max}
+}
+
+// displayTransition converts the transition configuration for a DisplayManager.
+func displayTransition(cfg config.TransitionConfig) matrix.Transition {
+	// An unknown easing was rejected when the config was validated; nil falls back to ease-in-out
+	easing, _ := matrix.ParseEasing(cfg.Easing) //nolint:errcheck // validated with the config
+
+	return matrix.Transition{Easing: easing, Duration: cfg.Duration}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/takama/daemon"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/testutils"
 )
 
@@ -139,6 +141,303 @@ func TestServiceCreation(t *testing.T) {
 	if service.stopCh == nil {
 		t.Error("NewService() stop channel not initialized")
 	}
+
+	if service.history == nil || service.history.Capacity() != cfg.Display.History.Window {
+		t.Error("NewService() history not sized to the configured window")
+	}
+}
+
+func TestServiceApplyConfigResizesHistory(t *testing.T) {
+	service, err := NewService(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	t.Cleanup(service.cancel)
+
+	cfg := config.DefaultConfig()
+	cfg.Display.History.Window = 10
+	service.applyConfigFromAPI(cfg)
+
+	if got := service.history.Capacity(); got != 10 {
+		t.Errorf("history capacity = %d, want 10 after config update", got)
+	}
+}
+
+// fakeProber answers version requests with a fixed response, or blocks until release is closed.
+type fakeProber struct {
+	err     error
+	release chan struct{}
+	version []byte
+}
+
+func (f *fakeProber) GetVersion() ([]byte, error) {
+	if f.release != nil {
+		<-f.release
+	}
+
+	return f.version, f.err
+}
+
+func TestProbeMatrixVersion(t *testing.T) {
+	hung := &fakeProber{release: make(chan struct{})}
+	t.Cleanup(func() { close(hung.release) })
+
+	tests := []struct {
+		prober  *fakeProber
+		name    string
+		wantErr bool
+	}{
+		{name: "valid response", prober: &fakeProber{version: []byte{0, 0x11, 0}}},
+		{name: "write fails", prober: &fakeProber{err: errors.New("failed to write command")}, wantErr: true},
+		{name: "short response", prober: &fakeProber{version: []byte{0}}, wantErr: true},
+		{name: "no response", prober: hung, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := probeMatrixVersion(ctx, tt.prober)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("probeMatrixVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceRegistersMatrixHealthChecks(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Matrix.DualMode = "split"
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "role": "primary"},
+		{"name": "right", "role": "secondary"},
+	}
+
+	service, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	t.Cleanup(service.cancel)
+
+	service.registerHealthChecks()
+	health := service.healthMonitor.GetHealth()
+
+	for _, name := range []string{"matrix:left", "matrix:right"} {
+		if _, ok := health[name]; !ok {
+			t.Errorf("no health check registered for %s, got %v", name, health)
+		}
+	}
+
+	if _, ok := health["matrix"]; ok {
+		t.Error("single matrix health check registered in multi-matrix mode")
+	}
+
+	if err := service.checkMatrix(context.Background(), "left"); err == nil {
+		t.Error("checkMatrix() should fail for a matrix that is not connected")
+	}
+}
+
+func TestServiceMatrixHealthAfterSingleFallback(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Matrix.DualMode = "split"
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "port": "missing-left", "role": "primary"},
+		{"name": "right", "port": "missing-right", "role": "secondary"},
+	}
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": matrix.NewVirtualMatrix()})
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if service.IsMultiMatrix() {
+		t.Fatal("service should fall back to a single matrix when neither configured port opens")
+	}
+
+	// The checkers registered for the configured matrices probe the matrix actually in use
+	for _, name := range []string{"left", "right"} {
+		if err := service.checkMatrix(context.Background(), name); err != nil {
+			t.Errorf("checkMatrix(%q) error = %v, want the fallback matrix to answer", name, err)
+		}
+	}
+}
+
+func TestServiceReadsFirmwareOnReconnect(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	// The module comes back with firmware too old for greyscale
+	older := matrix.FirmwareVersion{Minor: 1, Patch: 1}
+	service.handleConnectionEvent(matrix.ConnectionEvent{Kind: matrix.EventReconnected, Matrix: "single", Firmware: &older})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for service.FirmwareVersions()["single"] != "0.1.1" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := service.FirmwareVersions()["single"]; got != "0.1.1" {
+		t.Fatalf("firmware = %q, want 0.1.1 read on reconnecting", got)
+	}
+
+	fb := matrix.NewFramebuffer()
+	fb.SetPixel(3, 1, 40)
+
+	if err := service.display.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	if err := service.matrix.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if got := vm.Frame().Pixel(3, 1); got != 0xFF {
+		t.Errorf("pixel = %d, want frames drawn at full brightness on the older firmware", got)
+	}
+}
+
+// newVirtualService creates a service whose matrices are emulated by the given virtual matrices,
+// keyed by port name.
+func newVirtualService(t *testing.T, cfg *config.Config, matrices map[string]*matrix.VirtualMatrix) *Service {
+	t.Helper()
+
+	service, err := NewService(cfg)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	service.SetMatrixOpener(matrix.VirtualOpener(matrices))
+
+	return service
+}
+
+func TestServiceVirtualMatrixPixels(t *testing.T) {
+	vm := matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Matrix.Port = "virtual0"
+	cfg.Matrix.Brightness = 90
+	cfg.Display.UpdateRate = time.Nanosecond // Draw on every update
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{"virtual0": vm})
+	t.Cleanup(func() {
+		service.healthMonitor.Stop()
+		service.cancel()
+	})
+
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	// Commands are written in the background; wait for them before looking at the LEDs
+	flush := func() {
+		if err := service.matrix.Flush(); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	flush()
+
+	if vm.Brightness() != 90 {
+		t.Errorf("brightness = %d, want 90 from the config", vm.Brightness())
+	}
+
+	if err := service.checkMatrix(context.Background(), ""); err != nil {
+		t.Errorf("checkMatrix() error = %v, want the virtual matrix to answer", err)
+	}
+
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 50}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	flush()
+
+	if got := vm.Pattern(); len(got) != 2 || got[0] != matrix.PatternPercentage || got[1] != 50 {
+		t.Errorf("pattern = %v, want 50%% CPU shown as a percentage", got)
+	}
+
+	// History mode draws a column per sample, newest on the right
+	if err := service.SetDisplayMode("history"); err != nil {
+		t.Fatalf("SetDisplayMode() error = %v", err)
+	}
+
+	for _, cpu := range []float64{100, 0, 50} {
+		service.history.Add(stats.StatsSummary{CPUUsage: cpu})
+	}
+
+	if err := service.visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 50}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	flush()
+
+	want := matrix.NewFramebuffer()
+	want.FillRect(31, 0, 1, 9, 255)
+	want.FillRect(33, 4, 1, 5, 255)
+
+	if got := vm.Frame(); !got.Equal(want) {
+		t.Errorf("history frame lit the wrong LEDs:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestServiceRunsAgainstVirtualMatrices(t *testing.T) {
+	left, right := matrix.NewVirtualMatrix(), matrix.NewVirtualMatrix()
+	cfg := config.DefaultConfig()
+	cfg.Display.UpdateRate = 20 * time.Millisecond
+	cfg.Stats.CollectInterval = 20 * time.Millisecond
+	cfg.Matrix.DualMode = "mirror"
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "port": "virtual-left", "role": "primary", "brightness": 100},
+		{"name": "right", "port": "virtual-right", "role": "secondary", "brightness": 100},
+	}
+
+	service := newVirtualService(t, cfg, map[string]*matrix.VirtualMatrix{
+		"virtual-left":  left,
+		"virtual-right": right,
+	})
+
+	if err := service.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if !service.IsMultiMatrix() {
+		t.Error("service should drive both virtual matrices in multi-matrix mode")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for (left.Pattern() == nil || right.Pattern() == nil) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if left.Pattern() == nil || right.Pattern() == nil {
+		t.Fatal("the system loop should have drawn stats on both matrices")
+	}
+
+	if err := service.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if left.Brightness() != 0 || right.Brightness() != 0 {
+		t.Errorf("Stop() should turn both matrices off, brightness = %d and %d", left.Brightness(), right.Brightness())
+	}
 }
 
 func TestServiceInitialization(t *testing.T) {
@@ -0,0 +1,165 @@
+package idle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lines a desktop hook writes to a FileSource.
+const (
+	StateActive   = "active"   // The user is back
+	StateIdle     = "idle"     // The user has gone idle
+	StateLocked   = "locked"   // The session was locked
+	StateUnlocked = "unlocked" // The session was unlocked, so the user is back
+)
+
+// FileSource follows the user's state from lines a desktop hook writes to a file or named pipe,
+// such as "echo idle >> $XDG_RUNTIME_DIR/framework-led-idle" from an idle daemon. The user counts
+// as active until the hook writes idle, and as idle from then until it writes active or unlocked.
+// It is safe for concurrent use.
+type FileSource struct {
+	idleSince time.Time // When the hook said the user went idle; zero while active
+	modTime   time.Time // Of the regular file when it was last read
+	now       func() time.Time
+	pipe      *os.File // Open named pipe, or nil for a regular file
+	path      string
+	read      uint64 // Hash of the part of the regular file already read
+	offset    int    // Length of the part of the regular file already read
+	mu        sync.Mutex
+	locked    bool
+}
+
+// NewFileSource creates a FileSource reading path. A named pipe is read as lines arrive; a regular
+// file is read for new lines whenever it changes, and need not exist yet.
+func NewFileSource(path string) (*FileSource, error) {
+	s := &FileSource{path: path, now: time.Now}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		return s, nil
+	}
+
+	// Opening for writing too keeps the pipe open between hooks, so reads block rather than
+	// returning EOF when a hook closes its end
+	pipe, err := os.OpenFile(path, os.O_RDWR, 0) //nolint:gosec // the path comes from the user's own configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to open idle pipe: %w", err)
+	}
+
+	s.pipe = pipe
+
+	go s.readPipe(pipe)
+
+	return s, nil
+}
+
+// Close stops reading a named pipe.
+func (s *FileSource) Close() error {
+	if s.pipe == nil {
+		return nil
+	}
+
+	return s.pipe.Close()
+}
+
+// Activity returns the state the hook last wrote.
+func (s *FileSource) Activity() (Activity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pipe == nil {
+		if err := s.pollUnsafe(); err != nil {
+			return Activity{}, err
+		}
+	}
+
+	last := s.now()
+	if !s.idleSince.IsZero() {
+		last = s.idleSince
+	}
+
+	return Activity{Last: last, Locked: s.locked}, nil
+}
+
+func (s *FileSource) readPipe(pipe io.Reader) {
+	scanner := bufio.NewScanner(pipe)
+
+	for scanner.Scan() {
+		s.mu.Lock()
+		s.applyUnsafe(scanner.Text(), s.now())
+		s.mu.Unlock()
+	}
+}
+
+// pollUnsafe applies the lines added to the regular file since it was last read, as of the time
+// it was written. A file that was rewritten rather than appended to is read again from the start.
+func (s *FileSource) pollUnsafe() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		// The hook has not written anything yet
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read idle file: %w", err)
+	}
+
+	if info.ModTime().Equal(s.modTime) && info.Size() == int64(s.offset) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read idle file: %w", err)
+	}
+
+	if len(data) < s.offset || hashBytes(data[:s.offset]) != s.read {
+		s.offset = 0
+	}
+
+	// Leave a line that is still being written for next time
+	complete := bytes.LastIndexByte(data, '\n') + 1
+
+	if complete > s.offset {
+		for _, line := range strings.Split(string(data[s.offset:complete]), "\n") {
+			s.applyUnsafe(line, info.ModTime())
+		}
+
+		s.offset = complete
+		s.read = hashBytes(data[:complete])
+	}
+
+	s.modTime = info.ModTime()
+
+	return nil
+}
+
+func hashBytes(data []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(data) //nolint:errcheck // hashes never fail to write
+
+	return h.Sum64()
+}
+
+func (s *FileSource) applyUnsafe(line string, at time.Time) {
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case StateActive:
+		s.idleSince = time.Time{}
+	case StateIdle:
+		if s.idleSince.IsZero() {
+			s.idleSince = at
+		}
+	case StateLocked:
+		s.locked = true
+	case StateUnlocked:
+		s.locked = false
+		s.idleSince = time.Time{}
+	}
+}
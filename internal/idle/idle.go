@@ -0,0 +1,100 @@
+// Package idle reports whether the user is at the machine, from input devices and from hooks in
+// the desktop session, so the daemon can dim and sleep the LED matrices while nobody is looking.
+package idle
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Activity is what a Source knows about the user.
+type Activity struct {
+	Last   time.Time // Most recent input or other sign of the user
+	Locked bool      // The session is locked, so the user is away whatever Last says
+}
+
+// Source reports user activity.
+type Source interface {
+	Activity() (Activity, error)
+}
+
+// Merge returns a Source that reports the latest activity of all the given sources, and a lock if
+// any of them is locked. Sources that fail are left out, unless they all fail.
+func Merge(sources ...Source) Source {
+	return merged(sources)
+}
+
+type merged []Source
+
+func (m merged) Activity() (Activity, error) {
+	var (
+		result Activity
+		errs   []error
+	)
+
+	for _, source := range m {
+		activity, err := source.Activity()
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if activity.Last.After(result.Last) {
+			result.Last = activity.Last
+		}
+
+		result.Locked = result.Locked || activity.Locked
+	}
+
+	if len(errs) == len(m) {
+		return Activity{}, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+// Fake is a Source whose activity is set by hand, for tests. It is safe for concurrent use.
+type Fake struct {
+	activity Activity
+	err      error
+	mu       sync.Mutex
+}
+
+// NewFake creates a Fake reporting activity now.
+func NewFake() *Fake {
+	return &Fake{activity: Activity{Last: time.Now()}}
+}
+
+// Activity returns the activity last set.
+func (f *Fake) Activity() (Activity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.activity, f.err
+}
+
+// Touch records activity at t.
+func (f *Fake) Touch(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.activity.Last = t
+}
+
+// SetLocked locks or unlocks the fake session.
+func (f *Fake) SetLocked(locked bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.activity.Locked = locked
+}
+
+// SetError makes Activity fail with err, or succeed again if err is nil.
+func (f *Fake) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+}
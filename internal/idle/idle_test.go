@@ -0,0 +1,129 @@
+package idle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	earlier, later := NewFake(), NewFake()
+	earlier.Touch(time.Unix(100, 0))
+	later.Touch(time.Unix(200, 0))
+	earlier.SetLocked(true)
+
+	broken := NewFake()
+	broken.SetError(errors.New("unavailable"))
+
+	activity, err := Merge(earlier, broken, later).Activity()
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+
+	if !activity.Last.Equal(time.Unix(200, 0)) || !activity.Locked {
+		t.Errorf("Activity() = %+v, want the later time and locked", activity)
+	}
+
+	if _, err := Merge(broken).Activity(); err == nil {
+		t.Error("Activity() succeeded with every source failing, want an error")
+	}
+}
+
+func TestInputSource(t *testing.T) {
+	dir := t.TempDir()
+	used := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	for i, at := range []time.Time{used.Add(-time.Minute), used} {
+		device := filepath.Join(dir, "event"+strconv.Itoa(i))
+		if err := os.WriteFile(device, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(device, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activity, err := NewInputSource(dir).Activity()
+	if err != nil {
+		t.Fatalf("Activity() error = %v", err)
+	}
+
+	if !activity.Last.Equal(used) {
+		t.Errorf("Activity().Last = %v, want %v", activity.Last, used)
+	}
+
+	if _, err := NewInputSource(t.TempDir()).Activity(); err == nil {
+		t.Error("Activity() succeeded without input devices, want an error")
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idle")
+	now := time.Unix(10000, 0)
+
+	source, err := NewFileSource(path)
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	source.now = func() time.Time { return now }
+
+	write := func(data string, at time.Time) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	activity := func() Activity {
+		t.Helper()
+
+		activity, err := source.Activity()
+		if err != nil {
+			t.Fatalf("Activity() error = %v", err)
+		}
+
+		return activity
+	}
+
+	// Nothing written yet means the user is there
+	if got := activity(); !got.Last.Equal(now) {
+		t.Errorf("Activity().Last = %v before any hook, want now", got.Last)
+	}
+
+	idleAt := now.Add(-5 * time.Minute)
+	write("idle\n", idleAt)
+
+	if got := activity(); !got.Last.Equal(idleAt) {
+		t.Errorf("Activity().Last = %v, want %v when the hook said idle", got.Last, idleAt)
+	}
+
+	// A line still being written waits for its newline
+	write("idle\nlock", now)
+
+	if got := activity(); got.Locked {
+		t.Error("Activity() applied a partial line")
+	}
+
+	write("idle\nlocked\n", now)
+
+	if got := activity(); !got.Locked {
+		t.Error("Activity() is not locked after the hook wrote locked")
+	}
+
+	// A rewritten file is read from the start
+	write("unlocked\n", now)
+
+	if got := activity(); got.Locked || !got.Last.Equal(now) {
+		t.Errorf("Activity() = %+v after unlocking, want unlocked and active now", got)
+	}
+}
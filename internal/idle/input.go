@@ -0,0 +1,57 @@
+package idle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultInputDir is where Linux puts input device nodes.
+const DefaultInputDir = "/dev/input"
+
+// InputSource reports the last time any input device was used. The kernel touches the timestamps
+// of an event device node when it delivers input, so no device has to be opened or read.
+type InputSource struct {
+	dir string
+}
+
+// NewInputSource creates an InputSource watching the event devices in dir, or in DefaultInputDir
+// if dir is empty.
+func NewInputSource(dir string) *InputSource {
+	if dir == "" {
+		dir = DefaultInputDir
+	}
+
+	return &InputSource{dir: dir}
+}
+
+// Activity returns the latest access or modification time of the event devices.
+func (s *InputSource) Activity() (Activity, error) {
+	devices, err := filepath.Glob(filepath.Join(s.dir, "event*"))
+	if err != nil {
+		return Activity{}, fmt.Errorf("failed to list input devices: %w", err)
+	}
+
+	var last time.Time
+
+	for _, device := range devices {
+		info, err := os.Stat(device)
+		if err != nil {
+			// Devices come and go as they are plugged in and out
+			continue
+		}
+
+		for _, t := range []time.Time{info.ModTime(), accessTime(info)} {
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+
+	if last.IsZero() {
+		return Activity{}, fmt.Errorf("no input devices found in %s", s.dir)
+	}
+
+	return Activity{Last: last}, nil
+}
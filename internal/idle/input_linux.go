@@ -0,0 +1,17 @@
+package idle
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when a file was last read, or the zero time if it is not known.
+func accessTime(info os.FileInfo) time.Time {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+
+	return time.Unix(stat.Atim.Unix())
+}
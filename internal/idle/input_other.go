@@ -0,0 +1,13 @@
+//go:build !linux
+
+package idle
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns the zero time because access times are only read on Linux.
+func accessTime(os.FileInfo) time.Time {
+	return time.Time{}
+}
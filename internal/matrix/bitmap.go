@@ -0,0 +1,59 @@
+package matrix
+
+// DrawBWSize is the number of bytes in a DrawBW payload: 306 pixels packed one bit each.
+const DrawBWSize = 39
+
+// Bitmap is a 34x9 black and white image in the same orientation as Framebuffer.
+type Bitmap [FramebufferHeight][FramebufferWidth]bool
+
+// drawBWBit returns the byte index and bit mask for a pixel in the DrawBW payload.
+// The firmware walks its 9-wide hardware grid row by row, least significant bit first,
+// so hardware row x and column y map to bit x*9 + y.
+func drawBWBit(x, y int) (int, byte) {
+	index := x*FramebufferHeight + y
+
+	return index / 8, 1 << (index % 8)
+}
+
+// PackBitmap packs a Bitmap into the 39-byte layout expected by DrawBWCommand.
+func PackBitmap(bitmap Bitmap) [DrawBWSize]byte {
+	var packed [DrawBWSize]byte
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			if bitmap[y][x] {
+				i, mask := drawBWBit(x, y)
+				packed[i] |= mask
+			}
+		}
+	}
+
+	return packed
+}
+
+// UnpackBitmap decodes a 39-byte DrawBW payload back into a Bitmap. Padding bits are ignored.
+func UnpackBitmap(packed [DrawBWSize]byte) Bitmap {
+	var bitmap Bitmap
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			i, mask := drawBWBit(x, y)
+			bitmap[y][x] = packed[i]&mask != 0
+		}
+	}
+
+	return bitmap
+}
+
+// Threshold converts the framebuffer to a Bitmap, lighting every pixel at or above level.
+func (fb *Framebuffer) Threshold(level byte) Bitmap {
+	var bitmap Bitmap
+
+	for y := range fb.pixels {
+		for x, value := range fb.pixels[y] {
+			bitmap[y][x] = value > 0 && value >= level
+		}
+	}
+
+	return bitmap
+}
@@ -0,0 +1,112 @@
+package matrix
+
+import "testing"
+
+func TestPackBitmapLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, y      int
+		byteIndex int
+		mask      byte
+	}{
+		{"first pixel", 0, 0, 0, 0x01},
+		{"second hardware column", 0, 1, 0, 0x02},
+		{"end of first hardware row", 0, 8, 1, 0x01},
+		{"start of second hardware row", 1, 0, 1, 0x02},
+		{"last pixel", 33, 8, 38, 0x02},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bitmap Bitmap
+
+			bitmap[tt.y][tt.x] = true
+
+			packed := PackBitmap(bitmap)
+
+			for i, b := range packed {
+				want := byte(0)
+				if i == tt.byteIndex {
+					want = tt.mask
+				}
+
+				if b != want {
+					t.Errorf("PackBitmap() byte %d = 0x%02X, want 0x%02X", i, b, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPackUnpackBitmapRoundTrip(t *testing.T) {
+	var bitmap Bitmap
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			bitmap[y][x] = (x*7+y*3)%5 == 0
+		}
+	}
+
+	if got := UnpackBitmap(PackBitmap(bitmap)); got != bitmap {
+		t.Error("UnpackBitmap(PackBitmap()) did not round-trip")
+	}
+
+	var full Bitmap
+
+	for y := range full {
+		for x := range full[y] {
+			full[y][x] = true
+		}
+	}
+
+	packed := PackBitmap(full)
+	if packed[38] != 0x03 {
+		t.Errorf("PackBitmap() of full image last byte = 0x%02X, want 0x03 (padding bits clear)", packed[38])
+	}
+}
+
+func TestFramebufferThreshold(t *testing.T) {
+	fb := NewFramebuffer()
+	fb.SetPixel(1, 1, 200)
+	fb.SetPixel(2, 2, 50)
+
+	bitmap := fb.Threshold(128)
+
+	if !bitmap[1][1] {
+		t.Error("Threshold() should light pixel above level")
+	}
+
+	if bitmap[2][2] {
+		t.Error("Threshold() should not light pixel below level")
+	}
+
+	if fb.Threshold(0)[0][0] {
+		t.Error("Threshold(0) should not light pixels that are off")
+	}
+}
+
+func TestDisplayManagerDrawBitmap(t *testing.T) {
+	mockClient := NewMockClient()
+	dm := NewDisplayManager(mockClient)
+
+	var bitmap Bitmap
+
+	bitmap[4][10] = true
+
+	if err := dm.DrawBitmap(PackBitmap(bitmap)); err != nil {
+		t.Fatalf("DrawBitmap() error = %v", err)
+	}
+
+	commands := mockClient.GetCommands()
+	if len(commands) != 1 || commands[0].ID != CmdDrawBW {
+		t.Fatalf("DrawBitmap() sent %v, want a single DrawBW command", commands)
+	}
+
+	var params [DrawBWSize]byte
+
+	copy(params[:], commands[0].Params)
+
+	if UnpackBitmap(params) != bitmap {
+		t.Error("DrawBitmap() sent a payload that does not decode to the original bitmap")
+	}
+}
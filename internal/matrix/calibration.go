@@ -0,0 +1,99 @@
+package matrix
+
+import "math"
+
+// Calibration corrects what is sent to one module, so that modules side by side look equally
+// bright and greyscale steps look even. Gamma curves both the brightness and greyscale pixel
+// values; Scale, Offset and Max then adjust the brightness alone. The zero value changes nothing.
+type Calibration struct {
+	Gamma  float64 // Exponent applied to brightness and pixel values; 0 or 1 is linear
+	Scale  float64 // Multiplies the brightness; 0 leaves it as is
+	Offset int     // Added to the brightness after scaling
+	Max    byte    // Highest brightness sent; 0 is 255
+}
+
+// calibrationTables map every raw brightness and pixel value to the value sent to the module.
+type calibrationTables struct {
+	brightness [256]byte
+	pixel      [256]byte
+}
+
+// tables returns the lookup tables for c, or nil if c changes nothing.
+func (c Calibration) tables() *calibrationTables {
+	if c == (Calibration{}) {
+		return nil
+	}
+
+	t := &calibrationTables{}
+
+	for v := range 256 {
+		t.brightness[v] = c.Brightness(byte(v))
+		t.pixel[v] = c.Pixel(byte(v))
+	}
+
+	return t
+}
+
+// Brightness returns the calibrated brightness for level. A level of 0 stays 0, so a matrix
+// switched off stays off whatever the offset.
+func (c Calibration) Brightness(level byte) byte {
+	if level == 0 {
+		return 0
+	}
+
+	v := float64(c.Pixel(level))
+
+	if c.Scale > 0 {
+		v *= c.Scale
+	}
+
+	v += float64(c.Offset)
+
+	maxLevel := 255.0
+	if c.Max > 0 {
+		maxLevel = float64(c.Max)
+	}
+
+	return byte(math.Round(math.Max(0, math.Min(v, maxLevel))))
+}
+
+// Pixel returns the gamma-corrected greyscale value. A lit pixel is never corrected down to off.
+func (c Calibration) Pixel(value byte) byte {
+	if c.Gamma <= 0 || c.Gamma == 1 || value == 0 {
+		return value
+	}
+
+	v := math.Round(255 * math.Pow(float64(value)/255, c.Gamma))
+
+	return byte(math.Max(1, v))
+}
+
+// apply returns cmd with its brightness or pixel values calibrated. Other commands, and all
+// commands when t is nil, are returned unchanged.
+func (t *calibrationTables) apply(cmd Command) Command {
+	if t == nil {
+		return cmd
+	}
+
+	switch cmd.ID {
+	case CmdBrightness:
+		if len(cmd.Params) > 0 {
+			params := []byte{t.brightness[cmd.Params[0]]}
+			cmd.Params = append(params, cmd.Params[1:]...)
+		}
+	case CmdStageCol:
+		if len(cmd.Params) > 1 {
+			// The first parameter is the column; the rest are its pixels
+			params := make([]byte, len(cmd.Params))
+			params[0] = cmd.Params[0]
+
+			for i, v := range cmd.Params[1:] {
+				params[i+1] = t.pixel[v]
+			}
+
+			cmd.Params = params
+		}
+	}
+
+	return cmd
+}
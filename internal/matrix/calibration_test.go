@@ -0,0 +1,94 @@
+package matrix
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCalibrationBrightness(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration Calibration
+		level       byte
+		want        byte
+	}{
+		{"zero value", Calibration{}, 100, 100},
+		{"gamma", Calibration{Gamma: 2}, 128, 64},
+		{"scale", Calibration{Scale: 0.8}, 100, 80},
+		{"offset", Calibration{Offset: 10}, 100, 110},
+		{"negative offset", Calibration{Offset: -20}, 10, 0},
+		{"cap", Calibration{Max: 200}, 255, 200},
+		{"scale past full", Calibration{Scale: 2}, 200, 255},
+		{"off stays off", Calibration{Offset: 10}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calibration.Brightness(tt.level); got != tt.want {
+				t.Errorf("Brightness(%d) = %d, want %d", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibrationPixel(t *testing.T) {
+	c := Calibration{Gamma: 2.2, Scale: 0.5, Offset: 10}
+
+	if got := c.Pixel(255); got != 255 {
+		t.Errorf("Pixel(255) = %d, want 255; scale and offset only apply to brightness", got)
+	}
+
+	if got := c.Pixel(128); got != 56 {
+		t.Errorf("Pixel(128) = %d, want 56", got)
+	}
+
+	if got := c.Pixel(5); got != 1 {
+		t.Errorf("Pixel(5) = %d, want a dim pixel to stay lit at 1", got)
+	}
+
+	if got := c.Pixel(0); got != 0 {
+		t.Errorf("Pixel(0) = %d, want 0", got)
+	}
+}
+
+func TestSupervisorCalibrates(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+	port := h.opened["/dev/ttyACM0"]
+
+	_ = s.SetBrightness(200)
+	_ = s.StageColumn(0, [34]byte{255, 128})
+	_ = s.FlushColumns()
+
+	sent := len(port.GetWrittenData())
+
+	// A new calibration redraws what is shown
+	if err := s.SetCalibration(Calibration{Gamma: 2, Max: 100}); err != nil {
+		t.Fatalf("SetCalibration() error = %v", err)
+	}
+
+	var want []byte
+	for _, cmd := range []Command{
+		BrightnessCommand(100),
+		StageColCommand(0, [34]byte{255, 64}),
+		FlushColsCommand(),
+	} {
+		want = append(want, cmd.ToBytes()...)
+	}
+
+	if got := port.GetWrittenData()[sent:]; !bytes.Equal(got, want) {
+		t.Errorf("port received %v, want the display redrawn calibrated %v", got, want)
+	}
+
+	sent = len(port.GetWrittenData())
+
+	// Setting the same calibration again sends nothing
+	if err := s.SetCalibration(Calibration{Gamma: 2, Max: 100}); err != nil {
+		t.Fatalf("SetCalibration() error = %v", err)
+	}
+
+	_ = s.SetBrightness(50)
+
+	if got, want := port.GetWrittenData()[sent:], BrightnessCommand(10).ToBytes(); !bytes.Equal(got, want) {
+		t.Errorf("port received %v, want only the calibrated brightness %v", got, want)
+	}
+}
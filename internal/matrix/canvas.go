@@ -0,0 +1,108 @@
+package matrix
+
+import "fmt"
+
+// Layouts for a Canvas spanning two modules.
+const (
+	// LayoutHorizontal places the modules side by side as one 68x9 canvas.
+	LayoutHorizontal = "horizontal"
+	// LayoutVertical stacks the modules as one 34x18 canvas.
+	LayoutVertical = "vertical"
+)
+
+// canvasSlots is the number of modules a Canvas spans.
+const canvasSlots = 2
+
+// Canvas is a greyscale drawing surface spanning two modules. Slot 0 is the left or top module
+// and slot 1 the right or bottom one; Slice extracts the Framebuffer for each.
+type Canvas struct {
+	pixels [][]byte
+	layout string
+}
+
+// NewCanvas creates a blank Canvas for the given layout. An empty layout is treated as horizontal.
+func NewCanvas(layout string) (*Canvas, error) {
+	width, height := FramebufferWidth*canvasSlots, FramebufferHeight
+
+	switch layout {
+	case LayoutHorizontal, "":
+		layout = LayoutHorizontal
+	case LayoutVertical:
+		width, height = FramebufferWidth, FramebufferHeight*canvasSlots
+	default:
+		return nil, fmt.Errorf("unknown canvas layout: %s", layout)
+	}
+
+	pixels := make([][]byte, height)
+	for y := range pixels {
+		pixels[y] = make([]byte, width)
+	}
+
+	return &Canvas{pixels: pixels, layout: layout}, nil
+}
+
+// Layout returns the canvas layout.
+func (c *Canvas) Layout() string {
+	return c.layout
+}
+
+// Size returns the canvas dimensions.
+func (c *Canvas) Size() (int, int) {
+	return len(c.pixels[0]), len(c.pixels)
+}
+
+// SetPixel sets the brightness of the pixel at (x, y). Coordinates outside the canvas are ignored.
+func (c *Canvas) SetPixel(x, y int, value byte) {
+	if y < 0 || y >= len(c.pixels) || x < 0 || x >= len(c.pixels[y]) {
+		return
+	}
+
+	c.pixels[y][x] = value
+}
+
+// Pixel returns the brightness of the pixel at (x, y), or 0 if the coordinates are outside the canvas.
+func (c *Canvas) Pixel(x, y int) byte {
+	if y < 0 || y >= len(c.pixels) || x < 0 || x >= len(c.pixels[y]) {
+		return 0
+	}
+
+	return c.pixels[y][x]
+}
+
+// Clear turns all pixels off.
+func (c *Canvas) Clear() {
+	for y := range c.pixels {
+		clear(c.pixels[y])
+	}
+}
+
+// Line draws a straight line from (x0, y0) to (x1, y1) inclusive.
+func (c *Canvas) Line(x0, y0, x1, y1 int, value byte) {
+	drawLine(c, x0, y0, x1, y1, value)
+}
+
+// FillRect fills a w x h rectangle with its top-left corner at (x, y).
+func (c *Canvas) FillRect(x, y, w, h int, value byte) {
+	fillRect(c, x, y, w, h, value)
+}
+
+// Slice returns the part of the canvas shown by the module in the given slot.
+func (c *Canvas) Slice(slot int) *Framebuffer {
+	fb := NewFramebuffer()
+	if slot < 0 || slot >= canvasSlots {
+		return fb
+	}
+
+	offsetX, offsetY := slot*FramebufferWidth, 0
+	if c.layout == LayoutVertical {
+		offsetX, offsetY = 0, slot*FramebufferHeight
+	}
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			fb.pixels[y][x] = c.Pixel(offsetX+x, offsetY+y)
+		}
+	}
+
+	return fb
+}
@@ -0,0 +1,226 @@
+package matrix
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestMultiDisplayManager builds a MultiDisplayManager over mock clients with the given roles.
+func newTestMultiDisplayManager(roles map[string]string, layout string) (*MultiDisplayManager, map[string]*MockClient) {
+	mc := NewMultiClient()
+	mdm := &MultiDisplayManager{
+		displays:    make(map[string]*DisplayManager),
+		multiClient: mc,
+		dualMode:    "extended",
+		layout:      layout,
+	}
+	clients := make(map[string]*MockClient)
+
+	for name, role := range roles {
+		client := NewMockClient()
+		clients[name] = client
+		mdm.displays[name] = NewDisplayManager(client)
+		mc.config[name] = &SingleMatrixConfig{Name: name, Role: role}
+	}
+
+	return mdm, clients
+}
+
+func TestNewCanvas(t *testing.T) {
+	tests := []struct {
+		layout        string
+		width, height int
+	}{
+		{"", 68, 9},
+		{LayoutHorizontal, 68, 9},
+		{LayoutVertical, 34, 18},
+	}
+
+	for _, tt := range tests {
+		canvas, err := NewCanvas(tt.layout)
+		if err != nil {
+			t.Fatalf("NewCanvas(%q) error = %v", tt.layout, err)
+		}
+
+		if w, h := canvas.Size(); w != tt.width || h != tt.height {
+			t.Errorf("NewCanvas(%q) size = %dx%d, want %dx%d", tt.layout, w, h, tt.width, tt.height)
+		}
+	}
+
+	if _, err := NewCanvas("diagonal"); err == nil {
+		t.Error("NewCanvas() should reject unknown layouts")
+	}
+}
+
+func TestCanvasSliceHorizontal(t *testing.T) {
+	canvas, _ := NewCanvas(LayoutHorizontal)
+	canvas.Line(30, 4, 37, 4, 200)
+
+	left, right := canvas.Slice(0), canvas.Slice(1)
+
+	if left.Pixel(33, 4) != 200 || left.Pixel(29, 4) != 0 {
+		t.Error("left slice should hold canvas columns 0-33")
+	}
+
+	if right.Pixel(0, 4) != 200 || right.Pixel(3, 4) != 200 || right.Pixel(4, 4) != 0 {
+		t.Error("right slice should hold canvas columns 34-67")
+	}
+
+	if !canvas.Slice(2).Equal(NewFramebuffer()) {
+		t.Error("out of range slot should return a blank framebuffer")
+	}
+}
+
+func TestCanvasSliceVertical(t *testing.T) {
+	canvas, _ := NewCanvas(LayoutVertical)
+	canvas.FillRect(0, 8, 34, 2, 255)
+
+	top, bottom := canvas.Slice(0), canvas.Slice(1)
+
+	if top.Pixel(10, 8) != 255 || top.Pixel(10, 7) != 0 {
+		t.Error("top slice should hold canvas rows 0-8")
+	}
+
+	if bottom.Pixel(10, 0) != 255 || bottom.Pixel(10, 1) != 0 {
+		t.Error("bottom slice should hold canvas rows 9-17")
+	}
+}
+
+func TestMultiDisplayManagerDrawCanvasByRole(t *testing.T) {
+	// Names sort opposite to roles to check that role decides the slot
+	mdm, clients := newTestMultiDisplayManager(map[string]string{"a": "secondary", "b": "primary"}, LayoutHorizontal)
+
+	canvas, err := mdm.NewCanvas()
+	if err != nil {
+		t.Fatalf("NewCanvas() error = %v", err)
+	}
+
+	canvas.SetPixel(1, 0, 255)
+	canvas.SetPixel(35, 0, 255)
+	canvas.SetPixel(36, 0, 255)
+
+	if err := mdm.DrawCanvas(canvas); err != nil {
+		t.Fatalf("DrawCanvas() error = %v", err)
+	}
+
+	lit := func(client *MockClient) int {
+		count := 0
+
+		for _, cmd := range client.GetCommands() {
+			if cmd.ID == CmdStageCol && cmd.Params[0] == 0 {
+				for _, v := range cmd.Params[1:] {
+					if v != 0 {
+						count++
+					}
+				}
+			}
+		}
+
+		return count
+	}
+
+	if got := lit(clients["b"]); got != 1 {
+		t.Errorf("primary matrix lit %d pixels, want 1", got)
+	}
+
+	if got := lit(clients["a"]); got != 2 {
+		t.Errorf("secondary matrix lit %d pixels, want 2", got)
+	}
+}
+
+func TestMultiDisplayManagerDrawCanvasNow(t *testing.T) {
+	mdm, _ := newTestMultiDisplayManager(map[string]string{"left": "primary", "right": "secondary"}, LayoutHorizontal)
+	mdm.SetTransition(Transition{Easing: EaseLinear, Duration: time.Hour})
+
+	canvas, err := mdm.NewCanvas()
+	if err != nil {
+		t.Fatalf("NewCanvas() error = %v", err)
+	}
+
+	canvas.SetPixel(40, 3, 200)
+
+	if err := mdm.DrawCanvasNow(canvas); err != nil {
+		t.Fatalf("DrawCanvasNow() error = %v", err)
+	}
+
+	for slot, name := range []string{"left", "right"} {
+		display := mdm.displays[name]
+
+		display.mu.Lock()
+		shown, fading := display.frame.Equal(canvas.Slice(slot)), len(display.fades) > 0
+		display.mu.Unlock()
+
+		if !shown || fading {
+			t.Errorf("display %s should show its slice at once, without a transition", name)
+		}
+	}
+}
+
+func TestMultiDisplayManagerExtendedMode(t *testing.T) {
+	mdm, clients := newTestMultiDisplayManager(map[string]string{"left": "primary", "right": "secondary"}, LayoutHorizontal)
+
+	// Only the primary matrix's metric (cpu by default) is drawn
+	if err := mdm.UpdateMetric("memory", 50, nil); err != nil {
+		t.Fatalf("UpdateMetric() error = %v", err)
+	}
+
+	if got := len(clients["left"].GetCommands()); got != 0 {
+		t.Errorf("UpdateMetric() for another metric sent %d commands, want 0", got)
+	}
+
+	// 75% of 68 columns is 51, so the bar fills the left module and 17 columns of the right one
+	if err := mdm.UpdateMetric("cpu", 75, nil); err != nil {
+		t.Fatalf("UpdateMetric() error = %v", err)
+	}
+
+	if mdm.displays["left"].frame.Pixel(33, 4) != 255 {
+		t.Error("extended bar should fill the primary matrix")
+	}
+
+	right := mdm.displays["right"].frame
+	if right.Pixel(16, 4) != 255 || right.Pixel(17, 4) != 0 {
+		t.Error("extended bar should continue onto the secondary matrix")
+	}
+
+	if countCommands(clients["right"].GetCommands(), CmdPattern) != 0 {
+		t.Error("extended mode should draw frames, not built-in patterns")
+	}
+}
+
+func TestMultiDisplayManagerExtendedModeVertical(t *testing.T) {
+	mdm, _ := newTestMultiDisplayManager(map[string]string{"top": "primary", "bottom": "secondary"}, LayoutVertical)
+
+	// 50% of 18 rows fills the bottom module only
+	if err := mdm.UpdateMetric("cpu", 50, nil); err != nil {
+		t.Fatalf("UpdateMetric() error = %v", err)
+	}
+
+	if mdm.displays["bottom"].frame.Pixel(0, 0) != 255 {
+		t.Error("vertical bar should fill the bottom matrix")
+	}
+
+	if mdm.displays["top"].frame.Pixel(0, 8) != 0 {
+		t.Error("vertical bar at 50% should leave the top matrix dark")
+	}
+}
+
+func TestMultiDisplayManagerDrawFrameMirrors(t *testing.T) {
+	mdm, clients := newTestMultiDisplayManager(map[string]string{"left": "primary", "right": "secondary"}, LayoutHorizontal)
+
+	fb := NewFramebuffer()
+	fb.SetPixel(5, 8, 255)
+
+	if err := mdm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	for name, client := range clients {
+		if !mdm.displays[name].frame.Equal(fb) {
+			t.Errorf("display %s does not show the frame", name)
+		}
+
+		if countCommands(client.GetCommands(), CmdFlushCols) != 1 {
+			t.Errorf("display %s should flush staged columns once", name)
+		}
+	}
+}
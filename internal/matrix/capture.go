@@ -0,0 +1,338 @@
+package matrix
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Capture formats.
+const (
+	CaptureJSONL  = "jsonl"  // One JSON object per line, with the bytes in hex
+	CaptureBinary = "binary" // Length-prefixed records after a short header
+)
+
+// Directions of captured traffic.
+const (
+	DirectionSent     = "sent"     // Written by the daemon to the module
+	DirectionReceived = "received" // Read by the daemon from the module
+)
+
+// captureMagic starts every binary capture, so ReadCapture can tell the formats apart.
+var captureMagic = []byte("FLMCAP\x01\n")
+
+// CaptureRecord is one write to or read from a module's port.
+type CaptureRecord struct {
+	Time      time.Time
+	Port      string
+	Direction string // DirectionSent or DirectionReceived
+	Data      []byte
+}
+
+// jsonRecord is how a CaptureRecord is written in a JSONL capture.
+type jsonRecord struct {
+	Time      time.Time `json:"time"`
+	Port      string    `json:"port"`
+	Direction string    `json:"direction"`
+	Data      string    `json:"data"`
+}
+
+// Capture writes a timestamped record of every byte sent to and received from the modules it is
+// attached to with RecordingOpener. It is safe for concurrent use.
+type Capture struct {
+	w      io.Writer
+	closer io.Closer
+	now    func() time.Time
+	format string
+	mu     sync.Mutex
+}
+
+// NewCapture creates a Capture that writes records to w in the given format.
+func NewCapture(w io.Writer, format string) (*Capture, error) {
+	c := &Capture{w: w, format: format, now: time.Now}
+
+	switch format {
+	case CaptureJSONL:
+	case CaptureBinary:
+		if _, err := w.Write(captureMagic); err != nil {
+			return nil, fmt.Errorf("failed to write capture header: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown capture format %q (want %s or %s)", format, CaptureJSONL, CaptureBinary)
+	}
+
+	return c, nil
+}
+
+// CreateCapture creates or truncates the file at path and returns a Capture writing to it.
+// Closing the Capture closes the file.
+func CreateCapture(path, format string) (*Capture, error) {
+	f, err := os.Create(path) //nolint:gosec // the capture path comes from the user's own configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to create capture file: %w", err)
+	}
+
+	c, err := NewCapture(f, format)
+	if err != nil {
+		_ = f.Close() //nolint:errcheck // best-effort cleanup
+
+		return nil, err
+	}
+
+	c.closer = f
+
+	return c, nil
+}
+
+// Record writes a record of data passing through port in the given direction.
+func (c *Capture) Record(port, direction string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	record := CaptureRecord{Time: c.now(), Port: port, Direction: direction, Data: data}
+
+	var err error
+	if c.format == CaptureBinary {
+		err = writeBinaryRecord(c.w, record)
+	} else {
+		err = writeJSONRecord(c.w, record)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write capture record: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file the Capture writes to, if it opened one.
+func (c *Capture) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+
+	return c.closer.Close()
+}
+
+func writeJSONRecord(w io.Writer, record CaptureRecord) error {
+	line, err := json.Marshal(jsonRecord{
+		Time:      record.Time,
+		Port:      record.Port,
+		Direction: record.Direction,
+		Data:      hex.EncodeToString(record.Data),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+
+	return err
+}
+
+// writeBinaryRecord writes a record as its time in Unix nanoseconds, direction byte, port name
+// and data, each length-prefixed big-endian.
+func writeBinaryRecord(w io.Writer, record CaptureRecord) error {
+	direction := byte(0)
+	if record.Direction == DirectionReceived {
+		direction = 1
+	}
+
+	buf := binary.BigEndian.AppendUint64(nil, uint64(record.Time.UnixNano())) //nolint:gosec // times are after 1970
+	buf = append(buf, direction)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(record.Port))) //nolint:gosec // port names are short
+	buf = append(buf, record.Port...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(record.Data))) //nolint:gosec // writes are far below 4GiB
+	buf = append(buf, record.Data...)
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+// IsCapture reports whether data looks like the start of a capture in either format.
+func IsCapture(data []byte) bool {
+	return bytes.HasPrefix(data, captureMagic) || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// ReadCapture reads every record from a capture in either format.
+func ReadCapture(r io.Reader) ([]CaptureRecord, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(len(captureMagic))
+	if err == nil && bytes.Equal(header, captureMagic) {
+		_, _ = br.Discard(len(captureMagic)) //nolint:errcheck // the bytes were just peeked
+
+		return readBinaryCapture(br)
+	}
+
+	return readJSONCapture(br)
+}
+
+func readJSONCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var jr jsonRecord
+		if err := json.Unmarshal(scanner.Bytes(), &jr); err != nil {
+			return nil, fmt.Errorf("failed to parse capture line %d: %w", line, err)
+		}
+
+		data, err := hex.DecodeString(jr.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse capture line %d: invalid data: %w", line, err)
+		}
+
+		records = append(records, CaptureRecord{Time: jr.Time, Port: jr.Port, Direction: jr.Direction, Data: data})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	return records, nil
+}
+
+func readBinaryCapture(r io.Reader) ([]CaptureRecord, error) {
+	var records []CaptureRecord
+
+	for {
+		var head [11]byte // Time, direction and port length
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+
+			return nil, fmt.Errorf("failed to read capture record %d: %w", len(records)+1, err)
+		}
+
+		port := make([]byte, binary.BigEndian.Uint16(head[9:]))
+
+		var size [4]byte
+		if _, err := io.ReadFull(r, port); err != nil {
+			return nil, fmt.Errorf("failed to read capture record %d: %w", len(records)+1, err)
+		}
+
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, fmt.Errorf("failed to read capture record %d: %w", len(records)+1, err)
+		}
+
+		data := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("failed to read capture record %d: %w", len(records)+1, err)
+		}
+
+		direction := DirectionSent
+		if head[8] == 1 {
+			direction = DirectionReceived
+		}
+
+		records = append(records, CaptureRecord{
+			Time:      time.Unix(0, int64(binary.BigEndian.Uint64(head[:8]))), //nolint:gosec // written from a time
+			Port:      string(port),
+			Direction: direction,
+			Data:      data,
+		})
+	}
+}
+
+// RecordingOpener returns an Opener that opens ports with open and records all traffic on them
+// to capture. A failure to record is ignored, so capturing never breaks the connection.
+func RecordingOpener(open Opener, capture *Capture) Opener {
+	return func(portName string) (Transport, error) {
+		port, err := open(portName)
+		if err != nil {
+			return nil, err
+		}
+
+		return &recordingTransport{Transport: port, capture: capture, name: portName}, nil
+	}
+}
+
+// recordingTransport records the bytes that pass through a transport.
+type recordingTransport struct {
+	Transport
+	capture *Capture
+	name    string
+}
+
+func (t *recordingTransport) Write(data []byte) (int, error) {
+	n, err := t.Transport.Write(data)
+	if n > 0 {
+		_ = t.capture.Record(t.name, DirectionSent, data[:n]) //nolint:errcheck // capturing is best-effort
+	}
+
+	return n, err
+}
+
+func (t *recordingTransport) Read(buf []byte) (int, error) {
+	n, err := t.Transport.Read(buf)
+	if n > 0 {
+		_ = t.capture.Record(t.name, DirectionReceived, buf[:n]) //nolint:errcheck // capturing is best-effort
+	}
+
+	return n, err
+}
+
+// Replay writes the sent records of a capture with write, keeping the original gaps between them
+// divided by speed. A speed of zero or less writes them back to back. Received records are
+// skipped, since the module being replayed to answers for itself.
+func Replay(ctx context.Context, records []CaptureRecord, speed float64, write func(CaptureRecord) error) error {
+	return replay(ctx, records, speed, write, sleepContext)
+}
+
+func replay(ctx context.Context, records []CaptureRecord, speed float64, write func(CaptureRecord) error,
+	sleep func(context.Context, time.Duration) error,
+) error {
+	var last time.Time
+
+	for i, record := range records {
+		if record.Direction != DirectionSent {
+			continue
+		}
+
+		if speed > 0 && !last.IsZero() {
+			if gap := record.Time.Sub(last); gap > 0 {
+				if err := sleep(ctx, time.Duration(float64(gap)/speed)); err != nil {
+					return err
+				}
+			}
+		}
+
+		last = record.Time
+
+		if err := write(record); err != nil {
+			return fmt.Errorf("failed to replay record %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
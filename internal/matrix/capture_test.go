@@ -0,0 +1,211 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureRoundTrip(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := []CaptureRecord{
+		{Time: start, Port: "/dev/ttyACM0", Direction: DirectionSent, Data: VersionCommand().ToBytes()},
+		{Time: start.Add(3 * time.Millisecond), Port: "/dev/ttyACM0", Direction: DirectionReceived, Data: []byte{0, 0x19, 0}},
+		{Time: start.Add(time.Second), Port: "/dev/ttyACM1", Direction: DirectionSent, Data: BrightnessCommand(80).ToBytes()},
+	}
+
+	for _, format := range []string{CaptureJSONL, CaptureBinary} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			capture, err := NewCapture(&buf, format)
+			if err != nil {
+				t.Fatalf("NewCapture() error = %v", err)
+			}
+
+			for _, record := range want {
+				capture.now = func() time.Time { return record.Time }
+
+				if err := capture.Record(record.Port, record.Direction, record.Data); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			got, err := ReadCapture(&buf)
+			if err != nil {
+				t.Fatalf("ReadCapture() error = %v", err)
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("ReadCapture() = %d records, want %d", len(got), len(want))
+			}
+
+			for i := range want {
+				if !got[i].Time.Equal(want[i].Time) || got[i].Port != want[i].Port ||
+					got[i].Direction != want[i].Direction || !bytes.Equal(got[i].Data, want[i].Data) {
+					t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCaptureJSONLIsReadable(t *testing.T) {
+	var buf bytes.Buffer
+
+	capture, _ := NewCapture(&buf, CaptureJSONL)
+	capture.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	_ = capture.Record("/dev/ttyACM0", DirectionSent, BrightnessCommand(80).ToBytes())
+
+	want := `{"time":"2026-03-01T12:00:00Z","port":"/dev/ttyACM0","direction":"sent","data":"32ac0050"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("capture = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestReadCaptureErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad json":      "{not json}\n",
+		"bad hex":       `{"time":"2026-03-01T12:00:00Z","port":"p","direction":"sent","data":"zz"}` + "\n",
+		"short binary":  string(captureMagic) + "\x00\x00",
+		"short payload": string(captureMagic) + "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01p\x00\x00\x00\x05ab",
+	}
+
+	for name, capture := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadCapture(strings.NewReader(capture)); err == nil {
+				t.Error("ReadCapture() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestNewCaptureRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewCapture(&bytes.Buffer{}, "pcap"); err == nil {
+		t.Error("NewCapture() succeeded for an unknown format, want an error")
+	}
+}
+
+func TestClientCapture(t *testing.T) {
+	var buf bytes.Buffer
+
+	capture, _ := NewCapture(&buf, CaptureJSONL)
+
+	vm := NewVirtualMatrix()
+	client := NewClient()
+	client.SetOpener(VirtualOpener(map[string]*VirtualMatrix{"virtual0": vm}))
+	client.SetCapture(capture)
+
+	if err := client.Connect("virtual0"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	_ = client.SetBrightness(80)
+
+	if _, err := client.GetVersion(); err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+
+	records, err := ReadCapture(&buf)
+	if err != nil {
+		t.Fatalf("ReadCapture() error = %v", err)
+	}
+
+	var sent, received []byte
+
+	for _, record := range records {
+		if record.Port != "virtual0" {
+			t.Errorf("record port = %q, want virtual0", record.Port)
+		}
+
+		if record.Direction == DirectionSent {
+			sent = append(sent, record.Data...)
+		} else {
+			received = append(received, record.Data...)
+		}
+	}
+
+	if want := append(BrightnessCommand(80).ToBytes(), VersionCommand().ToBytes()...); !bytes.Equal(sent, want) {
+		t.Errorf("sent = % X, want % X", sent, want)
+	}
+
+	if !bytes.Equal(received, defaultVirtualVersion[:]) {
+		t.Errorf("received = % X, want % X", received, defaultVirtualVersion[:])
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	start := time.Unix(100, 0)
+	records := []CaptureRecord{
+		{Time: start, Direction: DirectionSent, Data: []byte{1}},
+		{Time: start.Add(50 * time.Millisecond), Direction: DirectionReceived, Data: []byte{9}},
+		{Time: start.Add(time.Second), Direction: DirectionSent, Data: []byte{2}},
+		{Time: start.Add(3 * time.Second), Direction: DirectionSent, Data: []byte{3}},
+	}
+
+	tests := []struct {
+		name   string
+		speed  float64
+		sleeps []time.Duration
+	}{
+		{"original speed", 1, []time.Duration{time.Second, 2 * time.Second}},
+		{"accelerated", 4, []time.Duration{250 * time.Millisecond, 500 * time.Millisecond}},
+		{"as fast as possible", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				sleeps  []time.Duration
+				written []byte
+			)
+
+			sleep := func(_ context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+
+				return nil
+			}
+			write := func(record CaptureRecord) error {
+				written = append(written, record.Data...)
+
+				return nil
+			}
+
+			if err := replay(context.Background(), records, tt.speed, write, sleep); err != nil {
+				t.Fatalf("replay() error = %v", err)
+			}
+
+			if !bytes.Equal(written, []byte{1, 2, 3}) {
+				t.Errorf("written = %v, want only the sent records", written)
+			}
+
+			if !reflect.DeepEqual(sleeps, tt.sleeps) {
+				t.Errorf("sleeps = %v, want %v", sleeps, tt.sleeps)
+			}
+		})
+	}
+}
+
+func TestReplayStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	records := []CaptureRecord{
+		{Time: time.Unix(0, 0), Direction: DirectionSent, Data: []byte{1}},
+		{Time: time.Unix(60, 0), Direction: DirectionSent, Data: []byte{2}},
+	}
+
+	writes := 0
+
+	err := Replay(ctx, records, 1, func(CaptureRecord) error {
+		writes++
+
+		return nil
+	})
+	if err == nil || writes != 1 {
+		t.Errorf("Replay() = %v after %d writes, want a cancellation error after 1", err, writes)
+	}
+}
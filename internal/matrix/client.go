@@ -3,20 +3,31 @@
 package matrix
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"go.bug.st/serial"
-	"go.bug.st/serial/enumerator"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
 )
 
 // Client manages serial communication with a single LED matrix module.
+//
+// Commands are written by a writer goroutine started by Connect, so SendCommand does not wait
+// for the module. A failed write is returned by the next SendCommand, Flush or query.
 type Client struct {
-	port   serial.Port
-	config *serial.Mode
+	port          Transport
+	config        *serial.Mode
+	open          Opener
+	writer        *writer
+	capture       *Capture
+	onQueue       func(QueueEvent)
+	portName      string
+	writeDeadline time.Duration
+	fallback      bool
 }
 
 // Communication constants for LED matrix modules.
@@ -25,13 +36,54 @@ const (
 	DefaultTimeout  = 1 * time.Second
 )
 
+// USB IDs of Framework LED matrix modules.
+const (
+	FrameworkVID          = "32AC"
+	FrameworkLEDMatrixPID = "0020"
+)
+
 // NewClient creates a new LED matrix client with default configuration.
 func NewClient() *Client {
-	return &Client{
+	c := &Client{
 		config: &serial.Mode{
 			BaudRate: DefaultBaudRate,
 		},
+		writeDeadline: DefaultWriteDeadline,
 	}
+	c.open = SerialOpener(c.config)
+
+	return c
+}
+
+// SetOpener replaces how Connect opens a port, for example to talk to a VirtualMatrix
+// instead of a serial device.
+func (c *Client) SetOpener(open Opener) {
+	c.open = open
+}
+
+// SetWriteDeadline sets how long a single write may take before the port is considered wedged
+// and closed. It applies from the next Connect.
+func (c *Client) SetWriteDeadline(deadline time.Duration) {
+	c.writeDeadline = deadline
+}
+
+// SetQueueHandler sets the function called when the write queue grows, shrinks or drops
+// commands. It applies from the next Connect, and must not send commands.
+func (c *Client) SetQueueHandler(handler func(QueueEvent)) {
+	c.onQueue = handler
+}
+
+// SetCapture records all traffic on ports opened by Connect to capture, or stops recording if
+// capture is nil. It applies from the next Connect.
+func (c *Client) SetCapture(capture *Capture) {
+	c.capture = capture
+}
+
+// SetDiscoveryFallback sets whether port discovery may pick any USB serial port when no module
+// with the Framework LED matrix VID and PID is found. It is off by default, since the fallback
+// sends matrix commands to whatever other device is plugged in.
+func (c *Client) SetDiscoveryFallback(fallback bool) {
+	c.fallback = fallback
 }
 
 // DiscoverPort automatically discovers the first available Framework LED matrix port.
@@ -50,35 +102,14 @@ func (c *Client) DiscoverPort() (string, error) {
 
 // DiscoverPorts returns all available Framework LED matrix ports.
 func (c *Client) DiscoverPorts() ([]string, error) {
-	ports, err := enumerator.GetDetailedPortsList()
+	devices, err := DiscoverDevices(c.fallback)
 	if err != nil {
-		return nil, fmt.Errorf("failed to enumerate ports: %w", err)
-	}
-
-	var frameworkPorts []string
-
-	for _, port := range ports {
-		if port.IsUSB {
-			logging.Debug("found USB port", "name", port.Name, "vid", port.VID, "pid", port.PID)
-
-			// Framework LED Matrix has VID 32AC
-			if port.VID == "32AC" {
-				frameworkPorts = append(frameworkPorts, port.Name)
-			}
-		}
-	}
-
-	if len(frameworkPorts) == 0 {
-		// Fallback: return first USB port if no Framework-specific ports found
-		for _, port := range ports {
-			if port.IsUSB {
-				frameworkPorts = append(frameworkPorts, port.Name)
-			}
-		}
+		return nil, err
 	}
 
-	if len(frameworkPorts) == 0 {
-		return nil, fmt.Errorf("no USB ports found")
+	frameworkPorts := make([]string, len(devices))
+	for i, device := range devices {
+		frameworkPorts[i] = device.Name
 	}
 
 	logging.Info("discovered potential LED matrix ports", "count", len(frameworkPorts), "ports", frameworkPorts)
@@ -98,36 +129,78 @@ func (c *Client) Connect(portName string) error {
 		portName = discoveredPort
 	}
 
-	port, err := serial.Open(portName, c.config)
+	open := c.open
+	if c.capture != nil {
+		open = RecordingOpener(open, c.capture)
+	}
+
+	port, err := open(portName)
 	if err != nil {
 		return fmt.Errorf("failed to open port %s: %w", portName, err)
 	}
 
 	c.port = port
+	c.portName = portName
+	c.writer = newWriter(port, DefaultWriteQueueSize, c.writeDeadline, c.onQueue)
 
 	logging.Info("connected to LED matrix", "port", portName)
 
 	return nil
 }
 
-// Disconnect closes the connection to the LED matrix.
+// PortName returns the name of the port the client last connected to.
+func (c *Client) PortName() string {
+	return c.portName
+}
+
+// Disconnect closes the connection to the LED matrix once queued commands are written, waiting
+// at most the write deadline for them.
 func (c *Client) Disconnect() error {
 	if c.port == nil {
 		return nil
 	}
 
+	if c.writer != nil {
+		c.writer.close(c.writeDeadline)
+		c.writer = nil
+	}
+
 	err := c.port.Close()
 	c.port = nil
 
 	return err
 }
 
-// SendCommand transmits a command to the LED matrix.
+// SendCommand queues a command for the LED matrix. Settings and display commands replace any
+// queued command they supersede. It returns ErrQueueFull if too many commands are waiting, and
+// the error of an earlier failed write once the port has failed.
 func (c *Client) SendCommand(cmd Command) error {
 	if c.port == nil {
 		return fmt.Errorf("not connected to any port")
 	}
 
+	if c.writer != nil {
+		return c.writer.send(cmd)
+	}
+
+	return c.writeDirect(cmd)
+}
+
+// Flush waits until every queued command has been written and returns the error of any failed write.
+func (c *Client) Flush() error {
+	if c.port == nil {
+		return fmt.Errorf("not connected to any port")
+	}
+
+	if c.writer == nil {
+		return nil
+	}
+
+	return c.writer.flush()
+}
+
+// writeDirect writes a command to a port that has no writer, as when a test sets the port itself.
+func (c *Client) writeDirect(cmd Command) error {
 	data := cmd.ToBytes()
 
 	_, err := c.port.Write(data)
@@ -140,33 +213,158 @@ func (c *Client) SendCommand(cmd Command) error {
 	return nil
 }
 
-// ReadResponse reads a response from the LED matrix with the specified number of expected bytes.
+// ReadResponse reads a response of exactly expectedBytes from the LED matrix. Reads continue
+// until the response is complete; a response cut short by the read timeout is an error.
 func (c *Client) ReadResponse(expectedBytes int) ([]byte, error) {
 	if c.port == nil {
 		return nil, fmt.Errorf("not connected to any port")
 	}
 
+	// The reply can only follow the commands already queued
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+
 	buffer := make([]byte, expectedBytes)
 
 	if err := c.port.SetReadTimeout(DefaultTimeout); err != nil {
 		return nil, fmt.Errorf("failed to set read timeout: %w", err)
 	}
 
-	n, err := c.port.Read(buffer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	read := 0
+	for read < expectedBytes {
+		n, err := c.port.Read(buffer[read:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if n == 0 {
+			// The read timed out
+			break
+		}
+
+		read += n
+	}
+
+	if read != expectedBytes {
+		return nil, fmt.Errorf("short response: got %d of %d bytes", read, expectedBytes)
+	}
+
+	return buffer, nil
+}
+
+// query sends a query command and reads its reply of the given size.
+func (c *Client) query(id byte, size int) ([]byte, error) {
+	if err := c.SendCommand(QueryCommand(id)); err != nil {
+		return nil, err
 	}
 
-	return buffer[:n], nil
+	return c.ReadResponse(size)
 }
 
 // GetVersion retrieves the firmware version from the LED matrix.
 func (c *Client) GetVersion() ([]byte, error) {
-	if err := c.SendCommand(VersionCommand()); err != nil {
-		return nil, err
+	return c.query(CmdVersion, VersionResponseSize)
+}
+
+// GetFirmwareVersion retrieves and decodes the firmware version from the LED matrix.
+func (c *Client) GetFirmwareVersion() (FirmwareVersion, error) {
+	response, err := c.GetVersion()
+	if err != nil {
+		return FirmwareVersion{}, err
+	}
+
+	return ParseFirmwareVersion(response)
+}
+
+// GetBrightness returns the module's current brightness level (0-255).
+func (c *Client) GetBrightness() (byte, error) {
+	response, err := c.query(CmdBrightness, BrightnessResponseSize)
+	if err != nil {
+		return 0, err
+	}
+
+	return response[0], nil
+}
+
+// SetSleep puts the module to sleep, turning its LEDs off, or wakes it up.
+func (c *Client) SetSleep(sleep bool) error {
+	return c.SendCommand(SleepCommand(sleep))
+}
+
+// IsSleeping reports whether the module is asleep.
+func (c *Client) IsSleeping() (bool, error) {
+	response, err := c.query(CmdSleep, SleepResponseSize)
+	if err != nil {
+		return false, err
+	}
+
+	return response[0] != 0, nil
+}
+
+// IsAnimating reports whether animation is enabled.
+func (c *Client) IsAnimating() (bool, error) {
+	response, err := c.query(CmdAnimate, AnimateResponseSize)
+	if err != nil {
+		return false, err
+	}
+
+	return response[0] != 0, nil
+}
+
+// SetAnimationPeriod sets how long each animation frame is shown, in whole milliseconds.
+func (c *Client) SetAnimationPeriod(period time.Duration) error {
+	return c.SendCommand(AnimationPeriodCommand(period))
+}
+
+// GetAnimationPeriod returns how long each animation frame is shown.
+func (c *Client) GetAnimationPeriod() (time.Duration, error) {
+	response, err := c.query(CmdAnimationPeriod, AnimationPeriodResponseSize)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(binary.LittleEndian.Uint16(response)) * time.Millisecond, nil
+}
+
+// SetPWMFrequency sets the LED driver's PWM frequency.
+func (c *Client) SetPWMFrequency(freq PWMFrequency) error {
+	return c.SendCommand(PWMFrequencyCommand(freq))
+}
+
+// GetPWMFrequency returns the LED driver's PWM frequency.
+func (c *Client) GetPWMFrequency() (PWMFrequency, error) {
+	response, err := c.query(CmdPWMFrequency, PWMFrequencyResponseSize)
+	if err != nil {
+		return 0, err
 	}
 
-	return c.ReadResponse(3)
+	return PWMFrequency(response[0]), nil
+}
+
+// SetDebugMode enables or disables the firmware's debug mode.
+func (c *Client) SetDebugMode(enable bool) error {
+	return c.SendCommand(DebugModeCommand(enable))
+}
+
+// IsDebugMode reports whether the firmware's debug mode is enabled.
+func (c *Client) IsDebugMode() (bool, error) {
+	response, err := c.query(CmdDebugMode, DebugModeResponseSize)
+	if err != nil {
+		return false, err
+	}
+
+	return response[0] != 0, nil
+}
+
+// ResetToBootloader restarts the module into its bootloader for a firmware update. The module
+// disappears from the serial bus until new firmware is flashed or it is power cycled.
+func (c *Client) ResetToBootloader() error {
+	if err := c.SendCommand(BootloaderCommand()); err != nil {
+		return err
+	}
+
+	return c.Flush()
 }
 
 // SetBrightness sets the brightness level of the LED matrix (0-255).
@@ -215,72 +413,212 @@ func (c *Client) FlushColumns() error {
 }
 
 // SingleMatrixConfig represents configuration for a single matrix.
+//
+// SerialNumber and Location pick the module by its USB serial number or USB topology path when
+// Port is empty, so it is found whichever port the operating system gave it. Calibration is
+// applied to everything sent to the module.
 type SingleMatrixConfig struct {
-	Name       string   `yaml:"name"`
-	Port       string   `yaml:"port"`
-	Role       string   `yaml:"role"`
-	Metrics    []string `yaml:"metrics"`
-	Brightness byte     `yaml:"brightness"`
+	Name         string      `yaml:"name"`
+	Port         string      `yaml:"port"`
+	SerialNumber string      `yaml:"serial_number"`
+	Location     string      `yaml:"location"`
+	Role         string      `yaml:"role"`
+	Metrics      []string    `yaml:"metrics"`
+	Calibration  Calibration `yaml:"calibration"`
+	Brightness   byte        `yaml:"brightness"`
 }
 
-// MultiClient manages multiple LED matrix clients.
+// MultiClient manages multiple LED matrix clients, each wrapped in a Supervisor that
+// reconnects it if the module is lost.
 type MultiClient struct {
-	clients map[string]*Client
-	config  map[string]*SingleMatrixConfig
-	mu      sync.RWMutex
+	clients       map[string]*Client
+	supervisors   map[string]*Supervisor
+	config        map[string]*SingleMatrixConfig
+	firmware      map[string]FirmwareVersion
+	onEvent       func(ConnectionEvent)
+	onQueue       func(QueueEvent)
+	discover      func() ([]Device, error)
+	open          Opener
+	capture       *Capture
+	writeDeadline time.Duration
+	mu            sync.RWMutex
 }
 
 // NewMultiClient creates a new MultiClient for managing multiple LED matrix connections.
 func NewMultiClient() *MultiClient {
 	return &MultiClient{
-		clients: make(map[string]*Client),
-		config:  make(map[string]*SingleMatrixConfig),
+		clients:     make(map[string]*Client),
+		supervisors: make(map[string]*Supervisor),
+		config:      make(map[string]*SingleMatrixConfig),
+		firmware:    make(map[string]FirmwareVersion),
+		discover:    func() ([]Device, error) { return DiscoverDevices(false) },
+	}
+}
+
+// SetEventHandler sets the function called when any managed matrix disconnects, reconnects or fails to reconnect.
+func (mc *MultiClient) SetEventHandler(handler func(ConnectionEvent)) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.onEvent = handler
+}
+
+// handleEvent records the firmware version a matrix reports when it reconnects, then passes the
+// event on to the handler set with SetEventHandler.
+func (mc *MultiClient) handleEvent(event ConnectionEvent) {
+	mc.mu.Lock()
+	if event.Firmware != nil {
+		mc.firmware[event.Matrix] = *event.Firmware
+	}
+
+	handler := mc.onEvent
+	mc.mu.Unlock()
+
+	if handler != nil {
+		handler(event)
 	}
 }
 
+// SetDiscoveryFallback sets whether DiscoverAndConnect may bind matrices to any USB serial port
+// when no module with the Framework LED matrix VID and PID is found.
+func (mc *MultiClient) SetDiscoveryFallback(fallback bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.discover = func() ([]Device, error) { return DiscoverDevices(fallback) }
+}
+
+// SetQueueHandler sets the function called when the write queue of any matrix connected by
+// DiscoverAndConnect changes, with QueueEvent.Matrix set to the matrix name.
+func (mc *MultiClient) SetQueueHandler(handler func(QueueEvent)) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.onQueue = handler
+}
+
+// SetWriteDeadline sets the write deadline of matrices connected by DiscoverAndConnect.
+func (mc *MultiClient) SetWriteDeadline(deadline time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.writeDeadline = deadline
+}
+
+// SetCapture records all traffic to and from matrices connected by DiscoverAndConnect to capture.
+func (mc *MultiClient) SetCapture(capture *Capture) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.capture = capture
+}
+
+// SetOpener replaces how matrices are opened when DiscoverAndConnect connects them.
+func (mc *MultiClient) SetOpener(open Opener) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.open = open
+}
+
 // DiscoverAndConnect discovers available LED matrices and connects to them based on the provided configuration.
+// See bindMatrices for how discovered devices are assigned to matrices that don't name a port.
 func (mc *MultiClient) DiscoverAndConnect(matrices []SingleMatrixConfig, baudRate int) error {
-	client := NewClient()
+	mc.mu.RLock()
+	open := mc.open
+	capture := mc.capture
+	discover := mc.discover
+	onQueue := mc.onQueue
+	writeDeadline := mc.writeDeadline
+	mc.mu.RUnlock()
 
-	discoveredPorts, err := client.DiscoverPorts()
-	if err != nil {
-		return fmt.Errorf("failed to discover ports: %w", err)
-	}
+	var devices []Device
+
+	// Devices only need to be discovered for matrices that don't name a port
+	for _, matrixConfig := range matrices {
+		if matrixConfig.Port != "" {
+			continue
+		}
+
+		discovered, err := discover()
+		if err != nil {
+			return fmt.Errorf("failed to discover ports: %w", err)
+		}
 
-	logging.Info("found potential matrix ports", "found", len(discoveredPorts), "configuring", len(matrices))
+		devices = discovered
 
-	for i, matrixConfig := range matrices {
-		var portToUse string
+		logging.Info("found potential matrix ports", "found", len(devices), "configuring", len(matrices))
+
+		break
+	}
 
-		switch {
-		case matrixConfig.Port != "":
-			portToUse = matrixConfig.Port
-		case i < len(discoveredPorts):
-			portToUse = discoveredPorts[i]
-		default:
-			logging.Warn("no port available for matrix, skipping", "matrix", matrixConfig.Name)
+	ports, errs := bindMatrices(matrices, devices)
+	for _, err := range errs {
+		logging.Warn("failed to bind matrix to a device", "error", err)
+	}
 
+	for i, matrixConfig := range matrices {
+		portToUse := ports[i]
+		if portToUse == "" {
 			continue
 		}
 
 		client := NewClient()
+		if open != nil {
+			client.SetOpener(open)
+		}
+
+		if writeDeadline > 0 {
+			client.SetWriteDeadline(writeDeadline)
+		}
+
+		client.SetCapture(capture)
+
+		if onQueue != nil {
+			name := matrixConfig.Name
+			client.SetQueueHandler(func(event QueueEvent) {
+				event.Matrix = name
+				onQueue(event)
+			})
+		}
+
 		if err := client.Connect(portToUse); err != nil {
 			logging.Warn("failed to connect to matrix", "matrix", matrixConfig.Name, "port", portToUse, "error", err)
+			errs = append(errs, fmt.Errorf("matrix %q on %s: %w", matrixConfig.Name, portToUse, err))
 
 			continue
 		}
 
-		if err := client.SetBrightness(matrixConfig.Brightness); err != nil {
+		// Read the version before anything else is sent, while no reply can be pending
+		version, versionErr := client.GetFirmwareVersion()
+		if versionErr != nil {
+			logging.Warn("failed to read matrix firmware version", "matrix", matrixConfig.Name, "error", versionErr)
+		}
+
+		supervisor := NewSupervisor(matrixConfig.Name, client)
+		if err := supervisor.SetCalibration(matrixConfig.Calibration); err != nil {
+			logging.Warn("failed to calibrate matrix", "matrix", matrixConfig.Name, "error", err)
+		}
+
+		if err := supervisor.SetBrightness(matrixConfig.Brightness); err != nil {
 			logging.Warn("failed to set brightness for matrix", "matrix", matrixConfig.Name, "error", err)
 		}
 
 		mc.mu.Lock()
+		supervisor.SetEventHandler(mc.handleEvent)
 		mc.clients[matrixConfig.Name] = client
+		mc.supervisors[matrixConfig.Name] = supervisor
+
+		if versionErr == nil {
+			mc.firmware[matrixConfig.Name] = version
+		}
+
 		configCopy := matrixConfig
 		mc.config[matrixConfig.Name] = &configCopy
 		mc.mu.Unlock()
 
-		logging.Info("successfully connected matrix", "matrix", matrixConfig.Name, "port", portToUse)
+		logging.Info("successfully connected matrix", "matrix", matrixConfig.Name, "port", portToUse,
+			"firmware", version.String())
 	}
 
 	mc.mu.RLock()
@@ -288,6 +626,10 @@ func (mc *MultiClient) DiscoverAndConnect(matrices []SingleMatrixConfig, baudRat
 	mc.mu.RUnlock()
 
 	if clientCount == 0 {
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to connect to any LED matrices: %w", errors.Join(errs...))
+		}
+
 		return fmt.Errorf("failed to connect to any LED matrices")
 	}
 
@@ -315,6 +657,25 @@ func (mc *MultiClient) GetClients() map[string]*Client {
 	return snapshot
 }
 
+// GetSupervisor returns the Supervisor for the specified matrix name, or nil if it has none.
+func (mc *MultiClient) GetSupervisor(name string) *Supervisor {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	return mc.supervisors[name]
+}
+
+// GetFirmwareVersion returns the firmware version read from the named matrix when it last connected.
+// It reports false if the matrix is not connected or did not answer the version request.
+func (mc *MultiClient) GetFirmwareVersion(name string) (FirmwareVersion, bool) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	version, ok := mc.firmware[name]
+
+	return version, ok
+}
+
 // GetConfig returns the configuration for the specified matrix name.
 func (mc *MultiClient) GetConfig(name string) *SingleMatrixConfig {
 	mc.mu.RLock()
@@ -331,6 +692,14 @@ func (mc *MultiClient) Disconnect() error {
 	var errors []error
 
 	for name, client := range mc.clients {
+		if supervisor := mc.supervisors[name]; supervisor != nil {
+			if err := supervisor.Disconnect(); err != nil {
+				errors = append(errors, fmt.Errorf("failed to disconnect %s: %w", name, err))
+			}
+
+			continue
+		}
+
 		if err := client.Disconnect(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to disconnect %s: %w", name, err))
 		}
@@ -232,7 +232,7 @@ func TestClientReadResponse(t *testing.T) {
 			readData:      []byte{1, 2},
 			expectedBytes: 3,
 			readError:     nil,
-			expectError:   false,
+			expectError:   true,
 		},
 	}
 
@@ -1,30 +1,132 @@
 package matrix
 
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
 // Protocol magic bytes for Framework LED matrix communication.
 const (
 	MagicByte1 = 0x32
 	MagicByte2 = 0xAC
 )
 
-// Command IDs for LED matrix operations.
+// Command IDs for LED matrix operations. Brightness, sleep, animate, animation period, PWM
+// frequency and debug mode double as queries when sent without parameters.
 const (
-	CmdBrightness = 0x00
-	CmdPattern    = 0x01
-	CmdAnimate    = 0x04
-	CmdDrawBW     = 0x06
-	CmdStageCol   = 0x07
-	CmdFlushCols  = 0x08
-	CmdVersion    = 0x20
+	CmdBrightness      = 0x00
+	CmdPattern         = 0x01
+	CmdBootloader      = 0x02
+	CmdSleep           = 0x03
+	CmdAnimate         = 0x04
+	CmdPanic           = 0x05
+	CmdDrawBW          = 0x06
+	CmdStageCol        = 0x07
+	CmdFlushCols       = 0x08
+	CmdAnimationPeriod = 0x1C
+	CmdPWMFrequency    = 0x1E
+	CmdDebugMode       = 0x1F
+	CmdVersion         = 0x20
 )
 
 // Pattern types for LED matrix display modes.
 const (
-	PatternPercentage = 0x00
-	PatternGradient   = 0x01
-	PatternZigZag     = 0x04
-	PatternFullBright = 0x05
+	PatternPercentage     = 0x00
+	PatternGradient       = 0x01
+	PatternDoubleGradient = 0x02
+	PatternLotus          = 0x03
+	PatternZigZag         = 0x04
+	PatternFullBright     = 0x05
+	PatternPanic          = 0x06
+	PatternLotus2         = 0x07
 )
 
+// commandNames names every command ID, for decoding and logs.
+var commandNames = map[byte]string{
+	CmdBrightness:      "Brightness",
+	CmdPattern:         "Pattern",
+	CmdBootloader:      "Bootloader",
+	CmdSleep:           "Sleep",
+	CmdAnimate:         "Animate",
+	CmdPanic:           "Panic",
+	CmdDrawBW:          "DrawBW",
+	CmdStageCol:        "StageCol",
+	CmdFlushCols:       "FlushCols",
+	CmdAnimationPeriod: "AnimationPeriod",
+	CmdPWMFrequency:    "PWMFrequency",
+	CmdDebugMode:       "DebugMode",
+	CmdVersion:         "Version",
+}
+
+// patternNames names every pattern type.
+var patternNames = map[byte]string{
+	PatternPercentage:     "percentage",
+	PatternGradient:       "gradient",
+	PatternDoubleGradient: "double gradient",
+	PatternLotus:          "lotus",
+	PatternZigZag:         "zigzag",
+	PatternFullBright:     "full bright",
+	PatternPanic:          "panic",
+	PatternLotus2:         "lotus2",
+}
+
+// CommandName returns the name of a command ID, such as "StageCol", or the ID in hex if it is unknown.
+func CommandName(id byte) string {
+	if name, ok := commandNames[id]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02X", id)
+}
+
+// PatternName returns the name of a pattern type, such as "zigzag", or the type in hex if it is unknown.
+func PatternName(pattern byte) string {
+	if name, ok := patternNames[pattern]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02X", pattern)
+}
+
+// Sizes of the firmware's replies to queries.
+const (
+	BrightnessResponseSize      = 1
+	SleepResponseSize           = 1
+	AnimateResponseSize         = 1
+	AnimationPeriodResponseSize = 2
+	PWMFrequencyResponseSize    = 1
+	DebugModeResponseSize       = 1
+	VersionResponseSize         = 3
+)
+
+// PWMFrequency selects the LED driver's PWM frequency.
+type PWMFrequency byte
+
+// PWM frequencies supported by the firmware.
+const (
+	PWM29kHz  PWMFrequency = 0x00
+	PWM3600Hz PWMFrequency = 0x01
+	PWM1800Hz PWMFrequency = 0x02
+	PWM900Hz  PWMFrequency = 0x03
+)
+
+// String returns the frequency in human readable form, such as "29kHz".
+func (f PWMFrequency) String() string {
+	switch f {
+	case PWM29kHz:
+		return "29kHz"
+	case PWM3600Hz:
+		return "3.6kHz"
+	case PWM1800Hz:
+		return "1.8kHz"
+	case PWM900Hz:
+		return "900Hz"
+	default:
+		return fmt.Sprintf("PWMFrequency(%d)", byte(f))
+	}
+}
+
 // Command represents a LED matrix command with ID and parameters.
 type Command struct {
 	Params []byte
@@ -82,6 +184,50 @@ func FullBrightCommand() Command {
 	return PatternCommand(PatternFullBright)
 }
 
+// SleepCommand creates a command to put the module to sleep or wake it up.
+func SleepCommand(sleep bool) Command {
+	var param byte
+	if sleep {
+		param = 1
+	}
+
+	return NewCommand(CmdSleep, param)
+}
+
+// BootloaderCommand creates a command that resets the module into its bootloader for firmware updates.
+func BootloaderCommand() Command {
+	return NewCommand(CmdBootloader)
+}
+
+// AnimationPeriodCommand creates a command to set how long each animation frame is shown.
+// The period is sent in whole milliseconds, little-endian, and clamped to what fits.
+func AnimationPeriodCommand(period time.Duration) Command {
+	ms := min(max(period.Milliseconds(), 0), 0xFFFF)
+
+	return NewCommand(CmdAnimationPeriod, binary.LittleEndian.AppendUint16(nil, uint16(ms))...)
+}
+
+// PWMFrequencyCommand creates a command to set the LED driver's PWM frequency.
+func PWMFrequencyCommand(freq PWMFrequency) Command {
+	return NewCommand(CmdPWMFrequency, byte(freq))
+}
+
+// DebugModeCommand creates a command to enable or disable the firmware's debug mode.
+func DebugModeCommand(enable bool) Command {
+	var param byte
+	if enable {
+		param = 1
+	}
+
+	return NewCommand(CmdDebugMode, param)
+}
+
+// QueryCommand creates a command that asks for the current value of a setting such as
+// CmdBrightness or CmdSleep, by sending its ID without parameters.
+func QueryCommand(id byte) Command {
+	return NewCommand(id)
+}
+
 // AnimateCommand creates a command to enable or disable animation effects.
 func AnimateCommand(enable bool) Command {
 	var param byte
@@ -107,6 +253,7 @@ func StageColCommand(col byte, pixels [34]byte) Command {
 }
 
 // FlushColsCommand creates a command to flush all staged columns to the display.
+// Together with StageColCommand it draws a greyscale frame.
 func FlushColsCommand() Command {
 	return NewCommand(CmdFlushCols)
 }
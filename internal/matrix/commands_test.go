@@ -1,8 +1,10 @@
 package matrix
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewCommand(t *testing.T) {
@@ -201,6 +203,43 @@ func TestAnimateCommand(t *testing.T) {
 	}
 }
 
+func TestSleepCommand(t *testing.T) {
+	if got, want := SleepCommand(true), (Command{ID: CmdSleep, Params: []byte{1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SleepCommand(true) = %v, want %v", got, want)
+	}
+
+	if got, want := SleepCommand(false), (Command{ID: CmdSleep, Params: []byte{0}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SleepCommand(false) = %v, want %v", got, want)
+	}
+}
+
+func TestSettingCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Command
+		want Command
+	}{
+		{"animation period", AnimationPeriodCommand(1000 * time.Millisecond), Command{ID: CmdAnimationPeriod, Params: []byte{0xE8, 0x03}}},
+		{"animation period clamped", AnimationPeriodCommand(2 * time.Minute), Command{ID: CmdAnimationPeriod, Params: []byte{0xFF, 0xFF}}},
+		{"pwm frequency", PWMFrequencyCommand(PWM900Hz), Command{ID: CmdPWMFrequency, Params: []byte{3}}},
+		{"debug mode", DebugModeCommand(true), Command{ID: CmdDebugMode, Params: []byte{1}}},
+		{"bootloader", BootloaderCommand(), Command{ID: CmdBootloader}},
+		{"query", QueryCommand(CmdSleep), Command{ID: CmdSleep}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.ID != tt.want.ID || !bytes.Equal(tt.got.Params, tt.want.Params) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if PWM3600Hz.String() != "3.6kHz" || PWMFrequency(9).String() != "PWMFrequency(9)" {
+		t.Errorf("PWMFrequency.String() = %q, %q", PWM3600Hz.String(), PWMFrequency(9).String())
+	}
+}
+
 func TestDrawBWCommand(t *testing.T) {
 	pixels := [39]byte{}
 	for i := range pixels {
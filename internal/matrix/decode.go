@@ -0,0 +1,221 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrShortCommand is returned by DecodeCommand when data ends before the command's parameters do.
+	ErrShortCommand = errors.New("command truncated")
+	// ErrInvalidMagic is returned by DecodeCommand when data does not start with the magic bytes.
+	ErrInvalidMagic = errors.New("invalid magic bytes")
+	// ErrUnknownCommand is returned by DecodeCommand for a command ID the firmware does not have.
+	ErrUnknownCommand = errors.New("unknown command ID")
+)
+
+// paramLength returns how many parameter bytes follow the command ID at the start of params,
+// which holds everything after the ID. Commands that double as queries take their parameter only
+// if one is present. It returns -1 for unknown command IDs.
+func paramLength(id byte, params []byte) int {
+	switch id {
+	case CmdBrightness, CmdSleep, CmdAnimate, CmdPWMFrequency, CmdDebugMode:
+		// A query has no parameter, so the next bytes may already be the next command
+		if len(params) == 0 || startsCommand(params) {
+			return 0
+		}
+
+		return 1
+	case CmdAnimationPeriod:
+		if len(params) == 0 || startsCommand(params) {
+			return 0
+		}
+
+		return 2
+	case CmdPattern:
+		if len(params) > 0 && params[0] == PatternPercentage {
+			return 2
+		}
+
+		return 1
+	case CmdDrawBW:
+		return DrawBWSize
+	case CmdStageCol:
+		return 1 + FramebufferWidth
+	case CmdBootloader, CmdPanic, CmdFlushCols, CmdVersion:
+		return 0
+	default:
+		return -1
+	}
+}
+
+func startsCommand(data []byte) bool {
+	return len(data) >= 2 && data[0] == MagicByte1 && data[1] == MagicByte2
+}
+
+// DecodeCommand decodes the command at the start of data, as produced by Command.ToBytes, and
+// returns it along with the number of bytes it used. It returns ErrShortCommand if data ends
+// before the command does, in which case more data should be read and decoding retried.
+func DecodeCommand(data []byte) (Command, int, error) {
+	if len(data) < 3 {
+		if len(data) == 0 || data[0] == MagicByte1 && (len(data) == 1 || data[1] == MagicByte2) {
+			return Command{}, 0, ErrShortCommand
+		}
+	}
+
+	if !startsCommand(data) {
+		return Command{}, 0, fmt.Errorf("%w 0x%02X 0x%02X", ErrInvalidMagic, data[0], data[min(1, len(data)-1)])
+	}
+
+	id := data[2]
+
+	n := paramLength(id, data[3:])
+	if n < 0 {
+		return Command{}, 0, fmt.Errorf("%w 0x%02X", ErrUnknownCommand, id)
+	}
+
+	if len(data) < 3+n {
+		return Command{}, 0, ErrShortCommand
+	}
+
+	params := make([]byte, n)
+	copy(params, data[3:3+n])
+
+	return Command{ID: id, Params: params}, 3 + n, nil
+}
+
+// DecodedFrame is a command, or a run of bytes that is not one, found by DecodeStream.
+type DecodedFrame struct {
+	Err     error // Why Raw is not a valid command; nil if it is
+	Raw     []byte
+	Command Command
+	Offset  int
+}
+
+// DecodeStream decodes every command in data. Malformed bytes are returned as frames with Err
+// set, and decoding resumes at the next magic bytes, so one bad frame does not hide the rest.
+func DecodeStream(data []byte) []DecodedFrame {
+	var frames []DecodedFrame
+
+	for offset := 0; offset < len(data); {
+		rest := data[offset:]
+
+		cmd, n, err := DecodeCommand(rest)
+		switch {
+		case err == nil:
+			frames = append(frames, DecodedFrame{Offset: offset, Raw: rest[:n], Command: cmd})
+			offset += n
+
+			continue
+		case errors.Is(err, ErrShortCommand):
+			return append(frames, DecodedFrame{Offset: offset, Raw: rest, Err: describeShort(rest)})
+		}
+
+		// Skip to the next command; an unknown ID's parameters can't be told from garbage
+		skip := 1
+		if errors.Is(err, ErrUnknownCommand) {
+			skip = 3
+		}
+
+		if next := bytes.Index(rest[skip:], []byte{MagicByte1, MagicByte2}); next >= 0 {
+			skip += next
+		} else {
+			skip = len(rest)
+		}
+
+		frames = append(frames, DecodedFrame{Offset: offset, Raw: rest[:skip], Err: err})
+		offset += skip
+	}
+
+	return frames
+}
+
+// describeShort explains why data, which DecodeCommand found truncated, is not a whole command.
+func describeShort(data []byte) error {
+	if len(data) < 3 {
+		return fmt.Errorf("%w: %d of 3 header bytes", ErrShortCommand, len(data))
+	}
+
+	return fmt.Errorf("%w: %s needs %d parameter bytes, has %d", ErrShortCommand, CommandName(data[2]),
+		paramLength(data[2], data[3:]), len(data)-3)
+}
+
+// Describe returns the command's name and decoded parameters, such as "Brightness 80" or
+// "Sleep query". Pixel data is summarised rather than listed.
+func (c Command) Describe() string {
+	name := CommandName(c.ID)
+	p := c.Params
+
+	switch c.ID {
+	case CmdBrightness, CmdSleep, CmdAnimate, CmdAnimationPeriod, CmdPWMFrequency, CmdDebugMode:
+		if len(p) == 0 {
+			return name + " query"
+		}
+	}
+
+	switch c.ID {
+	case CmdBrightness:
+		return fmt.Sprintf("%s %d", name, p[0])
+	case CmdSleep, CmdAnimate, CmdDebugMode:
+		return fmt.Sprintf("%s %s", name, onOff(p[0] != 0))
+	case CmdAnimationPeriod:
+		if len(p) < 2 {
+			break
+		}
+
+		return fmt.Sprintf("%s %s", name, time.Duration(binary.LittleEndian.Uint16(p))*time.Millisecond)
+	case CmdPWMFrequency:
+		return fmt.Sprintf("%s %s", name, PWMFrequency(p[0]))
+	case CmdPattern:
+		if len(p) == 0 {
+			break
+		}
+
+		if p[0] == PatternPercentage && len(p) > 1 {
+			return fmt.Sprintf("%s %s %d%%", name, PatternName(p[0]), p[1])
+		}
+
+		return fmt.Sprintf("%s %s", name, PatternName(p[0]))
+	case CmdDrawBW:
+		if len(p) < DrawBWSize {
+			break
+		}
+
+		lit := 0
+
+		for _, row := range UnpackBitmap([DrawBWSize]byte(p)) {
+			for _, on := range row {
+				if on {
+					lit++
+				}
+			}
+		}
+
+		return fmt.Sprintf("%s %d pixels lit", name, lit)
+	case CmdStageCol:
+		if len(p) == 0 {
+			break
+		}
+
+		return fmt.Sprintf("%s column %d", name, p[0])
+	case CmdVersion:
+		return name + " query"
+	}
+
+	if len(p) > 0 {
+		return fmt.Sprintf("%s % X", name, p)
+	}
+
+	return name
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+
+	return "off"
+}
@@ -0,0 +1,169 @@
+package matrix
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeCommandRoundTrip(t *testing.T) {
+	commands := []Command{
+		BrightnessCommand(0x32),
+		PercentageCommand(42),
+		GradientCommand(),
+		SleepCommand(true),
+		AnimateCommand(false),
+		DrawBWCommand([DrawBWSize]byte{0x32, 0xAC, 1}),
+		StageColCommand(3, [34]byte{0x32, 0xAC, 0x20}),
+		FlushColsCommand(),
+		VersionCommand(),
+	}
+
+	var stream []byte
+	for _, cmd := range commands {
+		stream = append(stream, cmd.ToBytes()...)
+	}
+
+	for i, want := range commands {
+		got, n, err := DecodeCommand(stream)
+		if err != nil {
+			t.Fatalf("command %d: DecodeCommand() error = %v", i, err)
+		}
+
+		if got.ID != want.ID || !reflect.DeepEqual(got.Params, append([]byte{}, want.Params...)) {
+			t.Errorf("command %d: DecodeCommand() = %v, want %v", i, got, want)
+		}
+
+		stream = stream[n:]
+	}
+
+	if len(stream) != 0 {
+		t.Errorf("%d bytes left over after decoding every command", len(stream))
+	}
+}
+
+func TestDecodeCommandQueries(t *testing.T) {
+	// Brightness query followed directly by a version request
+	stream := append(NewCommand(CmdBrightness).ToBytes(), VersionCommand().ToBytes()...)
+
+	cmd, n, err := DecodeCommand(stream)
+	if err != nil || cmd.ID != CmdBrightness || len(cmd.Params) != 0 || n != 3 {
+		t.Errorf("DecodeCommand() = %v, %d, %v, want a brightness query of 3 bytes", cmd, n, err)
+	}
+}
+
+func TestDecodeCommandErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		short bool
+	}{
+		{name: "empty", data: nil, short: true},
+		{name: "partial magic", data: []byte{MagicByte1}, short: true},
+		{name: "missing ID", data: []byte{MagicByte1, MagicByte2}, short: true},
+		{name: "truncated column", data: StageColCommand(0, [34]byte{}).ToBytes()[:10], short: true},
+		{name: "bad magic", data: []byte{0x00, MagicByte2, CmdVersion}},
+		{name: "unknown ID", data: []byte{MagicByte1, MagicByte2, 0x7F}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, n, err := DecodeCommand(tt.data)
+			if err == nil || n != 0 {
+				t.Fatalf("DecodeCommand() = %d, %v, want an error", n, err)
+			}
+
+			if errors.Is(err, ErrShortCommand) != tt.short {
+				t.Errorf("DecodeCommand() error = %v, want short = %t", err, tt.short)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamResyncs(t *testing.T) {
+	var stream []byte
+	stream = append(stream, BrightnessCommand(80).ToBytes()...)
+	stream = append(stream, 0xDE, 0xAD)                         // Garbage
+	stream = append(stream, MagicByte1, MagicByte2, 0x7F, 0x01) // Unknown ID with a parameter
+	stream = append(stream, VersionCommand().ToBytes()...)
+	stream = append(stream, StageColCommand(2, [34]byte{}).ToBytes()[:10]...) // Cut short
+
+	frames := DecodeStream(stream)
+
+	want := []struct {
+		err    error
+		id     byte
+		offset int
+		size   int
+	}{
+		{id: CmdBrightness, offset: 0, size: 4},
+		{err: ErrInvalidMagic, offset: 4, size: 2},
+		{err: ErrUnknownCommand, offset: 6, size: 4},
+		{id: CmdVersion, offset: 10, size: 3},
+		{err: ErrShortCommand, offset: 13, size: 10},
+	}
+
+	if len(frames) != len(want) {
+		t.Fatalf("DecodeStream() = %d frames, want %d: %+v", len(frames), len(want), frames)
+	}
+
+	for i, w := range want {
+		got := frames[i]
+		if got.Offset != w.offset || len(got.Raw) != w.size || !errors.Is(got.Err, w.err) ||
+			w.err == nil && got.Command.ID != w.id {
+			t.Errorf("frame %d = offset %d, %d bytes, %v, want offset %d, %d bytes, %v",
+				i, got.Offset, len(got.Raw), got.Err, w.offset, w.size, w.err)
+		}
+	}
+
+	if msg := frames[4].Err.Error(); msg != "command truncated: StageCol needs 35 parameter bytes, has 7" {
+		t.Errorf("truncated frame error = %q", msg)
+	}
+}
+
+func TestCommandDescribe(t *testing.T) {
+	tests := []struct {
+		cmd  Command
+		want string
+	}{
+		{BrightnessCommand(80), "Brightness 80"},
+		{QueryCommand(CmdBrightness), "Brightness query"},
+		{PercentageCommand(42), "Pattern percentage 42%"},
+		{ZigZagCommand(), "Pattern zigzag"},
+		{PatternCommand(0x42), "Pattern 0x42"},
+		{SleepCommand(true), "Sleep on"},
+		{AnimateCommand(false), "Animate off"},
+		{AnimationPeriodCommand(250 * time.Millisecond), "AnimationPeriod 250ms"},
+		{PWMFrequencyCommand(PWM1800Hz), "PWMFrequency 1.8kHz"},
+		{DebugModeCommand(true), "DebugMode on"},
+		{DrawBWCommand([DrawBWSize]byte{0x03, 0x80}), "DrawBW 3 pixels lit"},
+		{StageColCommand(4, [34]byte{}), "StageCol column 4"},
+		{FlushColsCommand(), "FlushCols"},
+		{VersionCommand(), "Version query"},
+		{BootloaderCommand(), "Bootloader"},
+		{NewCommand(0x7F, 1, 2), "0x7F 01 02"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cmd.Describe(); got != tt.want {
+			t.Errorf("Describe() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCommandNamesCoverDecoder(t *testing.T) {
+	// Every ID the decoder knows has a name, and every named ID can be decoded
+	for id := 0; id <= 0xFF; id++ {
+		_, named := commandNames[byte(id)]
+		known := paramLength(byte(id), nil) >= 0
+
+		if named != known {
+			t.Errorf("command 0x%02X: named = %t, decodable = %t", id, named, known)
+		}
+	}
+
+	if PatternName(PatternLotus2) != "lotus2" || CommandName(0x7F) != "0x7F" {
+		t.Errorf("PatternName() = %q, CommandName() = %q", PatternName(PatternLotus2), CommandName(0x7F))
+	}
+}
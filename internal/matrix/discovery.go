@@ -0,0 +1,218 @@
+package matrix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.bug.st/serial/enumerator"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+)
+
+// Device is a USB serial port that may be a Framework LED matrix.
+type Device struct {
+	enumerator.PortDetails
+	// Location is the port's USB topology path, such as "3-4.1". It stays the same across
+	// reboots as long as the module stays in the same socket, and is empty where unknown.
+	Location string
+}
+
+// String describes the device by port, serial number and location, for log and error messages.
+func (d Device) String() string {
+	serialNumber := d.SerialNumber
+	if serialNumber == "" {
+		serialNumber = "unknown"
+	}
+
+	location := d.Location
+	if location == "" {
+		location = "unknown"
+	}
+
+	return fmt.Sprintf("%s (serial number %s, location %s)", d.Name, serialNumber, location)
+}
+
+// IsLEDMatrix reports whether the device has the USB vendor and product IDs of a Framework LED matrix.
+func (d Device) IsLEDMatrix() bool {
+	return strings.EqualFold(d.VID, FrameworkVID) && strings.EqualFold(d.PID, FrameworkLEDMatrixPID)
+}
+
+// ListDevices returns every USB serial port with its full USB details, whether or not it is a
+// Framework LED matrix.
+func ListDevices() ([]Device, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate ports: %w", err)
+	}
+
+	var devices []Device
+
+	for _, port := range ports {
+		if !port.IsUSB {
+			continue
+		}
+
+		logging.Debug("found USB port", "name", port.Name, "vid", port.VID, "pid", port.PID,
+			"serial_number", port.SerialNumber)
+
+		device := Device{PortDetails: *port, Location: usbLocation(port.Name)}
+		if device.Product == "" {
+			device.Product = usbProduct(port.Name)
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// DiscoverDevices returns the USB serial ports with the Framework LED matrix VID and PID. If
+// there are none and fallback is set, every USB serial port is returned instead.
+func DiscoverDevices(fallback bool) ([]Device, error) {
+	usbDevices, err := ListDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	return selectLEDMatrices(usbDevices, fallback)
+}
+
+// selectLEDMatrices picks the Framework LED matrices out of usbDevices, as DiscoverDevices does.
+func selectLEDMatrices(usbDevices []Device, fallback bool) ([]Device, error) {
+	var devices []Device
+
+	for _, device := range usbDevices {
+		if device.IsLEDMatrix() {
+			devices = append(devices, device)
+		}
+	}
+
+	if len(devices) == 0 && fallback && len(usbDevices) > 0 {
+		logging.Warn("no Framework LED matrix found, falling back to every USB serial port",
+			"count", len(usbDevices))
+
+		devices = usbDevices
+	}
+
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no Framework LED matrix found (USB VID %s, PID %s) among %d USB serial ports",
+			FrameworkVID, FrameworkLEDMatrixPID, len(usbDevices))
+	}
+
+	return devices, nil
+}
+
+// bindMatrices picks the port for each configured matrix, returning the ports in the same order
+// as matrices with "" for any matrix that could not be bound, and an error explaining each one.
+//
+// Matrices that name a port use it. Matrices with a serial number or location use the one device
+// that matches all of them. The rest take the remaining devices in order of USB location, so the
+// same modules are chosen whatever order the operating system numbered their ports in.
+func bindMatrices(matrices []SingleMatrixConfig, devices []Device) ([]string, []error) {
+	sorted := make([]Device, len(devices))
+	copy(sorted, devices)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Location != sorted[j].Location {
+			return sorted[i].Location < sorted[j].Location
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	ports := make([]string, len(matrices))
+	boundTo := make(map[string]string) // Port name to the matrix bound to it
+
+	var errs []error
+
+	for i, m := range matrices {
+		if m.Port != "" {
+			ports[i] = m.Port
+			boundTo[m.Port] = m.Name
+		}
+	}
+
+	for i, m := range matrices {
+		if m.Port != "" || m.SerialNumber == "" && m.Location == "" {
+			continue
+		}
+
+		var matches []Device
+
+		for _, device := range sorted {
+			if (m.SerialNumber == "" || device.SerialNumber == m.SerialNumber) &&
+				(m.Location == "" || device.Location == m.Location) {
+				matches = append(matches, device)
+			}
+		}
+
+		switch {
+		case len(matches) == 0:
+			errs = append(errs, fmt.Errorf("matrix %q (%s) matches no discovered device; found %s",
+				m.Name, describeIdentity(m), describeDevices(sorted)))
+		case len(matches) > 1:
+			errs = append(errs, fmt.Errorf("matrix %q (%s) matches more than one device: %s",
+				m.Name, describeIdentity(m), describeDevices(matches)))
+		case boundTo[matches[0].Name] != "":
+			errs = append(errs, fmt.Errorf("matrix %q (%s) matches %s, which is already bound to matrix %q",
+				m.Name, describeIdentity(m), matches[0], boundTo[matches[0].Name]))
+		default:
+			ports[i] = matches[0].Name
+			boundTo[matches[0].Name] = m.Name
+		}
+	}
+
+	next := 0
+
+	for i, m := range matrices {
+		if m.Port != "" || m.SerialNumber != "" || m.Location != "" {
+			continue
+		}
+
+		for next < len(sorted) && boundTo[sorted[next].Name] != "" {
+			next++
+		}
+
+		if next == len(sorted) {
+			errs = append(errs, fmt.Errorf("matrix %q has no device left to bind to; found %s",
+				m.Name, describeDevices(sorted)))
+
+			continue
+		}
+
+		ports[i] = sorted[next].Name
+		boundTo[sorted[next].Name] = m.Name
+	}
+
+	return ports, errs
+}
+
+// describeIdentity lists the serial number and location a matrix is configured to match.
+func describeIdentity(m SingleMatrixConfig) string {
+	var parts []string
+
+	if m.SerialNumber != "" {
+		parts = append(parts, "serial number "+m.SerialNumber)
+	}
+
+	if m.Location != "" {
+		parts = append(parts, "location "+m.Location)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// describeDevices lists devices for an error message.
+func describeDevices(devices []Device) string {
+	if len(devices) == 0 {
+		return "no devices"
+	}
+
+	descriptions := make([]string, len(devices))
+	for i, device := range devices {
+		descriptions[i] = device.String()
+	}
+
+	return strings.Join(descriptions, ", ")
+}
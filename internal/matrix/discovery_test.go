@@ -0,0 +1,170 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func testDevice(name, serialNumber, location string) Device {
+	return Device{
+		PortDetails: enumerator.PortDetails{Name: name, IsUSB: true, VID: FrameworkVID, PID: FrameworkLEDMatrixPID, SerialNumber: serialNumber},
+		Location:    location,
+	}
+}
+
+func TestSelectLEDMatrices(t *testing.T) {
+	arduino := Device{PortDetails: enumerator.PortDetails{Name: "/dev/ttyACM0", IsUSB: true, VID: "2341", PID: "0043"}}
+	otherModule := Device{PortDetails: enumerator.PortDetails{Name: "/dev/ttyACM1", IsUSB: true, VID: "32ac", PID: "0013"}}
+	matrix := Device{PortDetails: enumerator.PortDetails{Name: "/dev/ttyACM2", IsUSB: true, VID: "32ac", PID: "0020"}}
+
+	got, err := selectLEDMatrices([]Device{arduino, otherModule, matrix}, false)
+	if err != nil || len(got) != 1 || got[0].Name != "/dev/ttyACM2" {
+		t.Errorf("selectLEDMatrices() = %v, %v, want only the LED matrix", got, err)
+	}
+
+	if _, err := selectLEDMatrices([]Device{arduino, otherModule}, false); err == nil {
+		t.Error("selectLEDMatrices() should not fall back to other USB devices unless asked to")
+	}
+
+	got, err = selectLEDMatrices([]Device{arduino, otherModule}, true)
+	if err != nil || len(got) != 2 {
+		t.Errorf("selectLEDMatrices() with fallback = %v, %v, want every USB device", got, err)
+	}
+
+	if _, err := selectLEDMatrices(nil, true); err == nil {
+		t.Error("selectLEDMatrices() should fail when there are no USB devices at all")
+	}
+}
+
+func TestBindMatrices(t *testing.T) {
+	// Listed in the order the ports were numbered, which differs from the USB sockets' order
+	devices := []Device{
+		testDevice("/dev/ttyACM0", "SERIAL-B", "3-4.2"),
+		testDevice("/dev/ttyACM1", "SERIAL-A", "3-4.1"),
+		testDevice("/dev/ttyACM2", "SERIAL-C", "3-4.3"),
+	}
+
+	tests := []struct {
+		name     string
+		errors   []string
+		matrices []SingleMatrixConfig
+		want     []string
+	}{
+		{
+			name:     "by location order",
+			matrices: []SingleMatrixConfig{{Name: "primary"}, {Name: "secondary"}},
+			want:     []string{"/dev/ttyACM1", "/dev/ttyACM0"},
+		},
+		{
+			name: "by serial number",
+			matrices: []SingleMatrixConfig{
+				{Name: "primary", SerialNumber: "SERIAL-C"},
+				{Name: "secondary", SerialNumber: "SERIAL-B"},
+			},
+			want: []string{"/dev/ttyACM2", "/dev/ttyACM0"},
+		},
+		{
+			name: "identified matrices bind first",
+			matrices: []SingleMatrixConfig{
+				{Name: "primary"},
+				{Name: "secondary", Location: "3-4.1"},
+				{Name: "third", Port: "/dev/ttyACM0"},
+			},
+			want: []string{"/dev/ttyACM2", "/dev/ttyACM1", "/dev/ttyACM0"},
+		},
+		{
+			name: "serial number and location must both match",
+			matrices: []SingleMatrixConfig{
+				{Name: "primary", SerialNumber: "SERIAL-A", Location: "3-4.2"},
+			},
+			want:   []string{""},
+			errors: []string{`matrix "primary" (serial number SERIAL-A, location 3-4.2) matches no discovered device`},
+		},
+		{
+			name: "device already bound",
+			matrices: []SingleMatrixConfig{
+				{Name: "primary", Port: "/dev/ttyACM2"},
+				{Name: "secondary", SerialNumber: "SERIAL-C"},
+			},
+			want:   []string{"/dev/ttyACM2", ""},
+			errors: []string{`already bound to matrix "primary"`},
+		},
+		{
+			name:     "more matrices than devices",
+			matrices: []SingleMatrixConfig{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}},
+			want:     []string{"/dev/ttyACM1", "/dev/ttyACM0", "/dev/ttyACM2", ""},
+			errors:   []string{`matrix "d" has no device left to bind to`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := bindMatrices(tt.matrices, devices)
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("bindMatrices() ports = %q, want %q", got, tt.want)
+			}
+
+			if len(errs) != len(tt.errors) {
+				t.Fatalf("bindMatrices() errors = %v, want %d", errs, len(tt.errors))
+			}
+
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), tt.errors[i]) {
+					t.Errorf("error %d = %q, want it to contain %q", i, err, tt.errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBindMatricesErrorListsDevices(t *testing.T) {
+	devices := []Device{testDevice("/dev/ttyACM0", "SERIAL-A", "")}
+
+	_, errs := bindMatrices([]SingleMatrixConfig{{Name: "left", SerialNumber: "SERIAL-Z"}}, devices)
+	if len(errs) != 1 {
+		t.Fatalf("bindMatrices() errors = %v, want 1", errs)
+	}
+
+	want := "found /dev/ttyACM0 (serial number SERIAL-A, location unknown)"
+	if !strings.Contains(errs[0].Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", errs[0], want)
+	}
+}
+
+func TestMultiClientBindsBySerialNumber(t *testing.T) {
+	left, right := NewVirtualMatrix(), NewVirtualMatrix()
+
+	mc := NewMultiClient()
+	mc.SetOpener(VirtualOpener(map[string]*VirtualMatrix{"virtual0": right, "virtual1": left}))
+	mc.discover = func() ([]Device, error) {
+		return []Device{testDevice("virtual0", "RIGHT", ""), testDevice("virtual1", "LEFT", "")}, nil
+	}
+
+	err := mc.DiscoverAndConnect([]SingleMatrixConfig{
+		{Name: "left", SerialNumber: "LEFT", Brightness: 10},
+		{Name: "right", SerialNumber: "RIGHT", Brightness: 20},
+	}, 115200)
+	if err != nil {
+		t.Fatalf("DiscoverAndConnect() error = %v", err)
+	}
+	defer mc.Disconnect()
+
+	for _, client := range mc.GetClients() {
+		_ = client.Flush()
+	}
+
+	if left.Brightness() != 10 || right.Brightness() != 20 {
+		t.Errorf("brightness left = %d, right = %d, want 10 and 20", left.Brightness(), right.Brightness())
+	}
+
+	mc = NewMultiClient()
+	mc.discover = func() ([]Device, error) { return []Device{testDevice("virtual0", "OTHER", "")}, nil }
+
+	err = mc.DiscoverAndConnect([]SingleMatrixConfig{{Name: "left", SerialNumber: "LEFT"}}, 115200)
+	if err == nil || !strings.Contains(err.Error(), `matrix "left" (serial number LEFT) matches no discovered device`) {
+		t.Errorf("DiscoverAndConnect() error = %v, want it to explain which matrix was not matched", err)
+	}
+}
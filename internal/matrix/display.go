@@ -2,6 +2,7 @@ package matrix
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,15 +16,29 @@ type ClientInterface interface {
 	ShowGradient() error
 	ShowFullBright() error
 	SetBrightness(level byte) error
+	DrawBitmap(pixels [39]byte) error
+	StageColumn(col byte, pixels [34]byte) error
+	FlushColumns() error
 }
 
 // DisplayManager manages display operations for a single LED matrix with rate limiting and state tracking.
+//
+// With a Transition set, brightness changes and status pattern switches fade, and frames
+// crossfade, on the DisplayManager's own timer. Errors during a transition are logged.
 type DisplayManager struct {
 	lastUpdate   time.Time
 	client       ClientInterface
 	currentState map[string]interface{}
+	frame        *Framebuffer // Pixels last drawn, partway through a crossfade if one is running
+	fades        map[string]*fade
+	now          func() time.Time
+	transition   Transition
 	updateRate   time.Duration
+	brightness   int // Brightness last sent to the module, or -1 if not yet known
 	mu           sync.RWMutex
+	caps         Capabilities
+	frameShown   bool // The module shows frame rather than a pattern or bitmap
+	fading       bool // The goroutine advancing fades is running
 }
 
 // NewDisplayManager creates a new DisplayManager with the specified client and default update rate.
@@ -32,9 +47,24 @@ func NewDisplayManager(client ClientInterface) *DisplayManager {
 		client:       client,
 		updateRate:   time.Second,
 		currentState: make(map[string]interface{}),
+		frame:        NewFramebuffer(),
+		fades:        make(map[string]*fade),
+		now:          time.Now,
+		transition:   Transition{Easing: EaseInOut},
+		brightness:   -1,
+		caps:         FullCapabilities(),
 	}
 }
 
+// SetCapabilities sets the firmware features the module supports. Until it is called every
+// feature is assumed to be available.
+func (dm *DisplayManager) SetCapabilities(caps Capabilities) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	dm.caps = caps
+}
+
 // SetUpdateRate sets the minimum time interval between display updates to prevent flickering.
 func (dm *DisplayManager) SetUpdateRate(rate time.Duration) {
 	dm.mu.Lock()
@@ -47,7 +77,8 @@ func (dm *DisplayManager) shouldUpdate() bool {
 	dm.mu.RLock()
 	defer dm.mu.RUnlock()
 
-	return time.Since(dm.lastUpdate) >= dm.updateRate
+	// Going back from a frame to a pattern is a change of content, not a redraw
+	return dm.frameShown || time.Since(dm.lastUpdate) >= dm.updateRate
 }
 
 func (dm *DisplayManager) markUpdatedUnsafe() {
@@ -64,7 +95,7 @@ func (dm *DisplayManager) UpdatePercentage(key string, percent float64) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
-	if lastPercent, exists := dm.currentState[key]; exists {
+	if lastPercent, exists := dm.currentState[key]; exists && !dm.frameShown {
 		if lastPercentFloat, ok := lastPercent.(float64); ok {
 			if abs(lastPercentFloat-percent) < 1.0 {
 				return nil
@@ -81,6 +112,7 @@ func (dm *DisplayManager) UpdatePercentage(key string, percent float64) error {
 		return fmt.Errorf("failed to update percentage display: %w", err)
 	}
 
+	dm.patternShownUnsafe()
 	dm.currentState[key] = percent
 	dm.markUpdatedUnsafe()
 	logging.Debug("updated percentage display", "key", key, "percent", percent)
@@ -110,6 +142,7 @@ func (dm *DisplayManager) ShowActivity(active bool) error {
 		return fmt.Errorf("failed to update activity display: %w", err)
 	}
 
+	dm.patternShownUnsafe()
 	dm.markUpdatedUnsafe()
 	logging.Debug("updated activity display", "active", active)
 
@@ -117,28 +150,47 @@ func (dm *DisplayManager) ShowActivity(active bool) error {
 }
 
 // ShowStatus displays system status using different LED patterns (normal=gradient, warning=zigzag,
-// critical=full bright, off=no brightness).
+// critical=full bright, off=no brightness). With a transition set, a change of status fades out
+// the old pattern and fades in the new one.
 func (dm *DisplayManager) ShowStatus(status string) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
-	var err error
+	var show func() error
 
 	switch status {
 	case "normal":
-		err = dm.client.ShowGradient()
+		show = dm.client.ShowGradient
 	case "warning":
-		err = dm.client.ShowZigZag()
+		show = dm.client.ShowZigZag
 	case "critical":
-		err = dm.client.ShowFullBright()
+		show = dm.client.ShowFullBright
 	case "off":
-		err = dm.client.SetBrightness(0)
 	default:
 		return fmt.Errorf("unknown status: %s", status)
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to update status display: %w", err)
+	if dm.transitionsEnabledUnsafe() && dm.brightness >= 0 {
+		_, fading := dm.fades[fadeBrightness]
+
+		switch {
+		case status == dm.currentState["status"] && fading:
+			// Already fading to this status
+		case status == "off":
+			dm.fadeBrightnessUnsafe(0)
+		case status != dm.currentState["status"]:
+			dm.dipUnsafe(show)
+		default:
+			if err := show(); err != nil {
+				return fmt.Errorf("failed to update status display: %w", err)
+			}
+
+			dm.patternShownUnsafe()
+		}
+	} else {
+		if err := dm.showStatusUnsafe(status, show); err != nil {
+			return fmt.Errorf("failed to update status display: %w", err)
+		}
 	}
 
 	dm.currentState["status"] = status
@@ -148,13 +200,128 @@ func (dm *DisplayManager) ShowStatus(status string) error {
 	return nil
 }
 
-// SetBrightness sets the LED matrix brightness level from 0-255.
+// showStatusUnsafe switches to a status pattern at once, or sets the brightness to 0 if show is nil.
+func (dm *DisplayManager) showStatusUnsafe(status string, show func() error) error {
+	dm.cancelFadeUnsafe(fadeBrightness)
+
+	if show == nil {
+		dm.cancelFadeUnsafe(fadeFrame)
+
+		if err := dm.client.SetBrightness(0); err != nil {
+			return err
+		}
+
+		dm.brightness = 0
+
+		return nil
+	}
+
+	if err := show(); err != nil {
+		return err
+	}
+
+	dm.patternShownUnsafe()
+	logging.Debug("switched status pattern", "status", status)
+
+	return nil
+}
+
+// patternShownUnsafe records that the module shows something other than frame, such as a
+// built-in pattern, so the next frame is pushed in full and crossfades in from black.
+func (dm *DisplayManager) patternShownUnsafe() {
+	dm.cancelFadeUnsafe(fadeFrame)
+	dm.frame.Invalidate()
+	dm.frameShown = false
+}
+
+// DrawFrame pushes a framebuffer to the LED matrix, staging only the columns that differ
+// from the last frame drawn. With a transition set, the new frame crossfades in.
+func (dm *DisplayManager) DrawFrame(fb *Framebuffer) error {
+	return dm.drawFrame(fb, true)
+}
+
+// DrawFrameNow pushes a framebuffer like DrawFrame, but without a transition, for frames that
+// are redrawn too often to fade between, such as blinking or scrolling content.
+func (dm *DisplayManager) DrawFrameNow(fb *Framebuffer) error {
+	return dm.drawFrame(fb, false)
+}
+
+func (dm *DisplayManager) drawFrame(fb *Framebuffer, transition bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	// Crossfades need greyscale; bitmaps can only switch pixels on and off
+	if transition && dm.transitionsEnabledUnsafe() && dm.caps.Greyscale {
+		dm.crossfadeUnsafe(fb)
+	} else {
+		dm.cancelFadeUnsafe(fadeFrame)
+		dm.frame.CopyFrom(fb)
+
+		if err := dm.pushFrameUnsafe(); err != nil {
+			return err
+		}
+	}
+
+	dm.currentState["frame"] = true
+	dm.markUpdatedUnsafe()
+
+	return nil
+}
+
+// pushFrameUnsafe shows frame on the module.
+func (dm *DisplayManager) pushFrameUnsafe() error {
+	if dm.caps.Greyscale {
+		if err := dm.frame.Push(dm.client); err != nil {
+			return fmt.Errorf("failed to draw frame: %w", err)
+		}
+	} else {
+		// Without greyscale columns, show every lit pixel at full brightness
+		if err := dm.client.DrawBitmap(PackBitmap(dm.frame.Threshold(1))); err != nil {
+			return fmt.Errorf("failed to draw frame: %w", err)
+		}
+
+		dm.frame.Invalidate()
+	}
+
+	dm.frameShown = true
+
+	return nil
+}
+
+// DrawBitmap draws a packed black and white bitmap on the LED matrix.
+func (dm *DisplayManager) DrawBitmap(pixels [39]byte) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if err := dm.client.DrawBitmap(pixels); err != nil {
+		return fmt.Errorf("failed to draw bitmap: %w", err)
+	}
+
+	dm.patternShownUnsafe()
+	dm.currentState["bitmap"] = true
+	dm.markUpdatedUnsafe()
+	logging.Debug("drew bitmap")
+
+	return nil
+}
+
+// SetBrightness sets the LED matrix brightness level from 0-255. With a transition set, the
+// brightness ramps to level from wherever it is, replacing any fade still running.
 func (dm *DisplayManager) SetBrightness(level byte) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
-	if err := dm.client.SetBrightness(level); err != nil {
-		return fmt.Errorf("failed to set brightness: %w", err)
+	// The first level is set at once, since there is nothing known to fade from
+	if dm.transitionsEnabledUnsafe() && dm.brightness >= 0 {
+		dm.fadeBrightnessUnsafe(level)
+	} else {
+		dm.cancelFadeUnsafe(fadeBrightness)
+
+		if err := dm.client.SetBrightness(level); err != nil {
+			return fmt.Errorf("failed to set brightness: %w", err)
+		}
+
+		dm.brightness = int(level)
 	}
 
 	dm.currentState["brightness"] = level
@@ -190,6 +357,7 @@ type MultiDisplayManager struct {
 	displays    map[string]*DisplayManager
 	multiClient *MultiClient
 	dualMode    string
+	layout      string
 	mu          sync.RWMutex
 }
 
@@ -202,7 +370,16 @@ func NewMultiDisplayManager(multiClient *MultiClient, dualMode string) *MultiDis
 	}
 
 	for name, client := range multiClient.GetClients() {
-		mdm.displays[name] = NewDisplayManager(client)
+		// Draw through the supervisor so a lost module is reconnected and its state replayed
+		if supervisor := multiClient.GetSupervisor(name); supervisor != nil {
+			mdm.displays[name] = NewDisplayManager(supervisor)
+		} else {
+			mdm.displays[name] = NewDisplayManager(client)
+		}
+
+		if version, ok := multiClient.GetFirmwareVersion(name); ok {
+			mdm.displays[name].SetCapabilities(version.Capabilities())
+		}
 	}
 
 	return mdm
@@ -285,14 +462,71 @@ func (mdm *MultiDisplayManager) updateSplitMode(metricName string, value float64
 }
 
 func (mdm *MultiDisplayManager) updateExtendedMode(metricName string, value float64) error {
-	// Show a wider visualization across both matrices
-	// For now, treat it like split mode but could be enhanced later
-	return mdm.updateSplitMode(metricName, value)
+	// Draw one bar for the primary matrix's metric across the whole canvas
+	order := mdm.canvasOrderUnsafe()
+	if len(order) == 0 {
+		return nil
+	}
+
+	metric := "cpu"
+	if matrixConfig := mdm.multiClient.GetConfig(order[0]); matrixConfig != nil && len(matrixConfig.Metrics) > 0 {
+		metric = matrixConfig.Metrics[0]
+	}
+
+	if metricName != metric {
+		return nil
+	}
+
+	canvas, err := NewCanvas(mdm.layout)
+	if err != nil {
+		return err
+	}
+
+	drawCanvasBar(canvas, value)
+
+	return mdm.drawCanvasUnsafe(canvas, (*DisplayManager).DrawFrame)
+}
+
+// drawCanvasBar fills the canvas in proportion to percent along its long axis, dimming the
+// leading edge for partial steps so the bar moves smoothly from one module to the next.
+func drawCanvasBar(canvas *Canvas, percent float64) {
+	width, height := canvas.Size()
+	vertical := canvas.Layout() == LayoutVertical
+
+	size := width
+	if vertical {
+		size = height
+	}
+
+	filled := clampPercent(percent) / 100 * float64(size)
+	full := int(filled)
+	edge := byte((filled - float64(full)) * 255)
+
+	if vertical {
+		canvas.FillRect(0, size-full, width, full, 255)
+		canvas.FillRect(0, size-full-1, width, 1, edge)
+
+		return
+	}
+
+	canvas.FillRect(0, 0, full, height, 255)
+	canvas.FillRect(full, 0, 1, height, edge)
+}
+
+func clampPercent(percent float64) float64 {
+	switch {
+	case percent < 0:
+		return 0
+	case percent > 100:
+		return 100
+	default:
+		return percent
+	}
 }
 
 func (mdm *MultiDisplayManager) updateIndependentMode(metricName string, value float64) error {
-	// Each matrix operates completely independently
-	// This would require more sophisticated configuration
+	// In independent mode each matrix is driven through its own DisplayManager with its own
+	// mode and metric (see GetDisplayManager); metric updates fall back to split behaviour
 	return mdm.updateSplitMode(metricName, value)
 }
 
@@ -330,6 +564,32 @@ func (mdm *MultiDisplayManager) UpdateStatus(status string) error {
 	return lastErr
 }
 
+// DrawFrame draws the same framebuffer on all managed displays.
+func (mdm *MultiDisplayManager) DrawFrame(fb *Framebuffer) error {
+	return mdm.drawFrame(fb, (*DisplayManager).DrawFrame)
+}
+
+// DrawFrameNow draws the same framebuffer on all managed displays without a transition.
+func (mdm *MultiDisplayManager) DrawFrameNow(fb *Framebuffer) error {
+	return mdm.drawFrame(fb, (*DisplayManager).DrawFrameNow)
+}
+
+func (mdm *MultiDisplayManager) drawFrame(fb *Framebuffer, draw func(*DisplayManager, *Framebuffer) error) error {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	var lastErr error
+
+	for name, display := range mdm.displays {
+		if err := draw(display, fb); err != nil {
+			lastErr = err
+			logging.Error("failed to draw frame on display", "matrix", name, "error", err)
+		}
+	}
+
+	return lastErr
+}
+
 // SetBrightness sets brightness level on all managed displays.
 func (mdm *MultiDisplayManager) SetBrightness(level byte) error {
 	mdm.mu.RLock()
@@ -357,6 +617,108 @@ func (mdm *MultiDisplayManager) SetUpdateRate(rate time.Duration) {
 	}
 }
 
+// SetTransition sets how changes are animated on all managed displays.
+func (mdm *MultiDisplayManager) SetTransition(transition Transition) {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	for _, display := range mdm.displays {
+		display.SetTransition(transition)
+	}
+}
+
+// CompleteTransitions jumps every running transition on all managed displays to its end.
+func (mdm *MultiDisplayManager) CompleteTransitions() {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	for _, display := range mdm.displays {
+		display.CompleteTransitions()
+	}
+}
+
+// SetLayout sets how the modules are arranged in extended mode: LayoutHorizontal (68x9) or LayoutVertical (34x18).
+func (mdm *MultiDisplayManager) SetLayout(layout string) {
+	mdm.mu.Lock()
+	defer mdm.mu.Unlock()
+
+	mdm.layout = layout
+}
+
+// NewCanvas returns a blank Canvas spanning the managed displays in the configured layout.
+func (mdm *MultiDisplayManager) NewCanvas() (*Canvas, error) {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	return NewCanvas(mdm.layout)
+}
+
+// DrawCanvas slices a Canvas across the managed displays by role: the primary matrix shows the
+// left or top half and the secondary matrix the right or bottom half.
+func (mdm *MultiDisplayManager) DrawCanvas(canvas *Canvas) error {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	return mdm.drawCanvasUnsafe(canvas, (*DisplayManager).DrawFrame)
+}
+
+// DrawCanvasNow slices a Canvas across the managed displays like DrawCanvas, without a transition.
+func (mdm *MultiDisplayManager) DrawCanvasNow(canvas *Canvas) error {
+	mdm.mu.RLock()
+	defer mdm.mu.RUnlock()
+
+	return mdm.drawCanvasUnsafe(canvas, (*DisplayManager).DrawFrameNow)
+}
+
+func (mdm *MultiDisplayManager) drawCanvasUnsafe(canvas *Canvas, draw func(*DisplayManager, *Framebuffer) error) error {
+	var lastErr error
+
+	for slot, name := range mdm.canvasOrderUnsafe() {
+		if slot >= canvasSlots {
+			break
+		}
+
+		if err := draw(mdm.displays[name], canvas.Slice(slot)); err != nil {
+			lastErr = err
+			logging.Error("failed to draw canvas slice", "matrix", name, "slot", slot, "error", err)
+		}
+	}
+
+	return lastErr
+}
+
+// canvasOrderUnsafe returns display names in canvas slot order: the primary matrix, then the
+// secondary matrix, then any others by name.
+func (mdm *MultiDisplayManager) canvasOrderUnsafe() []string {
+	rank := func(name string) int {
+		if matrixConfig := mdm.multiClient.GetConfig(name); matrixConfig != nil {
+			switch matrixConfig.Role {
+			case "primary":
+				return 0
+			case "secondary":
+				return 1
+			}
+		}
+
+		return 2
+	}
+
+	names := make([]string, 0, len(mdm.displays))
+	for name := range mdm.displays {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) != rank(names[j]) {
+			return rank(names[i]) < rank(names[j])
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
 // GetDisplayManager returns the DisplayManager for the specified matrix name.
 func (mdm *MultiDisplayManager) GetDisplayManager(name string) *DisplayManager {
 	mdm.mu.RLock()
@@ -347,6 +347,31 @@ func TestDisplayManagerUpdatePercentageMinimalChange(t *testing.T) {
 	}
 }
 
+func TestDisplayManagerUpdatePercentageAfterFrame(t *testing.T) {
+	mockClient := NewMockClient()
+	dm := NewDisplayManager(mockClient)
+	dm.SetUpdateRate(time.Hour)
+
+	if err := dm.UpdatePercentage("cpu", 50.0); err != nil {
+		t.Fatalf("UpdatePercentage() error = %v", err)
+	}
+
+	if err := dm.DrawFrame(NewFramebuffer()); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	// The frame replaced the percentage pattern, so the same percentage is shown again at once
+	mockClient.ClearCommands()
+
+	if err := dm.UpdatePercentage("cpu", 50.0); err != nil {
+		t.Fatalf("UpdatePercentage() error = %v", err)
+	}
+
+	if commands := mockClient.GetCommands(); len(commands) != 1 {
+		t.Errorf("Expected 1 command to redraw the percentage over a frame, got %d", len(commands))
+	}
+}
+
 func TestDisplayManagerShowActivity(t *testing.T) {
 	mockClient := NewMockClient()
 	dm := NewDisplayManager(mockClient)
@@ -0,0 +1,72 @@
+package matrix
+
+import "fmt"
+
+// FirmwareVersion is a module's firmware version as reported in reply to VersionCommand.
+type FirmwareVersion struct {
+	Major      byte
+	Minor      byte
+	Patch      byte
+	PreRelease bool
+}
+
+// MinGreyscaleFirmware is the first firmware that can stage and flush greyscale columns. Modules
+// on older firmware are driven with whatever the daemon can do without it.
+var MinGreyscaleFirmware = FirmwareVersion{Major: 0, Minor: 1, Patch: 2}
+
+// ParseFirmwareVersion decodes a version reply: the major version, then the minor version in the
+// high nibble and the patch version in the low nibble, then a non-zero byte for a pre-release.
+func ParseFirmwareVersion(response []byte) (FirmwareVersion, error) {
+	if len(response) != VersionResponseSize {
+		return FirmwareVersion{}, fmt.Errorf("invalid version response: got %d bytes, want %d",
+			len(response), VersionResponseSize)
+	}
+
+	return FirmwareVersion{
+		Major:      response[0],
+		Minor:      response[1] >> 4,
+		Patch:      response[1] & 0x0F,
+		PreRelease: response[2] != 0,
+	}, nil
+}
+
+// String returns the version as "major.minor.patch", with a "-pre" suffix for pre-releases.
+func (v FirmwareVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease {
+		s += "-pre"
+	}
+
+	return s
+}
+
+// AtLeast reports whether v is the same release as other or a later one. Pre-release builds
+// count as the release they lead up to.
+func (v FirmwareVersion) AtLeast(other FirmwareVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+
+	return v.Patch >= other.Patch
+}
+
+// Capabilities lists the optional firmware features the daemon relies on.
+type Capabilities struct {
+	Greyscale bool // StageCol and FlushCols draw greyscale frames
+}
+
+// FullCapabilities assumes every feature is available, as for a module whose version is unknown.
+func FullCapabilities() Capabilities {
+	return Capabilities{Greyscale: true}
+}
+
+// Capabilities returns the features supported by this firmware version.
+func (v FirmwareVersion) Capabilities() Capabilities {
+	return Capabilities{
+		Greyscale: v.AtLeast(MinGreyscaleFirmware),
+	}
+}
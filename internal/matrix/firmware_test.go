@@ -0,0 +1,122 @@
+package matrix
+
+import "testing"
+
+func TestParseFirmwareVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		response []byte
+		wantErr  bool
+	}{
+		{name: "release", response: []byte{0, 0x19, 0}, want: "0.1.9"},
+		{name: "pre-release", response: []byte{1, 0x2A, 1}, want: "1.2.10-pre"},
+		{name: "short reply", response: []byte{0, 0x19}, wantErr: true},
+		{name: "empty reply", response: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFirmwareVersion(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFirmwareVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseFirmwareVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirmwareCapabilities(t *testing.T) {
+	tests := []struct {
+		version FirmwareVersion
+		want    Capabilities
+	}{
+		{version: FirmwareVersion{Minor: 1, Patch: 1}, want: Capabilities{}},
+		{version: FirmwareVersion{Minor: 1, Patch: 2, PreRelease: true}, want: Capabilities{Greyscale: true}},
+		{version: FirmwareVersion{Major: 1}, want: Capabilities{Greyscale: true}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.version.Capabilities(); got != tt.want {
+			t.Errorf("%s.Capabilities() = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMultiClientReadsFirmwareVersions(t *testing.T) {
+	primary, secondary := NewVirtualMatrix(), NewVirtualMatrix()
+	secondary.SetVersion([VersionResponseSize]byte{0, 0x11, 0})
+
+	mc := NewMultiClient()
+	mc.SetOpener(VirtualOpener(map[string]*VirtualMatrix{"virtual0": primary, "virtual1": secondary}))
+
+	err := mc.DiscoverAndConnect([]SingleMatrixConfig{
+		{Name: "primary", Port: "virtual0"},
+		{Name: "secondary", Port: "virtual1"},
+	}, 115200)
+	if err != nil {
+		t.Fatalf("DiscoverAndConnect() error = %v", err)
+	}
+	defer mc.Disconnect()
+
+	if got, ok := mc.GetFirmwareVersion("secondary"); !ok || got.String() != "0.1.1" {
+		t.Errorf("GetFirmwareVersion(secondary) = %s, %t, want 0.1.1", got, ok)
+	}
+
+	if _, ok := mc.GetFirmwareVersion("missing"); ok {
+		t.Error("GetFirmwareVersion() should report false for an unknown matrix")
+	}
+
+	// The older module draws frames as black and white bitmaps
+	mdm := NewMultiDisplayManager(mc, "mirror")
+
+	fb := NewFramebuffer()
+	fb.SetPixel(3, 1, 40)
+
+	if err := mdm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	for _, client := range mc.GetClients() {
+		_ = client.Flush()
+	}
+
+	if primary.Frame().Pixel(3, 1) != 40 {
+		t.Errorf("primary pixel = %d, want the greyscale value 40", primary.Frame().Pixel(3, 1))
+	}
+
+	if secondary.Frame().Pixel(3, 1) != 0xFF {
+		t.Errorf("secondary pixel = %d, want the pixel drawn at full brightness", secondary.Frame().Pixel(3, 1))
+	}
+}
+
+func TestMultiClientRecordsFirmwareOnReconnect(t *testing.T) {
+	mc := NewMultiClient()
+	mc.firmware["left"] = FirmwareVersion{Minor: 1, Patch: 1}
+
+	var forwarded []ConnectionEvent
+
+	mc.SetEventHandler(func(event ConnectionEvent) { forwarded = append(forwarded, event) })
+
+	// The module was updated while it was unplugged
+	updated := FirmwareVersion{Minor: 1, Patch: 9}
+	mc.handleEvent(ConnectionEvent{Kind: EventReconnected, Matrix: "left", Firmware: &updated})
+
+	if got, ok := mc.GetFirmwareVersion("left"); !ok || got != updated {
+		t.Errorf("GetFirmwareVersion(left) = %s, %t, want %s read on reconnecting", got, ok, updated)
+	}
+
+	// A reconnect without a version keeps the last one read
+	mc.handleEvent(ConnectionEvent{Kind: EventReconnected, Matrix: "left"})
+
+	if got, _ := mc.GetFirmwareVersion("left"); got != updated {
+		t.Errorf("GetFirmwareVersion(left) = %s, want %s kept", got, updated)
+	}
+
+	if len(forwarded) != 2 {
+		t.Errorf("handler received %d events, want both passed on", len(forwarded))
+	}
+}
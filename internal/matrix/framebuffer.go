@@ -0,0 +1,234 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+)
+
+// Framebuffer dimensions as seen by the daemon. The module is mounted so that each of the
+// firmware's 9 columns of 34 LEDs appears as one row of 34 pixels.
+const (
+	FramebufferWidth  = 34
+	FramebufferHeight = 9
+)
+
+// ColumnClient defines the column staging operations needed to push a Framebuffer to a module.
+type ColumnClient interface {
+	StageColumn(col byte, pixels [34]byte) error
+	FlushColumns() error
+}
+
+// Surface is a greyscale drawing target, either a single module's Framebuffer or a Canvas
+// spanning several modules.
+type Surface interface {
+	Size() (width, height int)
+	SetPixel(x, y int, value byte)
+}
+
+// Framebuffer is a 34x9 greyscale canvas with 8-bit brightness per pixel.
+// It remembers what was last pushed so that Push only stages columns that changed.
+type Framebuffer struct {
+	pixels [FramebufferHeight][FramebufferWidth]byte
+	pushed [FramebufferHeight][FramebufferWidth]byte
+	synced bool
+}
+
+// NewFramebuffer creates a new Framebuffer with all pixels off.
+func NewFramebuffer() *Framebuffer {
+	return &Framebuffer{}
+}
+
+func inBounds(x, y int) bool {
+	return x >= 0 && x < FramebufferWidth && y >= 0 && y < FramebufferHeight
+}
+
+// Size returns the framebuffer dimensions.
+func (fb *Framebuffer) Size() (int, int) {
+	return FramebufferWidth, FramebufferHeight
+}
+
+// SetPixel sets the brightness of the pixel at (x, y). Coordinates outside the canvas are ignored.
+func (fb *Framebuffer) SetPixel(x, y int, value byte) {
+	if !inBounds(x, y) {
+		return
+	}
+
+	fb.pixels[y][x] = value
+}
+
+// Pixel returns the brightness of the pixel at (x, y), or 0 if the coordinates are outside the canvas.
+func (fb *Framebuffer) Pixel(x, y int) byte {
+	if !inBounds(x, y) {
+		return 0
+	}
+
+	return fb.pixels[y][x]
+}
+
+// Clear turns all pixels off.
+func (fb *Framebuffer) Clear() {
+	fb.Fill(0)
+}
+
+// Fill sets every pixel to the given brightness.
+func (fb *Framebuffer) Fill(value byte) {
+	for y := range fb.pixels {
+		for x := range fb.pixels[y] {
+			fb.pixels[y][x] = value
+		}
+	}
+}
+
+// Line draws a straight line from (x0, y0) to (x1, y1) inclusive using Bresenham's algorithm.
+func (fb *Framebuffer) Line(x0, y0, x1, y1 int, value byte) {
+	drawLine(fb, x0, y0, x1, y1, value)
+}
+
+func drawLine(s Surface, x0, y0, x1, y1 int, value byte) {
+	dx := absInt(x1 - x0)
+	dy := -absInt(y1 - y0)
+
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+
+	for {
+		s.SetPixel(x0, y0, value)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Rect draws the outline of a w x h rectangle with its top-left corner at (x, y).
+func (fb *Framebuffer) Rect(x, y, w, h int, value byte) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	fb.Line(x, y, x+w-1, y, value)
+	fb.Line(x, y+h-1, x+w-1, y+h-1, value)
+	fb.Line(x, y, x, y+h-1, value)
+	fb.Line(x+w-1, y, x+w-1, y+h-1, value)
+}
+
+// FillRect fills a w x h rectangle with its top-left corner at (x, y).
+func (fb *Framebuffer) FillRect(x, y, w, h int, value byte) {
+	fillRect(fb, x, y, w, h, value)
+}
+
+func fillRect(s Surface, x, y, w, h int, value byte) {
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			s.SetPixel(col, row, value)
+		}
+	}
+}
+
+// Blit copies a sprite given as rows of pixel values onto the canvas with its top-left corner at (x, y).
+// Parts of the sprite that fall outside the canvas are clipped.
+func (fb *Framebuffer) Blit(x, y int, sprite [][]byte) {
+	for row, pixels := range sprite {
+		for col, value := range pixels {
+			fb.SetPixel(x+col, y+row, value)
+		}
+	}
+}
+
+// CopyFrom replaces the contents of the canvas with the pixels of src.
+func (fb *Framebuffer) CopyFrom(src *Framebuffer) {
+	fb.pixels = src.pixels
+}
+
+// Blend sets every pixel to the value progress of the way from its value in from to its value
+// in to, where progress runs from 0 to 1.
+func (fb *Framebuffer) Blend(from, to *Framebuffer, progress float64) {
+	for y := range fb.pixels {
+		for x := range fb.pixels[y] {
+			a, b := float64(from.pixels[y][x]), float64(to.pixels[y][x])
+			fb.pixels[y][x] = byte(math.Round(a + (b-a)*progress))
+		}
+	}
+}
+
+// Equal reports whether both framebuffers hold the same pixels.
+func (fb *Framebuffer) Equal(other *Framebuffer) bool {
+	return fb.pixels == other.pixels
+}
+
+// Row returns a copy of the pixels in row y, which is also the payload of hardware column y.
+func (fb *Framebuffer) Row(y int) [FramebufferWidth]byte {
+	if y < 0 || y >= FramebufferHeight {
+		return [FramebufferWidth]byte{}
+	}
+
+	return fb.pixels[y]
+}
+
+// Invalidate forgets what was last pushed so that the next Push stages every column.
+// Use it after the module has displayed something else, such as a built-in pattern.
+func (fb *Framebuffer) Invalidate() {
+	fb.synced = false
+}
+
+// Push stages every column that changed since the last push and flushes them to the module.
+// Nothing is sent if the canvas is unchanged.
+func (fb *Framebuffer) Push(client ColumnClient) error {
+	staged := 0
+
+	for y := 0; y < FramebufferHeight; y++ {
+		if fb.synced && fb.pixels[y] == fb.pushed[y] {
+			continue
+		}
+
+		if err := client.StageColumn(byte(y), fb.pixels[y]); err != nil {
+			fb.synced = false
+
+			return fmt.Errorf("failed to stage column %d: %w", y, err)
+		}
+
+		fb.pushed[y] = fb.pixels[y]
+		staged++
+	}
+
+	if staged == 0 {
+		return nil
+	}
+
+	if err := client.FlushColumns(); err != nil {
+		fb.synced = false
+
+		return fmt.Errorf("failed to flush columns: %w", err)
+	}
+
+	fb.synced = true
+
+	return nil
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,279 @@
+package matrix
+
+import (
+	"errors"
+	"testing"
+)
+
+func countCommands(commands []Command, id byte) int {
+	count := 0
+
+	for _, cmd := range commands {
+		if cmd.ID == id {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestFramebufferSetPixel(t *testing.T) {
+	fb := NewFramebuffer()
+
+	fb.SetPixel(0, 0, 10)
+	fb.SetPixel(33, 8, 200)
+	fb.SetPixel(-1, 0, 255)
+	fb.SetPixel(34, 0, 255)
+	fb.SetPixel(0, 9, 255)
+
+	if fb.Pixel(0, 0) != 10 {
+		t.Errorf("Pixel(0, 0) = %d, want 10", fb.Pixel(0, 0))
+	}
+
+	if fb.Pixel(33, 8) != 200 {
+		t.Errorf("Pixel(33, 8) = %d, want 200", fb.Pixel(33, 8))
+	}
+
+	if fb.Pixel(34, 0) != 0 {
+		t.Errorf("Pixel(34, 0) = %d, want 0 for out of bounds", fb.Pixel(34, 0))
+	}
+}
+
+func TestFramebufferFillAndClear(t *testing.T) {
+	fb := NewFramebuffer()
+	fb.Fill(42)
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			if fb.Pixel(x, y) != 42 {
+				t.Fatalf("Fill() pixel (%d, %d) = %d, want 42", x, y, fb.Pixel(x, y))
+			}
+		}
+	}
+
+	fb.Clear()
+
+	if !fb.Equal(NewFramebuffer()) {
+		t.Error("Clear() should turn all pixels off")
+	}
+}
+
+func TestFramebufferLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		lit    [][2]int
+		x0, y0 int
+		x1, y1 int
+	}{
+		{"horizontal", [][2]int{{2, 3}, {3, 3}, {4, 3}, {5, 3}}, 2, 3, 5, 3},
+		{"vertical", [][2]int{{7, 0}, {7, 1}, {7, 2}}, 7, 0, 7, 2},
+		{"diagonal", [][2]int{{0, 0}, {1, 1}, {2, 2}, {3, 3}}, 0, 0, 3, 3},
+		{"reversed", [][2]int{{3, 1}, {4, 1}, {5, 1}}, 5, 1, 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb := NewFramebuffer()
+			fb.Line(tt.x0, tt.y0, tt.x1, tt.y1, 255)
+
+			expected := NewFramebuffer()
+			for _, p := range tt.lit {
+				expected.SetPixel(p[0], p[1], 255)
+			}
+
+			if !fb.Equal(expected) {
+				t.Errorf("Line(%d, %d, %d, %d) did not light the expected pixels", tt.x0, tt.y0, tt.x1, tt.y1)
+			}
+		})
+	}
+}
+
+func TestFramebufferRect(t *testing.T) {
+	fb := NewFramebuffer()
+	fb.Rect(1, 1, 4, 3, 255)
+
+	lit := 0
+
+	for y := 0; y < FramebufferHeight; y++ {
+		for x := 0; x < FramebufferWidth; x++ {
+			if fb.Pixel(x, y) != 0 {
+				lit++
+			}
+		}
+	}
+
+	// 4x3 outline has 10 pixels
+	if lit != 10 {
+		t.Errorf("Rect() lit %d pixels, want 10", lit)
+	}
+
+	if fb.Pixel(2, 2) != 0 {
+		t.Error("Rect() should not fill the interior")
+	}
+}
+
+func TestFramebufferFillRectClipping(t *testing.T) {
+	fb := NewFramebuffer()
+	fb.FillRect(30, 6, 10, 10, 7)
+
+	if fb.Pixel(33, 8) != 7 {
+		t.Errorf("FillRect() pixel (33, 8) = %d, want 7", fb.Pixel(33, 8))
+	}
+
+	if fb.Pixel(29, 8) != 0 {
+		t.Errorf("FillRect() pixel (29, 8) = %d, want 0", fb.Pixel(29, 8))
+	}
+}
+
+func TestFramebufferBlit(t *testing.T) {
+	fb := NewFramebuffer()
+	sprite := [][]byte{
+		{1, 2},
+		{3, 4},
+	}
+
+	fb.Blit(33, 7, sprite)
+
+	if fb.Pixel(33, 7) != 1 || fb.Pixel(33, 8) != 3 {
+		t.Errorf("Blit() copied wrong values: (33,7)=%d (33,8)=%d", fb.Pixel(33, 7), fb.Pixel(33, 8))
+	}
+
+	fb.Blit(-1, -1, sprite)
+
+	if fb.Pixel(0, 0) != 4 {
+		t.Errorf("Blit() with negative offset pixel (0, 0) = %d, want 4", fb.Pixel(0, 0))
+	}
+}
+
+func TestFramebufferPush(t *testing.T) {
+	mockClient := NewMockClient()
+	fb := NewFramebuffer()
+	fb.SetPixel(5, 2, 128)
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	commands := mockClient.GetCommands()
+	if got := countCommands(commands, CmdStageCol); got != FramebufferHeight {
+		t.Errorf("first Push() staged %d columns, want %d", got, FramebufferHeight)
+	}
+
+	if commands[len(commands)-1].ID != CmdFlushCols {
+		t.Error("Push() should end with a flush command")
+	}
+
+	stage := commands[2]
+	if stage.Params[0] != 2 || stage.Params[1+5] != 128 {
+		t.Errorf("Push() column 2 params = %v, want pixel 5 set to 128", stage.Params)
+	}
+}
+
+func TestFramebufferPushSkipsUnchangedColumns(t *testing.T) {
+	mockClient := NewMockClient()
+	fb := NewFramebuffer()
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	mockClient.ClearCommands()
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if got := len(mockClient.GetCommands()); got != 0 {
+		t.Errorf("Push() of unchanged frame sent %d commands, want 0", got)
+	}
+
+	fb.SetPixel(0, 4, 255)
+	fb.SetPixel(1, 6, 255)
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	commands := mockClient.GetCommands()
+	if got := countCommands(commands, CmdStageCol); got != 2 {
+		t.Errorf("Push() staged %d columns, want 2", got)
+	}
+
+	if got := countCommands(commands, CmdFlushCols); got != 1 {
+		t.Errorf("Push() flushed %d times, want 1", got)
+	}
+
+	mockClient.ClearCommands()
+	fb.Invalidate()
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if got := countCommands(mockClient.GetCommands(), CmdStageCol); got != FramebufferHeight {
+		t.Errorf("Push() after Invalidate() staged %d columns, want %d", got, FramebufferHeight)
+	}
+}
+
+func TestFramebufferPushErrorForcesFullPush(t *testing.T) {
+	mockClient := NewMockClient()
+	fb := NewFramebuffer()
+
+	mockClient.SetConnectionError(errors.New("write failed"))
+
+	if err := fb.Push(mockClient); err == nil {
+		t.Fatal("Push() should return error when staging fails")
+	}
+
+	mockClient.SetConnectionError(nil)
+
+	if err := fb.Push(mockClient); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if got := countCommands(mockClient.GetCommands(), CmdStageCol); got != FramebufferHeight {
+		t.Errorf("Push() after failure staged %d columns, want %d", got, FramebufferHeight)
+	}
+}
+
+func TestDisplayManagerDrawFrame(t *testing.T) {
+	mockClient := NewMockClient()
+	dm := NewDisplayManager(mockClient)
+
+	fb := NewFramebuffer()
+	fb.FillRect(0, 0, 10, 9, 255)
+
+	if err := dm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	if got := countCommands(mockClient.GetCommands(), CmdStageCol); got != FramebufferHeight {
+		t.Errorf("DrawFrame() staged %d columns, want %d", got, FramebufferHeight)
+	}
+
+	// Redrawing the same frame sends nothing
+	mockClient.ClearCommands()
+
+	if err := dm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	if got := len(mockClient.GetCommands()); got != 0 {
+		t.Errorf("DrawFrame() of identical frame sent %d commands, want 0", got)
+	}
+
+	// A built-in pattern replaces the frame on the module, so the next frame is sent in full
+	if err := dm.ShowStatus("warning"); err != nil {
+		t.Fatalf("ShowStatus() error = %v", err)
+	}
+
+	mockClient.ClearCommands()
+
+	if err := dm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	if got := countCommands(mockClient.GetCommands(), CmdStageCol); got != FramebufferHeight {
+		t.Errorf("DrawFrame() after pattern staged %d columns, want %d", got, FramebufferHeight)
+	}
+}
@@ -0,0 +1,499 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+)
+
+// Connection event kinds reported by a Supervisor.
+const (
+	EventDisconnected    = "disconnected"
+	EventReconnected     = "reconnected"
+	EventReconnectFailed = "reconnect_failed"
+)
+
+// Default reconnect backoff. The delay doubles after each failed attempt up to the maximum.
+const (
+	DefaultReconnectInitialDelay = 500 * time.Millisecond
+	DefaultReconnectMaxDelay     = 30 * time.Second
+)
+
+// ConnectionEvent reports a change in the connection to a supervised matrix.
+type ConnectionEvent struct {
+	Err         error            // Cause of a disconnect or failed attempt
+	Firmware    *FirmwareVersion // Version read on reconnecting; nil if the module did not report one
+	Matrix      string           // Name of the matrix
+	Kind        string           // EventDisconnected, EventReconnected or EventReconnectFailed
+	Port        string           // Port the matrix was on, or was found on when reconnected
+	Attempt     int              // Reconnect attempt number, starting at 1
+	NextAttempt time.Duration    // Delay before the next attempt after a failure
+}
+
+// Supervisor wraps a Client and reconnects it when the module goes away, for example when it
+// is unplugged or the port errors out after suspend and resume.
+//
+// A failed write or read closes the port. Each later command first tries to reconnect, at most
+// once per backoff delay, by finding the module again by VID 32AC and serial number. Once
+// reconnected, the last brightness and display state are replayed so the module shows what the
+// daemon last drew. Commands sent while disconnected fail without being queued.
+//
+// Brightness and greyscale pixel values are calibrated for the module as they are sent. The
+// uncalibrated commands are kept for replay, so a new calibration can be applied to what is shown.
+type Supervisor struct {
+	nextAttempt  time.Time
+	client       *Client
+	onEvent      func(ConnectionEvent)
+	calibration  *calibrationTables // Applied to every command sent; nil sends commands unchanged
+	discover     func() ([]*enumerator.PortDetails, error)
+	connect      func(portName string) error
+	now          func() time.Time
+	columns      map[byte]Command
+	brightness   *Command
+	animate      *Command
+	name         string
+	portName     string
+	serialNumber string
+	display      []Command
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	attempts     int
+	mu           sync.Mutex
+	connected    bool
+	closed       bool
+	asleep       bool
+}
+
+// NewSupervisor creates a Supervisor for a connected client. The module's serial number is
+// looked up from its port so the same module can be found again if it reappears elsewhere.
+func NewSupervisor(name string, client *Client) *Supervisor {
+	s := &Supervisor{
+		client:       client,
+		name:         name,
+		portName:     client.PortName(),
+		connected:    client.port != nil,
+		discover:     enumerator.GetDetailedPortsList,
+		connect:      client.Connect,
+		now:          time.Now,
+		columns:      make(map[byte]Command),
+		initialDelay: DefaultReconnectInitialDelay,
+		maxDelay:     DefaultReconnectMaxDelay,
+	}
+
+	s.serialNumber = s.lookupSerialNumber(s.portName)
+
+	return s
+}
+
+// SetBackoff sets the delay before the first reconnect attempt and the maximum delay between attempts.
+func (s *Supervisor) SetBackoff(initial, maxDelay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initialDelay = initial
+	s.maxDelay = maxDelay
+}
+
+// SetEventHandler sets the function called when the matrix disconnects, reconnects or fails to reconnect.
+func (s *Supervisor) SetEventHandler(handler func(ConnectionEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onEvent = handler
+}
+
+// Name returns the name of the supervised matrix.
+func (s *Supervisor) Name() string {
+	return s.name
+}
+
+// Client returns the supervised client.
+func (s *Supervisor) Client() *Client {
+	return s.client
+}
+
+// Connected reports whether the matrix is currently connected.
+func (s *Supervisor) Connected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connected
+}
+
+// Disconnect closes the port. The supervisor does not reconnect afterwards.
+func (s *Supervisor) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connected = false
+	s.closed = true
+
+	return s.client.Disconnect()
+}
+
+// SendCommand transmits a command, reconnecting first if the matrix was lost.
+func (s *Supervisor) SendCommand(cmd Command) error {
+	return s.do(func() error {
+		if err := s.client.SendCommand(s.calibration.apply(cmd)); err != nil {
+			return err
+		}
+
+		s.recordUnsafe(cmd)
+
+		return nil
+	})
+}
+
+// Flush waits until every queued command has been written, reconnecting first if the matrix was lost.
+func (s *Supervisor) Flush() error {
+	return s.do(s.client.Flush)
+}
+
+// GetVersion retrieves the firmware version, reconnecting first if the matrix was lost.
+func (s *Supervisor) GetVersion() ([]byte, error) {
+	var version []byte
+
+	err := s.do(func() error {
+		var err error
+
+		version, err = s.client.GetVersion()
+
+		return err
+	})
+
+	return version, err
+}
+
+// GetFirmwareVersion retrieves and decodes the firmware version, reconnecting first if the matrix was lost.
+func (s *Supervisor) GetFirmwareVersion() (FirmwareVersion, error) {
+	response, err := s.GetVersion()
+	if err != nil {
+		return FirmwareVersion{}, err
+	}
+
+	return ParseFirmwareVersion(response)
+}
+
+// do runs op against the client, reconnecting beforehand if needed and marking the matrix
+// disconnected if op fails. Events are delivered after the lock is released.
+func (s *Supervisor) do(op func() error) error {
+	s.mu.Lock()
+
+	var events []ConnectionEvent
+
+	err := s.ensureConnectedUnsafe(&events)
+	if err == nil {
+		// A full queue means the module is slow, not gone, so only the command is dropped
+		if err = op(); err != nil && !errors.Is(err, ErrQueueFull) {
+			s.lostUnsafe(err, &events)
+		}
+	}
+
+	handler := s.onEvent
+	s.mu.Unlock()
+
+	if handler != nil {
+		for _, event := range events {
+			handler(event)
+		}
+	}
+
+	return err
+}
+
+func (s *Supervisor) ensureConnectedUnsafe(events *[]ConnectionEvent) error {
+	if s.connected {
+		return nil
+	}
+
+	if s.closed {
+		return fmt.Errorf("matrix %s is disconnected", s.name)
+	}
+
+	if now := s.now(); now.Before(s.nextAttempt) {
+		wait := s.nextAttempt.Sub(now).Round(time.Millisecond)
+
+		return fmt.Errorf("matrix %s disconnected, next reconnect attempt in %v", s.name, wait)
+	}
+
+	s.attempts++
+
+	portName, err := s.locateUnsafe()
+	if err == nil {
+		err = s.connect(portName)
+	}
+
+	var firmware *FirmwareVersion
+
+	if err == nil {
+		s.connected = true
+		s.portName = portName
+		firmware = s.readFirmwareUnsafe()
+
+		err = s.replayUnsafe()
+		if err != nil {
+			s.connected = false
+			_ = s.client.Disconnect()
+		}
+	}
+
+	if err != nil {
+		delay := s.backoffUnsafe()
+		s.nextAttempt = s.now().Add(delay)
+		*events = append(*events, ConnectionEvent{
+			Kind:        EventReconnectFailed,
+			Matrix:      s.name,
+			Port:        portName,
+			Attempt:     s.attempts,
+			NextAttempt: delay,
+			Err:         err,
+		})
+
+		return fmt.Errorf("failed to reconnect matrix %s: %w", s.name, err)
+	}
+
+	*events = append(*events, ConnectionEvent{
+		Kind:     EventReconnected,
+		Matrix:   s.name,
+		Port:     portName,
+		Attempt:  s.attempts,
+		Firmware: firmware,
+	})
+	s.attempts = 0
+
+	return nil
+}
+
+// readFirmwareUnsafe reads the version of a module that has just reconnected, before anything is
+// replayed while no reply can be pending. The firmware may have been updated while it was away.
+func (s *Supervisor) readFirmwareUnsafe() *FirmwareVersion {
+	version, err := s.client.GetFirmwareVersion()
+	if err != nil {
+		logging.Warn("failed to read matrix firmware version", "matrix", s.name, "error", err)
+
+		return nil
+	}
+
+	return &version
+}
+
+// lostUnsafe closes the port after a failed write or read and schedules the first reconnect attempt.
+func (s *Supervisor) lostUnsafe(cause error, events *[]ConnectionEvent) {
+	if err := s.client.Disconnect(); err != nil {
+		logging.Debug("failed to close lost matrix port", "matrix", s.name, "error", err)
+	}
+
+	s.connected = false
+	s.attempts = 0
+	s.nextAttempt = s.now().Add(s.initialDelay)
+
+	*events = append(*events, ConnectionEvent{
+		Kind:        EventDisconnected,
+		Matrix:      s.name,
+		Port:        s.portName,
+		NextAttempt: s.initialDelay,
+		Err:         cause,
+	})
+}
+
+// backoffUnsafe returns the delay after the current failed attempt: the initial delay doubled
+// for each failed attempt, capped at the maximum delay.
+func (s *Supervisor) backoffUnsafe() time.Duration {
+	delay := s.initialDelay
+	for i := 0; i < s.attempts && delay < s.maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.maxDelay)
+}
+
+// locateUnsafe finds the port the module is on now. A module with a known serial number is
+// matched by it; otherwise the last port is reused if a Framework module is still there.
+func (s *Supervisor) locateUnsafe() (string, error) {
+	ports, err := s.discover()
+	if err != nil {
+		return "", fmt.Errorf("failed to enumerate ports: %w", err)
+	}
+
+	for _, port := range ports {
+		if !port.IsUSB || !strings.EqualFold(port.VID, FrameworkVID) {
+			continue
+		}
+
+		if s.serialNumber != "" {
+			if port.SerialNumber == s.serialNumber {
+				return port.Name, nil
+			}
+
+			continue
+		}
+
+		if port.Name == s.portName {
+			return port.Name, nil
+		}
+	}
+
+	if s.serialNumber != "" {
+		return "", fmt.Errorf("no Framework LED matrix with serial number %s found", s.serialNumber)
+	}
+
+	return "", fmt.Errorf("no Framework LED matrix found on %s", s.portName)
+}
+
+// lookupSerialNumber returns the USB serial number of the module on portName, or "" if unknown.
+func (s *Supervisor) lookupSerialNumber(portName string) string {
+	if portName == "" {
+		return ""
+	}
+
+	ports, err := s.discover()
+	if err != nil {
+		return ""
+	}
+
+	for _, port := range ports {
+		if port.Name == portName && strings.EqualFold(port.VID, FrameworkVID) {
+			return port.SerialNumber
+		}
+	}
+
+	return ""
+}
+
+// recordUnsafe remembers the state set by a command that was sent successfully so it can be
+// replayed after a reconnect.
+func (s *Supervisor) recordUnsafe(cmd Command) {
+	switch cmd.ID {
+	case CmdBrightness:
+		s.brightness = &cmd
+	case CmdAnimate:
+		s.animate = &cmd
+	case CmdSleep:
+		s.asleep = len(cmd.Params) > 0 && cmd.Params[0] != 0
+	case CmdPattern, CmdDrawBW:
+		s.display = []Command{cmd}
+		clear(s.columns)
+	case CmdStageCol:
+		if len(cmd.Params) > 0 {
+			s.columns[cmd.Params[0]] = cmd
+		}
+	case CmdFlushCols:
+		// The display manager only stages columns that changed, so replay every column seen
+		cols := make([]byte, 0, len(s.columns))
+		for col := range s.columns {
+			cols = append(cols, col)
+		}
+
+		sort.Slice(cols, func(i, j int) bool { return cols[i] < cols[j] })
+
+		s.display = s.display[:0]
+		for _, col := range cols {
+			s.display = append(s.display, s.columns[col])
+		}
+
+		s.display = append(s.display, cmd)
+	}
+}
+
+// replayUnsafe restores the last brightness, animation and display state on a reconnected module,
+// and puts it back to sleep if it was asleep.
+func (s *Supervisor) replayUnsafe() error {
+	var commands []Command
+
+	if s.brightness != nil {
+		commands = append(commands, *s.brightness)
+	}
+
+	if s.animate != nil {
+		commands = append(commands, *s.animate)
+	}
+
+	commands = append(commands, s.display...)
+
+	if s.asleep {
+		commands = append(commands, SleepCommand(true))
+	}
+
+	for _, cmd := range commands {
+		if err := s.client.SendCommand(s.calibration.apply(cmd)); err != nil {
+			return fmt.Errorf("failed to replay display state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetCalibration changes how brightness and pixel values are corrected for the module, and
+// redraws what it shows with the new calibration.
+func (s *Supervisor) SetCalibration(calibration Calibration) error {
+	tables := calibration.tables()
+
+	s.mu.Lock()
+	unchanged := (tables == nil && s.calibration == nil) ||
+		(tables != nil && s.calibration != nil && *tables == *s.calibration)
+	s.calibration = tables
+	s.mu.Unlock()
+
+	if unchanged {
+		return nil
+	}
+
+	return s.do(s.replayUnsafe)
+}
+
+// SetSleep puts the module to sleep, turning its LEDs off, or wakes it up.
+func (s *Supervisor) SetSleep(sleep bool) error {
+	return s.SendCommand(SleepCommand(sleep))
+}
+
+// SetBrightness sets the brightness level of the LED matrix (0-255).
+func (s *Supervisor) SetBrightness(level byte) error {
+	return s.SendCommand(BrightnessCommand(level))
+}
+
+// ShowPercentage displays a percentage value (0-100) on the LED matrix.
+func (s *Supervisor) ShowPercentage(percent byte) error {
+	return s.SendCommand(PercentageCommand(percent))
+}
+
+// ShowGradient displays a gradient pattern on the LED matrix.
+func (s *Supervisor) ShowGradient() error {
+	return s.SendCommand(GradientCommand())
+}
+
+// ShowZigZag displays a zigzag pattern on the LED matrix.
+func (s *Supervisor) ShowZigZag() error {
+	return s.SendCommand(ZigZagCommand())
+}
+
+// ShowFullBright illuminates all LEDs at maximum brightness.
+func (s *Supervisor) ShowFullBright() error {
+	return s.SendCommand(FullBrightCommand())
+}
+
+// SetAnimate enables or disables animation effects on the LED matrix.
+func (s *Supervisor) SetAnimate(enable bool) error {
+	return s.SendCommand(AnimateCommand(enable))
+}
+
+// DrawBitmap draws a black and white bitmap on the LED matrix using a 39-byte pixel array.
+func (s *Supervisor) DrawBitmap(pixels [39]byte) error {
+	return s.SendCommand(DrawBWCommand(pixels))
+}
+
+// StageColumn stages a column of pixels for display on the LED matrix.
+func (s *Supervisor) StageColumn(col byte, pixels [34]byte) error {
+	return s.SendCommand(StageColCommand(col, pixels))
+}
+
+// FlushColumns applies all staged columns to the LED matrix display.
+func (s *Supervisor) FlushColumns() error {
+	return s.SendCommand(FlushColsCommand())
+}
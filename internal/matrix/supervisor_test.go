@@ -0,0 +1,268 @@
+package matrix
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// supervisorHarness drives a Supervisor over mock ports with a fake clock and port list.
+type supervisorHarness struct {
+	now      time.Time
+	ports    []*enumerator.PortDetails
+	opened   map[string]*MockPort
+	events   []ConnectionEvent
+	connects []string
+}
+
+func newSupervisorHarness(t *testing.T) (*Supervisor, *supervisorHarness) {
+	t.Helper()
+
+	h := &supervisorHarness{
+		now:    time.Unix(0, 0),
+		opened: make(map[string]*MockPort),
+		ports: []*enumerator.PortDetails{
+			{Name: "/dev/ttyACM0", IsUSB: true, VID: FrameworkVID, PID: "0020", SerialNumber: "FRAKDEBZ0100000000"},
+		},
+	}
+
+	client := NewClient()
+	client.port = NewMockPort()
+	client.portName = "/dev/ttyACM0"
+	h.opened[client.portName] = client.port.(*MockPort)
+
+	s := NewSupervisor("left", client)
+	s.discover = func() ([]*enumerator.PortDetails, error) { return h.ports, nil }
+	s.now = func() time.Time { return h.now }
+	s.connect = func(portName string) error {
+		h.connects = append(h.connects, portName)
+
+		// The module answers the version request sent on reconnecting
+		port := NewMockPort()
+		port.SetReadData([]byte{0, 0x19, 0})
+		h.opened[portName] = port
+		client.port = port
+		client.portName = portName
+
+		return nil
+	}
+	s.serialNumber = s.lookupSerialNumber(client.portName)
+	s.SetEventHandler(func(event ConnectionEvent) { h.events = append(h.events, event) })
+
+	return s, h
+}
+
+// unplug makes writes to the current port fail and removes the module from the port list.
+func (h *supervisorHarness) unplug(s *Supervisor) {
+	s.client.port.(*MockPort).SetWriteError(errors.New("input/output error"))
+	h.ports = nil
+}
+
+func (h *supervisorHarness) lastEvent() ConnectionEvent {
+	if len(h.events) == 0 {
+		return ConnectionEvent{}
+	}
+
+	return h.events[len(h.events)-1]
+}
+
+func TestSupervisorReconnectsAndReplaysState(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	if s.serialNumber != "FRAKDEBZ0100000000" {
+		t.Fatalf("serial number = %q, want it looked up from the port", s.serialNumber)
+	}
+
+	// Brightness, then a full frame, then a frame that only changes column 1
+	_ = s.SetBrightness(120)
+	_ = s.StageColumn(0, [34]byte{1})
+	_ = s.StageColumn(1, [34]byte{2})
+	_ = s.FlushColumns()
+	_ = s.StageColumn(1, [34]byte{3})
+	_ = s.FlushColumns()
+
+	h.unplug(s)
+
+	if err := s.ShowZigZag(); err == nil {
+		t.Fatal("ShowZigZag() should fail when the write fails")
+	}
+
+	if s.Connected() || h.lastEvent().Kind != EventDisconnected {
+		t.Fatalf("supervisor should report the matrix as disconnected, events = %+v", h.events)
+	}
+
+	// Too early for the first attempt: no reconnect is tried
+	if err := s.FlushColumns(); err == nil || len(h.connects) != 0 {
+		t.Fatalf("FlushColumns() before the backoff delay error = %v, connects = %v", err, h.connects)
+	}
+
+	// After suspend and resume the module comes back on another port
+	h.ports = []*enumerator.PortDetails{
+		{Name: "/dev/ttyACM1", IsUSB: true, VID: FrameworkVID, SerialNumber: "FRAKDEBZ0100000000"},
+	}
+	h.now = h.now.Add(DefaultReconnectInitialDelay)
+
+	if err := s.ShowGradient(); err != nil {
+		t.Fatalf("ShowGradient() after replug error = %v", err)
+	}
+
+	if event := h.lastEvent(); event.Kind != EventReconnected || event.Port != "/dev/ttyACM1" || event.Attempt != 1 {
+		t.Errorf("last event = %+v, want reconnect on /dev/ttyACM1 at attempt 1", event)
+	}
+
+	if firmware := h.lastEvent().Firmware; firmware == nil || firmware.String() != "0.1.9" {
+		t.Errorf("reconnect event firmware = %v, want 0.1.9 read from the module", firmware)
+	}
+
+	var want []byte
+	for _, cmd := range []Command{
+		QueryCommand(CmdVersion),
+		BrightnessCommand(120),
+		StageColCommand(0, [34]byte{1}),
+		StageColCommand(1, [34]byte{3}),
+		FlushColsCommand(),
+		GradientCommand(),
+	} {
+		want = append(want, cmd.ToBytes()...)
+	}
+
+	if got := h.opened["/dev/ttyACM1"].GetWrittenData(); !bytes.Equal(got, want) {
+		t.Errorf("reconnected port received %v, want replayed state then the new command %v", got, want)
+	}
+}
+
+func TestSupervisorReplaysPattern(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	_ = s.ShowPercentage(42)
+
+	h.unplug(s)
+	_ = s.ShowPercentage(43)
+
+	h.ports = []*enumerator.PortDetails{
+		{Name: "/dev/ttyACM0", IsUSB: true, VID: FrameworkVID, SerialNumber: "FRAKDEBZ0100000000"},
+	}
+	h.now = h.now.Add(time.Minute)
+
+	if err := s.ShowPercentage(44); err != nil {
+		t.Fatalf("ShowPercentage() after replug error = %v", err)
+	}
+
+	var want []byte
+	for _, cmd := range []Command{QueryCommand(CmdVersion), PercentageCommand(42), PercentageCommand(44)} {
+		want = append(want, cmd.ToBytes()...)
+	}
+
+	if written := h.opened["/dev/ttyACM0"].GetWrittenData(); !bytes.Equal(written, want) {
+		t.Errorf("reconnected port received %v, want the last pattern replayed first", written)
+	}
+}
+
+func TestSupervisorReplaysSleep(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	_ = s.SetBrightness(50)
+	_ = s.SetSleep(true)
+
+	h.unplug(s)
+	_ = s.SetSleep(true)
+
+	h.ports = []*enumerator.PortDetails{
+		{Name: "/dev/ttyACM0", IsUSB: true, VID: FrameworkVID, SerialNumber: "FRAKDEBZ0100000000"},
+	}
+	h.now = h.now.Add(time.Minute)
+
+	if err := s.SetSleep(false); err != nil {
+		t.Fatalf("SetSleep() after replug error = %v", err)
+	}
+
+	var want []byte
+	for _, cmd := range []Command{QueryCommand(CmdVersion), BrightnessCommand(50), SleepCommand(true), SleepCommand(false)} {
+		want = append(want, cmd.ToBytes()...)
+	}
+
+	if written := h.opened["/dev/ttyACM0"].GetWrittenData(); !bytes.Equal(written, want) {
+		t.Errorf("reconnected port received %v, want the module put back to sleep before waking %v", written, want)
+	}
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+	s.SetBackoff(100*time.Millisecond, 300*time.Millisecond)
+
+	h.unplug(s)
+	_ = s.ShowZigZag()
+
+	want := []time.Duration{200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+
+	for i, delay := range want {
+		h.now = h.now.Add(time.Second)
+
+		if err := s.ShowZigZag(); err == nil {
+			t.Fatalf("attempt %d: ShowZigZag() should fail while the module is missing", i+1)
+		}
+
+		event := h.lastEvent()
+		if event.Kind != EventReconnectFailed || event.Attempt != i+1 || event.NextAttempt != delay {
+			t.Errorf("attempt %d: event = %+v, want failure with next attempt in %v", i+1, event, delay)
+		}
+	}
+}
+
+func TestSupervisorMatchesSerialNumber(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	h.unplug(s)
+	_ = s.ShowZigZag()
+
+	// The other module of a dual setup is listed first and must not be picked
+	h.ports = []*enumerator.PortDetails{
+		{Name: "/dev/ttyACM0", IsUSB: true, VID: FrameworkVID, SerialNumber: "FRAKDEBZ0100000001"},
+		{Name: "/dev/ttyUSB0", IsUSB: true, VID: "0403", SerialNumber: "FRAKDEBZ0100000000"},
+		{Name: "/dev/ttyACM2", IsUSB: true, VID: "32ac", SerialNumber: "FRAKDEBZ0100000000"},
+	}
+	h.now = h.now.Add(time.Minute)
+
+	if err := s.ShowZigZag(); err != nil {
+		t.Fatalf("ShowZigZag() error = %v", err)
+	}
+
+	if len(h.connects) != 1 || h.connects[0] != "/dev/ttyACM2" {
+		t.Errorf("connects = %v, want the module with the matching serial number", h.connects)
+	}
+}
+
+func TestSupervisorDisconnectStopsReconnecting(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	if err := s.Disconnect(); err != nil {
+		t.Fatalf("Disconnect() error = %v", err)
+	}
+
+	h.now = h.now.Add(time.Minute)
+
+	if err := s.ShowZigZag(); err == nil {
+		t.Error("ShowZigZag() should fail after Disconnect()")
+	}
+
+	if len(h.connects) != 0 || len(h.events) != 0 {
+		t.Errorf("supervisor reconnected after Disconnect(): connects = %v, events = %v", h.connects, h.events)
+	}
+}
+
+func TestMultiDisplayManagerUsesSupervisors(t *testing.T) {
+	mc := NewMultiClient()
+	client := NewClient()
+	client.port = NewMockPort()
+	mc.clients["left"] = client
+	mc.supervisors["left"] = NewSupervisor("left", client)
+
+	mdm := NewMultiDisplayManager(mc, "mirror")
+
+	if _, ok := mdm.displays["left"].client.(*Supervisor); !ok {
+		t.Error("NewMultiDisplayManager() should draw through the matrix's supervisor")
+	}
+}
@@ -0,0 +1,268 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+)
+
+// transitionInterval is how often running transitions are advanced. Each step of a crossfade
+// restages the columns that changed, so it is kept well within what a module can take.
+const transitionInterval = 50 * time.Millisecond
+
+// Easing maps the fraction of a transition's duration that has passed, from 0 to 1, to how far
+// the transition has got, also from 0 to 1.
+type Easing func(t float64) float64
+
+// Easing curves.
+var (
+	EaseLinear Easing = func(t float64) float64 { return t }
+	EaseIn     Easing = func(t float64) float64 { return t * t }
+	EaseOut    Easing = func(t float64) float64 { return 1 - (1-t)*(1-t) }
+	EaseInOut  Easing = func(t float64) float64 { return t * t * (3 - 2*t) }
+)
+
+// easingNames names the easing curves for configuration.
+var easingNames = map[string]Easing{
+	"linear":      EaseLinear,
+	"ease-in":     EaseIn,
+	"ease-out":    EaseOut,
+	"ease-in-out": EaseInOut,
+}
+
+// ParseEasing returns the easing curve with the given name: linear, ease-in, ease-out or
+// ease-in-out. An empty name is ease-in-out.
+func ParseEasing(name string) (Easing, error) {
+	if name == "" {
+		return EaseInOut, nil
+	}
+
+	easing, ok := easingNames[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown easing %q", name)
+	}
+
+	return easing, nil
+}
+
+// Transition sets how a DisplayManager animates changes. With a zero Duration, changes are
+// shown at once.
+type Transition struct {
+	Easing   Easing // Nil is ease-in-out
+	Duration time.Duration
+}
+
+// Kinds of fade. Starting a fade replaces the running fade of the same kind.
+const (
+	fadeBrightness = "brightness" // Brightness changes, including the dip around a status pattern switch
+	fadeFrame      = "frame"      // Crossfades between framebuffer frames
+)
+
+// fade is a transition in progress. Its functions are called with the DisplayManager locked.
+type fade struct {
+	start     time.Time
+	easing    Easing
+	apply     func(progress float64) // Shows the state progress of the way through, after easing
+	interrupt func()                 // Called if the fade is replaced before it finishes; may be nil
+	duration  time.Duration
+}
+
+// SetTransition sets how brightness changes, status pattern switches and frames are animated.
+// Running transitions finish with the settings they started with.
+func (dm *DisplayManager) SetTransition(transition Transition) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if transition.Easing == nil {
+		transition.Easing = EaseInOut
+	}
+
+	dm.transition = transition
+}
+
+// CompleteTransitions jumps every running transition to its end, for example before the module
+// is switched off or disconnected.
+func (dm *DisplayManager) CompleteTransitions() {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for kind, f := range dm.fades {
+		f.apply(1)
+		delete(dm.fades, kind)
+	}
+}
+
+func (dm *DisplayManager) transitionsEnabledUnsafe() bool {
+	return dm.transition.Duration > 0
+}
+
+// startFadeUnsafe replaces any running fade of the same kind with f, and starts the goroutine
+// that advances fades if it isn't running.
+func (dm *DisplayManager) startFadeUnsafe(kind string, f *fade) {
+	dm.cancelFadeUnsafe(kind)
+
+	f.start = dm.now()
+	f.easing = dm.transition.Easing
+	f.duration = dm.transition.Duration
+	dm.fades[kind] = f
+
+	if !dm.fading {
+		dm.fading = true
+
+		go dm.runFades()
+	}
+}
+
+// cancelFadeUnsafe stops the running fade of the given kind where it is.
+func (dm *DisplayManager) cancelFadeUnsafe(kind string) {
+	f, ok := dm.fades[kind]
+	if !ok {
+		return
+	}
+
+	delete(dm.fades, kind)
+
+	if f.interrupt != nil {
+		f.interrupt()
+	}
+}
+
+// runFades advances fades on their own timer until none are left.
+func (dm *DisplayManager) runFades() {
+	ticker := time.NewTicker(transitionInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !dm.stepFades() {
+			return
+		}
+	}
+}
+
+// stepFades shows every running fade as of now, removes the finished ones and reports whether
+// any are left.
+func (dm *DisplayManager) stepFades() bool {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	now := dm.now()
+
+	for kind, f := range dm.fades {
+		t := 1.0
+		if f.duration > 0 {
+			t = min(max(float64(now.Sub(f.start))/float64(f.duration), 0), 1)
+		}
+
+		f.apply(f.easing(t))
+
+		if t >= 1 {
+			delete(dm.fades, kind)
+		}
+	}
+
+	if len(dm.fades) == 0 {
+		dm.fading = false
+
+		return false
+	}
+
+	return true
+}
+
+// fadeBrightnessUnsafe ramps the brightness from its current level to level.
+func (dm *DisplayManager) fadeBrightnessUnsafe(level byte) {
+	from := dm.brightness
+
+	dm.startFadeUnsafe(fadeBrightness, &fade{apply: func(p float64) {
+		dm.applyBrightnessUnsafe(lerp(from, int(level), p))
+	}})
+}
+
+// dipUnsafe fades the brightness out, calls show to switch what the module displays, and fades
+// back in to the target brightness.
+func (dm *DisplayManager) dipUnsafe(show func() error) {
+	from := dm.brightness
+	to := dm.targetBrightnessUnsafe()
+	switched := false
+
+	switchDisplay := func() {
+		if switched {
+			return
+		}
+
+		switched = true
+
+		dm.cancelFadeUnsafe(fadeFrame)
+
+		if err := show(); err != nil {
+			logging.Warn("failed to switch display during transition", "error", err)
+		}
+
+		dm.patternShownUnsafe()
+	}
+
+	dm.startFadeUnsafe(fadeBrightness, &fade{
+		apply: func(p float64) {
+			if p < 0.5 {
+				dm.applyBrightnessUnsafe(lerp(from, 0, 2*p))
+
+				return
+			}
+
+			switchDisplay()
+			dm.applyBrightnessUnsafe(lerp(0, to, 2*p-1))
+		},
+		interrupt: switchDisplay,
+	})
+}
+
+// targetBrightnessUnsafe returns the brightness last asked for, which a running fade may not
+// have reached yet.
+func (dm *DisplayManager) targetBrightnessUnsafe() int {
+	if level, ok := dm.currentState["brightness"].(byte); ok {
+		return int(level)
+	}
+
+	return dm.brightness
+}
+
+// applyBrightnessUnsafe sends a brightness level reached by a fade, if it changed.
+func (dm *DisplayManager) applyBrightnessUnsafe(level int) {
+	if level == dm.brightness {
+		return
+	}
+
+	if err := dm.client.SetBrightness(byte(level)); err != nil {
+		logging.Warn("failed to set brightness during transition", "error", err)
+
+		return
+	}
+
+	dm.brightness = level
+}
+
+// crossfadeUnsafe fades from the frame shown, or from black if a pattern is shown, to target.
+func (dm *DisplayManager) crossfadeUnsafe(target *Framebuffer) {
+	from := NewFramebuffer()
+	if dm.frameShown {
+		from.CopyFrom(dm.frame)
+	}
+
+	to := NewFramebuffer()
+	to.CopyFrom(target)
+
+	dm.startFadeUnsafe(fadeFrame, &fade{apply: func(p float64) {
+		dm.frame.Blend(from, to, p)
+
+		if err := dm.pushFrameUnsafe(); err != nil {
+			logging.Warn("failed to draw frame during transition", "error", err)
+		}
+	}})
+}
+
+// lerp returns the integer progress of the way from a to b.
+func lerp(a, b int, progress float64) int {
+	return a + int(math.Round(float64(b-a)*progress))
+}
@@ -0,0 +1,276 @@
+package matrix
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// newFadingDisplay returns a DisplayManager at brightness 100 with one-second linear transitions
+// timed by the returned clock.
+func newFadingDisplay(t *testing.T) (*DisplayManager, *MockClient, *fakeClock) {
+	t.Helper()
+
+	client := NewMockClient()
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	dm := NewDisplayManager(client)
+	dm.now = clock.Now
+
+	if err := dm.SetBrightness(100); err != nil {
+		t.Fatalf("SetBrightness() error = %v", err)
+	}
+
+	dm.SetTransition(Transition{Easing: EaseLinear, Duration: time.Second})
+	client.ClearCommands()
+
+	return dm, client, clock
+}
+
+// lastStagedPixel returns the first pixel of the last column staged for row y, or -1 if none was.
+func lastStagedPixel(commands []Command, y byte) int {
+	pixel := -1
+
+	for _, cmd := range commands {
+		if cmd.ID == CmdStageCol && cmd.Params[0] == y {
+			pixel = int(cmd.Params[1])
+		}
+	}
+
+	return pixel
+}
+
+func TestParseEasing(t *testing.T) {
+	for _, name := range []string{"", "linear", "ease-in", "ease-out", "ease-in-out"} {
+		easing, err := ParseEasing(name)
+		if err != nil {
+			t.Errorf("ParseEasing(%q) error = %v", name, err)
+
+			continue
+		}
+
+		if easing(0) != 0 || easing(1) != 1 {
+			t.Errorf("ParseEasing(%q) runs from %v to %v, want 0 to 1", name, easing(0), easing(1))
+		}
+	}
+
+	if EaseIn(0.5) >= 0.5 || EaseOut(0.5) <= 0.5 || EaseInOut(0.5) != 0.5 {
+		t.Error("easing curves have the wrong shape at the midpoint")
+	}
+
+	if _, err := ParseEasing("bounce"); err == nil {
+		t.Error("ParseEasing() succeeded for an unknown easing, want an error")
+	}
+}
+
+func TestSetBrightnessFades(t *testing.T) {
+	dm, client, clock := newFadingDisplay(t)
+
+	if err := dm.SetBrightness(200); err != nil {
+		t.Fatalf("SetBrightness() error = %v", err)
+	}
+
+	if state := dm.GetCurrentState(); state["brightness"] != byte(200) {
+		t.Errorf("brightness state = %v, want the target 200 at once", state["brightness"])
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	dm.stepFades()
+
+	if client.GetBrightness() != 150 {
+		t.Errorf("brightness halfway = %d, want 150", client.GetBrightness())
+	}
+
+	clock.Advance(500 * time.Millisecond)
+
+	if dm.stepFades() {
+		t.Error("stepFades() reported fades left after the transition finished")
+	}
+
+	if client.GetBrightness() != 200 {
+		t.Errorf("brightness at the end = %d, want 200", client.GetBrightness())
+	}
+}
+
+func TestSetBrightnessRetargetsRunningFade(t *testing.T) {
+	dm, client, clock := newFadingDisplay(t)
+
+	_ = dm.SetBrightness(200)
+
+	clock.Advance(500 * time.Millisecond)
+	dm.stepFades()
+
+	// Fading down starts from wherever the first fade had got to
+	_ = dm.SetBrightness(0)
+
+	clock.Advance(500 * time.Millisecond)
+	dm.stepFades()
+
+	if client.GetBrightness() != 75 {
+		t.Errorf("brightness = %d, want 75 halfway from 150 to 0", client.GetBrightness())
+	}
+}
+
+func TestShowStatusDipsBetweenPatterns(t *testing.T) {
+	dm, client, clock := newFadingDisplay(t)
+	dm.SetTransition(Transition{})
+
+	_ = dm.ShowStatus("normal")
+
+	dm.SetTransition(Transition{Easing: EaseLinear, Duration: time.Second})
+
+	if err := dm.ShowStatus("critical"); err != nil {
+		t.Fatalf("ShowStatus() error = %v", err)
+	}
+
+	clock.Advance(250 * time.Millisecond)
+	dm.stepFades()
+
+	if client.GetBrightness() != 50 || client.GetLastPattern() != "gradient" {
+		t.Errorf("a quarter through: brightness %d, pattern %s; want 50, gradient",
+			client.GetBrightness(), client.GetLastPattern())
+	}
+
+	clock.Advance(350 * time.Millisecond)
+	dm.stepFades()
+
+	if client.GetBrightness() != 20 || client.GetLastPattern() != "fullbright" {
+		t.Errorf("after the switch: brightness %d, pattern %s; want 20, fullbright",
+			client.GetBrightness(), client.GetLastPattern())
+	}
+
+	clock.Advance(400 * time.Millisecond)
+	dm.stepFades()
+
+	if client.GetBrightness() != 100 {
+		t.Errorf("brightness at the end = %d, want 100", client.GetBrightness())
+	}
+}
+
+func TestInterruptedDipStillSwitchesPattern(t *testing.T) {
+	dm, client, clock := newFadingDisplay(t)
+
+	_ = dm.ShowStatus("warning")
+
+	clock.Advance(100 * time.Millisecond)
+	dm.stepFades()
+
+	_ = dm.SetBrightness(40)
+
+	if client.GetLastPattern() != "zigzag" {
+		t.Errorf("pattern = %q after interrupting the dip, want zigzag", client.GetLastPattern())
+	}
+
+	dm.CompleteTransitions()
+
+	if client.GetBrightness() != 40 {
+		t.Errorf("brightness = %d, want 40", client.GetBrightness())
+	}
+}
+
+func TestDrawFrameCrossfades(t *testing.T) {
+	dm, client, clock := newFadingDisplay(t)
+
+	fb := NewFramebuffer()
+	fb.SetPixel(0, 0, 200)
+
+	if err := dm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	// A frame shown after a pattern fades in from black
+	clock.Advance(500 * time.Millisecond)
+	dm.stepFades()
+
+	if got := lastStagedPixel(client.GetCommands(), 0); got != 100 {
+		t.Errorf("pixel halfway in = %d, want 100", got)
+	}
+
+	dm.CompleteTransitions()
+
+	next := NewFramebuffer()
+	next.SetPixel(0, 0, 0)
+
+	_ = dm.DrawFrame(next)
+
+	clock.Advance(250 * time.Millisecond)
+	dm.stepFades()
+
+	if got := lastStagedPixel(client.GetCommands(), 0); got != 150 {
+		t.Errorf("pixel a quarter through = %d, want 150", got)
+	}
+}
+
+func TestDrawFrameNowSkipsTransition(t *testing.T) {
+	dm, client, _ := newFadingDisplay(t)
+
+	fb := NewFramebuffer()
+	fb.SetPixel(0, 0, 200)
+
+	_ = dm.DrawFrame(fb)
+
+	next := NewFramebuffer()
+	next.SetPixel(0, 0, 50)
+
+	if err := dm.DrawFrameNow(next); err != nil {
+		t.Fatalf("DrawFrameNow() error = %v", err)
+	}
+
+	if got := lastStagedPixel(client.GetCommands(), 0); got != 50 {
+		t.Errorf("pixel = %d, want 50 at once", got)
+	}
+
+	if dm.stepFades() {
+		t.Error("DrawFrameNow() should cancel the running crossfade")
+	}
+}
+
+func TestCompleteTransitions(t *testing.T) {
+	dm, client, _ := newFadingDisplay(t)
+
+	if err := dm.ShowStatus("off"); err != nil {
+		t.Fatalf("ShowStatus() error = %v", err)
+	}
+
+	dm.CompleteTransitions()
+
+	if client.GetBrightness() != 0 {
+		t.Errorf("brightness = %d, want 0 once transitions are complete", client.GetBrightness())
+	}
+
+	if dm.stepFades() {
+		t.Error("stepFades() reported fades left after CompleteTransitions()")
+	}
+}
+
+func TestTransitionsOffByDefault(t *testing.T) {
+	client := NewMockClient()
+	dm := NewDisplayManager(client)
+
+	_ = dm.SetBrightness(100)
+	_ = dm.SetBrightness(10)
+
+	if client.GetBrightness() != 10 {
+		t.Errorf("brightness = %d, want 10 at once without a transition", client.GetBrightness())
+	}
+}
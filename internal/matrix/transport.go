@@ -0,0 +1,30 @@
+package matrix
+
+import (
+	"io"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Transport is the byte stream to a matrix module. A serial port is the usual transport;
+// VirtualMatrix provides one that emulates a module in process.
+type Transport interface {
+	io.ReadWriteCloser
+	SetReadTimeout(timeout time.Duration) error
+}
+
+// Opener opens the transport to the module on the named port.
+type Opener func(portName string) (Transport, error)
+
+// SerialOpener returns an Opener that opens serial ports in the given mode.
+func SerialOpener(mode *serial.Mode) Opener {
+	return func(portName string) (Transport, error) {
+		port, err := serial.Open(portName, mode)
+		if err != nil {
+			return nil, err
+		}
+
+		return port, nil
+	}
+}
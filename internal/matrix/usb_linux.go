@@ -0,0 +1,42 @@
+package matrix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysClassTTY is where Linux lists tty devices; replaced in tests.
+var sysClassTTY = "/sys/class/tty"
+
+// usbLocation returns the USB topology path of the device behind a serial port, such as "3-4.1",
+// or "" if the port is not a USB device.
+func usbLocation(portName string) string {
+	// The tty's device link points at the USB interface, named after its device as "3-4.1:1.0"
+	iface, err := filepath.EvalSymlinks(filepath.Join(sysClassTTY, filepath.Base(portName), "device"))
+	if err != nil {
+		return ""
+	}
+
+	location, _, found := strings.Cut(filepath.Base(iface), ":")
+	if !found {
+		return ""
+	}
+
+	return location
+}
+
+// usbProduct returns the product string of the USB device behind a serial port, or "" if unknown.
+func usbProduct(portName string) string {
+	iface, err := filepath.EvalSymlinks(filepath.Join(sysClassTTY, filepath.Base(portName), "device"))
+	if err != nil {
+		return ""
+	}
+
+	product, err := os.ReadFile(filepath.Join(filepath.Dir(iface), "product"))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(product))
+}
@@ -0,0 +1,46 @@
+package matrix
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUSBAttributes(t *testing.T) {
+	root := t.TempDir()
+
+	iface := filepath.Join(root, "devices", "usb3", "3-4", "3-4.1", "3-4.1:1.0")
+	if err := os.MkdirAll(iface, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tty := filepath.Join(root, "class", "tty", "ttyACM0")
+	if err := os.MkdirAll(tty, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(iface, filepath.Join(tty, "device")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(iface), "product"), []byte("LED Matrix Input Module\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := sysClassTTY
+	sysClassTTY = filepath.Join(root, "class", "tty")
+
+	t.Cleanup(func() { sysClassTTY = original })
+
+	if got := usbLocation("/dev/ttyACM0"); got != "3-4.1" {
+		t.Errorf("usbLocation() = %q, want 3-4.1", got)
+	}
+
+	if got := usbLocation("/dev/ttyS0"); got != "" {
+		t.Errorf("usbLocation() = %q for a port with no device, want empty", got)
+	}
+
+	if got := usbProduct("/dev/ttyACM0"); got != "LED Matrix Input Module" {
+		t.Errorf("usbProduct() = %q, want LED Matrix Input Module", got)
+	}
+}
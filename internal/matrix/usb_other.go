@@ -0,0 +1,13 @@
+//go:build !linux
+
+package matrix
+
+// usbLocation returns "" because USB locations are only looked up on Linux.
+func usbLocation(string) string {
+	return ""
+}
+
+// usbProduct returns "" because enumerator reports the product string where it is known.
+func usbProduct(string) string {
+	return ""
+}
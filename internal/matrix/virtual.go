@@ -0,0 +1,361 @@
+package matrix
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultVirtualVersion is the firmware version a VirtualMatrix reports until SetVersion is called.
+var defaultVirtualVersion = [VersionResponseSize]byte{0, 0x19, 0}
+
+// VirtualMatrix emulates a Framework LED matrix module in process. It decodes the command stream
+// written to it the way the firmware does and keeps the resulting state, so the client and the
+// whole daemon can be run without hardware and checked for exactly which LEDs are lit.
+//
+// Writes are decoded as a byte stream with DecodeCommand, so the emulator can sit behind a pipe
+// or pseudo-terminal as well as a Client. Only the percentage and full-bright patterns are
+// rendered into pixels; other patterns are reported by Pattern and leave the LEDs dark.
+type VirtualMatrix struct {
+	pattern    []byte
+	pending    []byte
+	response   []byte
+	staged     [FramebufferHeight][FramebufferWidth]byte
+	display    [FramebufferHeight][FramebufferWidth]byte
+	commands   int
+	period     time.Duration
+	mu         sync.Mutex
+	version    [VersionResponseSize]byte
+	brightness byte
+	pwm        PWMFrequency
+	animate    bool
+	sleeping   bool
+	debug      bool
+	bootloader bool
+	closed     bool
+}
+
+// NewVirtualMatrix creates a VirtualMatrix that is powered on with all LEDs dark.
+func NewVirtualMatrix() *VirtualMatrix {
+	return &VirtualMatrix{
+		brightness: 255,
+		version:    defaultVirtualVersion,
+	}
+}
+
+// VirtualOpener returns an Opener that opens the given virtual matrices by port name, so a
+// Client or MultiClient can connect to them as if they were serial ports.
+func VirtualOpener(matrices map[string]*VirtualMatrix) Opener {
+	return func(portName string) (Transport, error) {
+		vm, ok := matrices[portName]
+		if !ok {
+			return nil, fmt.Errorf("no virtual matrix on port %s", portName)
+		}
+
+		vm.mu.Lock()
+		vm.closed = false
+		vm.bootloader = false
+		vm.mu.Unlock()
+
+		return vm, nil
+	}
+}
+
+// Write decodes and applies the commands in data. A command cut off partway through its
+// parameters is completed by the next Write. Bytes that don't start a known command are skipped.
+func (vm *VirtualMatrix) Write(data []byte) (int, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	if vm.closed {
+		return 0, fmt.Errorf("virtual matrix port is closed")
+	}
+
+	vm.pending = append(vm.pending, data...)
+
+	for len(vm.pending) > 0 {
+		cmd, n, err := DecodeCommand(vm.pending)
+		if errors.Is(err, ErrShortCommand) {
+			break
+		}
+
+		if err != nil {
+			vm.pending = vm.pending[1:]
+
+			continue
+		}
+
+		vm.pending = vm.pending[n:]
+		vm.applyUnsafe(cmd.ID, cmd.Params)
+	}
+
+	return len(data), nil
+}
+
+// Read returns the reply to the last query. With no reply pending it returns no data, as a
+// serial port does when its read timeout expires.
+func (vm *VirtualMatrix) Read(buffer []byte) (int, error) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	if vm.closed {
+		return 0, fmt.Errorf("virtual matrix port is closed")
+	}
+
+	n := copy(buffer, vm.response)
+	vm.response = vm.response[n:]
+
+	return n, nil
+}
+
+// Close closes the port. The emulated module keeps its state and can be opened again.
+func (vm *VirtualMatrix) Close() error {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	vm.closed = true
+	vm.pending = nil
+
+	return nil
+}
+
+// SetReadTimeout is a no-op: reads never block.
+func (vm *VirtualMatrix) SetReadTimeout(_ time.Duration) error {
+	return nil
+}
+
+func (vm *VirtualMatrix) applyUnsafe(id byte, params []byte) {
+	vm.commands++
+
+	// The firmware wakes on any command but sleep and bootloader, queries included
+	if id != CmdSleep && id != CmdBootloader {
+		vm.sleeping = false
+	}
+
+	switch id {
+	case CmdBrightness:
+		if len(params) == 0 {
+			vm.response = append(vm.response, vm.brightness)
+
+			return
+		}
+
+		vm.brightness = params[0]
+	case CmdPattern:
+		if len(params) > 0 {
+			vm.showPatternUnsafe(params)
+		}
+	case CmdSleep:
+		if len(params) == 0 {
+			vm.response = append(vm.response, boolByte(vm.sleeping))
+
+			return
+		}
+
+		vm.sleeping = params[0] != 0
+	case CmdAnimate:
+		if len(params) == 0 {
+			vm.response = append(vm.response, boolByte(vm.animate))
+
+			return
+		}
+
+		vm.animate = params[0] != 0
+	case CmdAnimationPeriod:
+		if len(params) < 2 {
+			vm.response = binary.LittleEndian.AppendUint16(vm.response, uint16(vm.period.Milliseconds()))
+
+			return
+		}
+
+		vm.period = time.Duration(binary.LittleEndian.Uint16(params)) * time.Millisecond
+	case CmdPWMFrequency:
+		if len(params) == 0 {
+			vm.response = append(vm.response, byte(vm.pwm))
+
+			return
+		}
+
+		vm.pwm = PWMFrequency(params[0])
+	case CmdDebugMode:
+		if len(params) == 0 {
+			vm.response = append(vm.response, boolByte(vm.debug))
+
+			return
+		}
+
+		vm.debug = params[0] != 0
+	case CmdBootloader:
+		// The module drops off the bus to be flashed
+		vm.bootloader = true
+		vm.closed = true
+		vm.pending = nil
+	case CmdDrawBW:
+		if len(params) < DrawBWSize {
+			return
+		}
+
+		bitmap := UnpackBitmap([DrawBWSize]byte(params[:DrawBWSize]))
+		for y := range bitmap {
+			for x, lit := range bitmap[y] {
+				vm.display[y][x] = 0
+				if lit {
+					vm.display[y][x] = 0xFF
+				}
+			}
+		}
+
+		vm.pattern = nil
+	case CmdStageCol:
+		if len(params) < 1+FramebufferWidth || int(params[0]) >= FramebufferHeight {
+			return
+		}
+
+		copy(vm.staged[params[0]][:], params[1:])
+	case CmdFlushCols:
+		vm.display = vm.staged
+		vm.pattern = nil
+	case CmdVersion:
+		vm.response = append(vm.response, vm.version[:]...)
+	}
+}
+
+func (vm *VirtualMatrix) showPatternUnsafe(params []byte) {
+	vm.pattern = append([]byte(nil), params...)
+	vm.display = [FramebufferHeight][FramebufferWidth]byte{}
+
+	switch params[0] {
+	case PatternPercentage:
+		if len(params) < 2 {
+			return
+		}
+
+		// The firmware fills whole rows of the 34 LED columns up from the bottom
+		lit := FramebufferWidth * int(min(params[1], 100)) / 100
+		for y := range vm.display {
+			for x := FramebufferWidth - lit; x < FramebufferWidth; x++ {
+				vm.display[y][x] = 0xFF
+			}
+		}
+	case PatternFullBright:
+		for y := range vm.display {
+			for x := range vm.display[y] {
+				vm.display[y][x] = 0xFF
+			}
+		}
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// SetVersion sets the firmware version reported in reply to version queries.
+func (vm *VirtualMatrix) SetVersion(version [VersionResponseSize]byte) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	vm.version = version
+}
+
+// Brightness returns the global brightness last set.
+func (vm *VirtualMatrix) Brightness() byte {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.brightness
+}
+
+// Animating reports whether animation is enabled.
+func (vm *VirtualMatrix) Animating() bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.animate
+}
+
+// Sleeping reports whether the module has been put to sleep.
+func (vm *VirtualMatrix) Sleeping() bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.sleeping
+}
+
+// Pattern returns the pattern ID and parameters in effect, or nil if the display was last set
+// by drawing a bitmap or flushing columns.
+func (vm *VirtualMatrix) Pattern() []byte {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return append([]byte(nil), vm.pattern...)
+}
+
+// AnimationPeriod returns the animation frame period last set, or zero if it was never set.
+func (vm *VirtualMatrix) AnimationPeriod() time.Duration {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.period
+}
+
+// PWMFrequency returns the LED driver's PWM frequency.
+func (vm *VirtualMatrix) PWMFrequency() PWMFrequency {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.pwm
+}
+
+// DebugMode reports whether debug mode is enabled.
+func (vm *VirtualMatrix) DebugMode() bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.debug
+}
+
+// InBootloader reports whether the module was reset into its bootloader. It stays closed until
+// opened again, which stands in for flashing or power cycling it.
+func (vm *VirtualMatrix) InBootloader() bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.bootloader
+}
+
+// Commands returns the number of commands received.
+func (vm *VirtualMatrix) Commands() int {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	return vm.commands
+}
+
+// Frame returns the LEDs currently shown, in Framebuffer orientation.
+func (vm *VirtualMatrix) Frame() *Framebuffer {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	fb := NewFramebuffer()
+	fb.pixels = vm.display
+
+	return fb
+}
+
+// Staged returns the columns staged but not necessarily flushed, in Framebuffer orientation.
+func (vm *VirtualMatrix) Staged() *Framebuffer {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+
+	fb := NewFramebuffer()
+	fb.pixels = vm.staged
+
+	return fb
+}
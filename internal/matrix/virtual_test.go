@@ -0,0 +1,217 @@
+package matrix
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func connectVirtual(t *testing.T) (*Client, *VirtualMatrix) {
+	t.Helper()
+
+	vm := NewVirtualMatrix()
+	client := NewClient()
+	client.SetOpener(VirtualOpener(map[string]*VirtualMatrix{"virtual0": vm}))
+
+	if err := client.Connect("virtual0"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	return client, vm
+}
+
+func TestVirtualMatrixState(t *testing.T) {
+	client, vm := connectVirtual(t)
+
+	_ = client.SetBrightness(80)
+	_ = client.SetAnimate(true)
+	_ = client.SendCommand(SleepCommand(true))
+	_ = client.Flush()
+
+	if vm.Brightness() != 80 || !vm.Animating() || !vm.Sleeping() {
+		t.Errorf("brightness = %d, animating = %t, sleeping = %t", vm.Brightness(), vm.Animating(), vm.Sleeping())
+	}
+
+	// Query forms of the same commands reply with the current value
+	_ = client.SendCommand(NewCommand(CmdBrightness))
+
+	if got, err := client.ReadResponse(1); err != nil || !bytes.Equal(got, []byte{80}) {
+		t.Errorf("brightness query = %v, %v, want [80]", got, err)
+	}
+
+	if version, err := client.GetVersion(); err != nil || len(version) != VersionResponseSize {
+		t.Errorf("GetVersion() = %v, %v", version, err)
+	}
+
+	if vm.Commands() != 5 {
+		t.Errorf("Commands() = %d, want 5", vm.Commands())
+	}
+
+	if vm.Sleeping() {
+		t.Error("module still asleep after other commands, which wake the firmware")
+	}
+}
+
+func TestClientTypedQueries(t *testing.T) {
+	client, vm := connectVirtual(t)
+
+	_ = client.SetBrightness(33)
+	_ = client.SetAnimate(true)
+	_ = client.SetAnimationPeriod(250 * time.Millisecond)
+	_ = client.SetPWMFrequency(PWM1800Hz)
+	_ = client.SetDebugMode(true)
+
+	// Sleep last and query it first, since any other command wakes the module
+	_ = client.SetSleep(true)
+
+	if got, err := client.IsSleeping(); err != nil || !got {
+		t.Errorf("IsSleeping() = %t, %v, want true", got, err)
+	}
+
+	if got, err := client.GetBrightness(); err != nil || got != 33 {
+		t.Errorf("GetBrightness() = %d, %v, want 33", got, err)
+	}
+
+	if got, err := client.IsAnimating(); err != nil || !got {
+		t.Errorf("IsAnimating() = %t, %v, want true", got, err)
+	}
+
+	if got, err := client.GetAnimationPeriod(); err != nil || got != 250*time.Millisecond {
+		t.Errorf("GetAnimationPeriod() = %v, %v, want 250ms", got, err)
+	}
+
+	if got, err := client.GetPWMFrequency(); err != nil || got != PWM1800Hz {
+		t.Errorf("GetPWMFrequency() = %v, %v, want 1.8kHz", got, err)
+	}
+
+	if got, err := client.IsDebugMode(); err != nil || !got {
+		t.Errorf("IsDebugMode() = %t, %v, want true", got, err)
+	}
+
+	if err := client.ResetToBootloader(); err != nil || !vm.InBootloader() {
+		t.Errorf("ResetToBootloader() = %v, in bootloader = %t", err, vm.InBootloader())
+	}
+
+	if _, err := client.GetBrightness(); err == nil {
+		t.Error("GetBrightness() should fail once the module has left for the bootloader")
+	}
+}
+
+func TestClientReadResponseTimeout(t *testing.T) {
+	client, _ := connectVirtual(t)
+
+	// Nothing was asked, so the read times out with no data
+	if _, err := client.ReadResponse(1); err == nil {
+		t.Error("ReadResponse() should fail when the module sends nothing")
+	}
+}
+
+func TestVirtualMatrixPatterns(t *testing.T) {
+	client, vm := connectVirtual(t)
+
+	_ = client.ShowPercentage(50)
+	_ = client.Flush()
+
+	if got := vm.Pattern(); !bytes.Equal(got, []byte{PatternPercentage, 50}) {
+		t.Errorf("Pattern() = %v, want percentage 50", got)
+	}
+
+	frame := vm.Frame()
+	for y := 0; y < FramebufferHeight; y++ {
+		if frame.Pixel(16, y) != 0 || frame.Pixel(17, y) != 0xFF {
+			t.Fatalf("row %d: 50%% should light the bottom 17 LEDs of each column", y)
+		}
+	}
+
+	_ = client.ShowFullBright()
+	_ = client.Flush()
+
+	full := NewFramebuffer()
+	full.Fill(0xFF)
+
+	if !vm.Frame().Equal(full) {
+		t.Error("full bright pattern should light every LED")
+	}
+}
+
+func TestVirtualMatrixColumnsAndBitmap(t *testing.T) {
+	client, vm := connectVirtual(t)
+	dm := NewDisplayManager(client)
+
+	fb := NewFramebuffer()
+	fb.Line(0, 0, 33, 8, 200)
+
+	if err := dm.DrawFrame(fb); err != nil {
+		t.Fatalf("DrawFrame() error = %v", err)
+	}
+
+	_ = client.Flush()
+
+	if !vm.Frame().Equal(fb) || vm.Pattern() != nil {
+		t.Error("flushed columns should match the drawn frame")
+	}
+
+	// Staged columns are not shown until flushed
+	_ = client.StageColumn(0, [34]byte{1, 2, 3})
+	_ = client.Flush()
+
+	if vm.Frame().Pixel(0, 0) != 200 || vm.Staged().Pixel(2, 0) != 3 {
+		t.Error("staging a column should not change the display before a flush")
+	}
+
+	var bitmap Bitmap
+	bitmap[4][10] = true
+
+	_ = client.DrawBitmap(PackBitmap(bitmap))
+	_ = client.Flush()
+
+	want := NewFramebuffer()
+	want.SetPixel(10, 4, 0xFF)
+
+	if !vm.Frame().Equal(want) {
+		t.Error("DrawBitmap() should show exactly the lit bitmap pixels")
+	}
+}
+
+func TestVirtualMatrixClose(t *testing.T) {
+	client, vm := connectVirtual(t)
+
+	_ = client.SetBrightness(10)
+	_ = client.Disconnect()
+
+	if _, err := vm.Write(BrightnessCommand(20).ToBytes()); err == nil {
+		t.Error("Write() should fail once the port is closed")
+	}
+
+	if err := client.Connect("virtual0"); err != nil {
+		t.Fatalf("reconnect error = %v", err)
+	}
+
+	if err := client.SetBrightness(30); err != nil || client.Flush() != nil || vm.Brightness() != 30 {
+		t.Errorf("SetBrightness() after reopening = %v, brightness %d", err, vm.Brightness())
+	}
+
+	if err := client.Connect("virtual1"); err == nil {
+		t.Error("Connect() should fail for a port with no virtual matrix")
+	}
+}
+
+func TestVirtualMatrixStream(t *testing.T) {
+	vm := NewVirtualMatrix()
+
+	// Noise, then a column split across writes, then a flush in the same write as the rest of it
+	stream := append([]byte{0xFF, 0x00}, StageColCommand(2, [34]byte{9}).ToBytes()...)
+	stream = append(stream, FlushColsCommand().ToBytes()...)
+
+	_, _ = vm.Write(stream[:10])
+
+	if vm.Commands() != 0 {
+		t.Fatalf("Commands() = %d, want the partial column held back", vm.Commands())
+	}
+
+	_, _ = vm.Write(stream[10:])
+
+	if vm.Commands() != 2 || vm.Frame().Pixel(0, 2) != 9 {
+		t.Errorf("Commands() = %d, pixel = %d, want the column staged and flushed", vm.Commands(), vm.Frame().Pixel(0, 2))
+	}
+}
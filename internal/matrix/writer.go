@@ -0,0 +1,290 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+)
+
+// Write queue defaults.
+const (
+	DefaultWriteQueueSize = 64
+	DefaultWriteDeadline  = 1 * time.Second
+)
+
+// Reasons commands are dropped from a write queue, as reported in QueueEvent.Dropped.
+const (
+	DropCoalesced   = "coalesced"    // Superseded by a later command before it was written
+	DropQueueFull   = "queue_full"   // The queue was full when the command was sent
+	DropWriteFailed = "write_failed" // Still queued when an earlier write failed
+)
+
+var (
+	// ErrQueueFull is returned by SendCommand when the client's write queue is full. The module
+	// is still connected; the command is dropped.
+	ErrQueueFull = errors.New("write queue full")
+	// ErrWriteTimeout is the cause of a failed write that missed its deadline. The port is
+	// closed to unblock it.
+	ErrWriteTimeout = errors.New("write deadline exceeded")
+)
+
+// QueueEvent reports a change to a client's write queue.
+type QueueEvent struct {
+	Matrix  string // Set by MultiClient; empty for a standalone client
+	Dropped string // Why commands were dropped, or empty if none were
+	Count   int    // How many commands were dropped
+	Depth   int    // Commands waiting to be written after the change
+}
+
+// writer writes commands to a port from its own goroutine, so a slow or wedged module never
+// blocks the caller. Commands superseded by a later one before they are written are dropped.
+// The first failed write stops the writer, and every later send returns its error.
+type writer struct {
+	port     Transport
+	onEvent  func(QueueEvent)
+	err      error
+	done     chan struct{}
+	cond     *sync.Cond
+	queue    []Command
+	deadline time.Duration
+	size     int
+	mu       sync.Mutex
+	busy     bool // A command has been taken off the queue and is being written
+	stopping bool
+}
+
+func newWriter(port Transport, size int, deadline time.Duration, onEvent func(QueueEvent)) *writer {
+	w := &writer{
+		port:     port,
+		onEvent:  onEvent,
+		done:     make(chan struct{}),
+		deadline: deadline,
+		size:     size,
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	go w.run()
+
+	return w
+}
+
+// send queues cmd and returns without waiting for it to be written.
+func (w *writer) send(cmd Command) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.stopping {
+		return fmt.Errorf("not connected to any port")
+	}
+
+	var events []QueueEvent
+
+	if coalesced := w.coalesceUnsafe(cmd); coalesced > 0 {
+		events = append(events, QueueEvent{Dropped: DropCoalesced, Count: coalesced, Depth: len(w.queue)})
+	}
+
+	if len(w.queue) >= w.size {
+		w.emitUnsafe(append(events, QueueEvent{Dropped: DropQueueFull, Count: 1, Depth: len(w.queue)}))
+
+		return fmt.Errorf("failed to queue command 0x%02X: %w", cmd.ID, ErrQueueFull)
+	}
+
+	w.queue = append(w.queue, cmd)
+	w.emitUnsafe(append(events, QueueEvent{Depth: len(w.queue)}))
+	w.cond.Broadcast()
+
+	return nil
+}
+
+// coalesceUnsafe removes queued commands that cmd supersedes and returns how many it removed.
+//
+// Settings such as brightness replace any queued change to the same setting. A pattern, bitmap
+// or column flush replaces any queued pattern, bitmap or flush, since the module will end up
+// showing cmd; columns staged for the replaced flushes are kept, except for the latest staging
+// of each column, because a flush shows every staged column. Queries are never coalesced.
+func (w *writer) coalesceUnsafe(cmd Command) int {
+	var supersedes func(queued Command) bool
+
+	switch {
+	case isDisplayCommand(cmd):
+		supersedes = isDisplayCommand
+	case cmd.ID == CmdStageCol:
+		// Only a column staged since the last queued flush can be restaged
+		for i := len(w.queue) - 1; i >= 0 && !isDisplayCommand(w.queue[i]); i-- {
+			if w.queue[i].ID == CmdStageCol && w.queue[i].Params[0] == cmd.Params[0] {
+				w.queue = slices.Delete(w.queue, i, i+1)
+
+				return 1
+			}
+		}
+
+		return 0
+	case isSetting(cmd):
+		supersedes = func(queued Command) bool { return queued.ID == cmd.ID && len(queued.Params) > 0 }
+	default:
+		return 0
+	}
+
+	before := len(w.queue)
+	w.queue = slices.DeleteFunc(w.queue, supersedes)
+
+	if isDisplayCommand(cmd) {
+		w.dedupeColumnsUnsafe()
+	}
+
+	return before - len(w.queue)
+}
+
+// dedupeColumnsUnsafe keeps only the latest queued staging of each column. It must only be
+// called when no flush is queued between them.
+func (w *writer) dedupeColumnsUnsafe() {
+	latest := make(map[byte]int)
+
+	for i, queued := range w.queue {
+		if queued.ID == CmdStageCol {
+			latest[queued.Params[0]] = i
+		}
+	}
+
+	i := 0
+	w.queue = slices.DeleteFunc(w.queue, func(queued Command) bool {
+		stale := queued.ID == CmdStageCol && latest[queued.Params[0]] != i
+		i++
+
+		return stale
+	})
+}
+
+// isDisplayCommand reports whether cmd replaces everything the module shows.
+func isDisplayCommand(cmd Command) bool {
+	return cmd.ID == CmdPattern || cmd.ID == CmdDrawBW || cmd.ID == CmdFlushCols
+}
+
+// isSetting reports whether cmd sets a value that a later command of the same kind replaces.
+func isSetting(cmd Command) bool {
+	switch cmd.ID {
+	case CmdBrightness, CmdSleep, CmdAnimate, CmdAnimationPeriod, CmdPWMFrequency, CmdDebugMode:
+		return len(cmd.Params) > 0
+	default:
+		return false
+	}
+}
+
+// flush waits until every queued command has been written and returns the writer's error, if any.
+func (w *writer) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.err == nil && (len(w.queue) > 0 || w.busy) {
+		w.cond.Wait()
+	}
+
+	return w.err
+}
+
+// close stops the writer once the queued commands are written, waiting at most timeout for them.
+func (w *writer) close(timeout time.Duration) {
+	w.mu.Lock()
+	w.stopping = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(timeout):
+		logging.Warn("timed out waiting for queued matrix commands to be written")
+	}
+}
+
+func (w *writer) run() {
+	defer close(w.done)
+
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.stopping {
+			w.cond.Wait()
+		}
+
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+
+			return
+		}
+
+		next := w.queue[0]
+		w.queue = slices.Delete(w.queue, 0, 1)
+		w.busy = true
+		w.emitUnsafe([]QueueEvent{{Depth: len(w.queue)}})
+		w.mu.Unlock()
+
+		err := w.write(next)
+
+		w.mu.Lock()
+		w.busy = false
+
+		if err != nil {
+			w.err = fmt.Errorf("failed to write command: %w", err)
+
+			if dropped := len(w.queue); dropped > 0 {
+				w.queue = nil
+				w.emitUnsafe([]QueueEvent{{Dropped: DropWriteFailed, Count: dropped}})
+			}
+		}
+
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// write writes one command, closing the port if the write misses its deadline.
+func (w *writer) write(cmd Command) error {
+	data := cmd.ToBytes()
+
+	var timedOut atomic.Bool
+
+	timer := time.AfterFunc(w.deadline, func() {
+		timedOut.Store(true)
+
+		_ = w.port.Close() //nolint:errcheck // closing is only to unblock the write
+	})
+
+	_, err := w.port.Write(data)
+	timer.Stop()
+
+	if timedOut.Load() {
+		return ErrWriteTimeout
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logging.Debug("sent command", "id", fmt.Sprintf("0x%02X", cmd.ID), "data", data)
+
+	return nil
+}
+
+// emitUnsafe reports events in the order the queue changed. The handler is called with the
+// queue locked, so it must not send commands.
+func (w *writer) emitUnsafe(events []QueueEvent) {
+	if w.onEvent == nil {
+		return
+	}
+
+	for _, event := range events {
+		w.onEvent(event)
+	}
+}
@@ -0,0 +1,319 @@
+package matrix
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedPort is a transport whose writes block until the gate is opened or the port is closed.
+type gatedPort struct {
+	started chan struct{} // Receives once per write, before it blocks
+	gate    chan struct{}
+	closed  chan struct{}
+	written []byte
+	mu      sync.Mutex
+	once    sync.Once
+}
+
+func newGatedPort() *gatedPort {
+	return &gatedPort{
+		started: make(chan struct{}, 128),
+		gate:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (p *gatedPort) Write(data []byte) (int, error) {
+	p.started <- struct{}{}
+
+	select {
+	case <-p.gate:
+	case <-p.closed:
+		return 0, errors.New("port closed")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.written = append(p.written, data...)
+
+	return len(data), nil
+}
+
+func (p *gatedPort) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (p *gatedPort) Close() error {
+	p.once.Do(func() { close(p.closed) })
+
+	return nil
+}
+
+func (p *gatedPort) SetReadTimeout(time.Duration) error { return nil }
+
+func (p *gatedPort) open() { close(p.gate) }
+
+func (p *gatedPort) bytes() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return bytes.Clone(p.written)
+}
+
+// waitForWrite waits until the writer has started writing a command.
+func (p *gatedPort) waitForWrite(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-p.started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a write")
+	}
+}
+
+// queueRecorder collects the events a writer reports.
+type queueRecorder struct {
+	events []QueueEvent
+	mu     sync.Mutex
+}
+
+func (r *queueRecorder) record(event QueueEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// dropped returns how many commands were dropped for reason.
+func (r *queueRecorder) dropped(reason string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+
+	for _, event := range r.events {
+		if event.Dropped == reason {
+			count += event.Count
+		}
+	}
+
+	return count
+}
+
+func commandBytes(cmds ...Command) []byte {
+	var data []byte
+	for _, cmd := range cmds {
+		data = append(data, cmd.ToBytes()...)
+	}
+
+	return data
+}
+
+func TestWriterCoalescesSupersededCommands(t *testing.T) {
+	port := newGatedPort()
+	recorder := &queueRecorder{}
+	w := newWriter(port, DefaultWriteQueueSize, time.Second, recorder.record)
+
+	// Hold the writer on its first command so the rest queue up behind it
+	first := BrightnessCommand(1)
+	if err := w.send(first); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+
+	port.waitForWrite(t)
+
+	cmds := []Command{
+		BrightnessCommand(10),
+		VersionCommand(),
+		BrightnessCommand(20),
+		FullBrightCommand(),
+		StageColCommand(0, [34]byte{1}),
+		StageColCommand(0, [34]byte{2}),
+		StageColCommand(1, [34]byte{3}),
+		FlushColsCommand(),
+		StageColCommand(0, [34]byte{4}),
+		FlushColsCommand(),
+	}
+	for _, cmd := range cmds {
+		if err := w.send(cmd); err != nil {
+			t.Fatalf("send(0x%02X) error = %v", cmd.ID, err)
+		}
+	}
+
+	port.open()
+
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	// The first flush's column 0 is restaged before the second flush, so only the last staging
+	// of each column is written ahead of a single flush
+	want := commandBytes(
+		first,
+		VersionCommand(),
+		BrightnessCommand(20),
+		StageColCommand(1, [34]byte{3}),
+		StageColCommand(0, [34]byte{4}),
+		FlushColsCommand(),
+	)
+	if got := port.bytes(); !bytes.Equal(got, want) {
+		t.Errorf("written = % X, want % X", got, want)
+	}
+
+	if got := recorder.dropped(DropCoalesced); got != 5 {
+		t.Errorf("coalesced drops = %d, want 5", got)
+	}
+
+	w.close(time.Second)
+}
+
+func TestWriterKeepsColumnsStagedForSupersededFlush(t *testing.T) {
+	port := newGatedPort()
+	w := newWriter(port, DefaultWriteQueueSize, time.Second, nil)
+
+	_ = w.send(BrightnessCommand(1))
+	port.waitForWrite(t)
+
+	// Column 1 is only staged for the first flush, but the second flush still shows it
+	_ = w.send(StageColCommand(0, [34]byte{1}))
+	_ = w.send(StageColCommand(1, [34]byte{2}))
+	_ = w.send(FlushColsCommand())
+	_ = w.send(StageColCommand(0, [34]byte{3}))
+	_ = w.send(FlushColsCommand())
+
+	port.open()
+
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	want := commandBytes(
+		BrightnessCommand(1),
+		StageColCommand(1, [34]byte{2}),
+		StageColCommand(0, [34]byte{3}),
+		FlushColsCommand(),
+	)
+	if got := port.bytes(); !bytes.Equal(got, want) {
+		t.Errorf("written = % X, want % X", got, want)
+	}
+
+	w.close(time.Second)
+}
+
+func TestWriterQueueFull(t *testing.T) {
+	port := newGatedPort()
+	recorder := &queueRecorder{}
+	w := newWriter(port, 2, time.Second, recorder.record)
+
+	_ = w.send(BrightnessCommand(1))
+	port.waitForWrite(t)
+
+	// Queries are never coalesced, so they fill the queue
+	_ = w.send(VersionCommand())
+	_ = w.send(VersionCommand())
+
+	err := w.send(VersionCommand())
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("send() error = %v, want ErrQueueFull", err)
+	}
+
+	if got := recorder.dropped(DropQueueFull); got != 1 {
+		t.Errorf("queue full drops = %d, want 1", got)
+	}
+
+	// A full queue does not stop the writer
+	port.open()
+
+	if err := w.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	if err := w.send(VersionCommand()); err != nil {
+		t.Errorf("send() after draining error = %v", err)
+	}
+
+	w.close(time.Second)
+}
+
+func TestWriterDeadline(t *testing.T) {
+	port := newGatedPort()
+	recorder := &queueRecorder{}
+	w := newWriter(port, DefaultWriteQueueSize, 20*time.Millisecond, recorder.record)
+
+	// The gate never opens, so the first write is wedged until the deadline closes the port
+	_ = w.send(BrightnessCommand(1))
+	port.waitForWrite(t)
+	_ = w.send(VersionCommand())
+
+	err := w.flush()
+	if !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("flush() error = %v, want ErrWriteTimeout", err)
+	}
+
+	select {
+	case <-port.closed:
+	default:
+		t.Error("port was not closed after the write deadline")
+	}
+
+	if got := recorder.dropped(DropWriteFailed); got != 1 {
+		t.Errorf("write failed drops = %d, want 1", got)
+	}
+
+	// The failure is sticky, so the module is treated as lost
+	if err := w.send(BrightnessCommand(2)); !errors.Is(err, ErrWriteTimeout) {
+		t.Errorf("send() after timeout error = %v, want ErrWriteTimeout", err)
+	}
+
+	w.close(time.Second)
+}
+
+func TestWriterCloseWritesQueuedCommands(t *testing.T) {
+	port := newGatedPort()
+	w := newWriter(port, DefaultWriteQueueSize, time.Second, nil)
+
+	_ = w.send(BrightnessCommand(1))
+	port.waitForWrite(t)
+	_ = w.send(VersionCommand())
+
+	port.open()
+	w.close(time.Second)
+
+	if want := commandBytes(BrightnessCommand(1), VersionCommand()); !bytes.Equal(port.bytes(), want) {
+		t.Errorf("written = % X, want % X", port.bytes(), want)
+	}
+
+	if err := w.send(VersionCommand()); err == nil {
+		t.Error("send() after close succeeded, want an error")
+	}
+}
+
+func TestSupervisorQueueFullIsNotLost(t *testing.T) {
+	s, h := newSupervisorHarness(t)
+
+	port := newGatedPort()
+	s.client.port = port
+	s.client.writer = newWriter(port, 1, time.Second, nil)
+
+	_ = s.client.SendCommand(BrightnessCommand(1))
+	port.waitForWrite(t)
+	_ = s.client.SendCommand(VersionCommand())
+
+	if err := s.SetBrightness(50); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("SetBrightness() error = %v, want ErrQueueFull", err)
+	}
+
+	if !s.Connected() {
+		t.Error("Connected() = false after a full queue, want true")
+	}
+
+	if len(h.connects) != 0 {
+		t.Errorf("reconnected %d times after a full queue", len(h.connects))
+	}
+
+	port.open()
+	s.client.writer.close(time.Second)
+}
@@ -326,6 +326,35 @@ func (am *ApplicationMetrics) RecordMatrixOperation(operation string, matrixID s
 	am.collector.RecordDuration("matrix_operation_duration_seconds", duration, labels)
 }
 
+// RecordMatrixConnectionEvent records a matrix disconnect, reconnect or failed reconnect attempt
+// and whether the matrix is currently connected.
+func (am *ApplicationMetrics) RecordMatrixConnectionEvent(matrixID string, event string, connected bool) {
+	am.collector.IncCounter("matrix_connection_events_total", map[string]string{
+		"matrix_id": matrixID,
+		"event":     event,
+	})
+
+	connectedValue := 1.0
+	if !connected {
+		connectedValue = 0.0
+	}
+
+	am.collector.SetGauge("matrix_connected", connectedValue, map[string]string{"matrix_id": matrixID})
+}
+
+// RecordMatrixWriteQueue records the depth of a matrix's write queue and, if commands were
+// dropped from it, how many and why.
+func (am *ApplicationMetrics) RecordMatrixWriteQueue(matrixID string, depth int, dropReason string, dropped int) {
+	am.collector.SetGauge("matrix_write_queue_depth", float64(depth), map[string]string{"matrix_id": matrixID})
+
+	if dropped > 0 {
+		am.collector.AddCounter("matrix_commands_dropped_total", float64(dropped), map[string]string{
+			"matrix_id": matrixID,
+			"reason":    dropReason,
+		})
+	}
+}
+
 // RecordStatsCollection records statistics collection metrics.
 func (am *ApplicationMetrics) RecordStatsCollection(statsType string, value float64, duration time.Duration) {
 	labels := map[string]string{
@@ -503,6 +503,57 @@ func TestApplicationMetrics_RecordMatrixOperation(t *testing.T) {
 	}
 }
 
+func TestApplicationMetrics_RecordMatrixConnectionEvent(t *testing.T) {
+	logger, err := logging.NewLogger(logging.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := NewMetricsCollector(logger, time.Second)
+	defer collector.Close()
+
+	appMetrics := NewApplicationMetrics(collector)
+
+	appMetrics.RecordMatrixConnectionEvent("left", "disconnected", false)
+	appMetrics.RecordMatrixConnectionEvent("left", "reconnected", true)
+
+	metrics := collector.GetMetrics()
+
+	if m := metrics["matrix_connection_events_total,event=disconnected,matrix_id=left"]; m == nil || m.Value != 1 {
+		t.Errorf("RecordMatrixConnectionEvent() disconnect counter = %v, want 1", m)
+	}
+
+	if m := metrics["matrix_connected,matrix_id=left"]; m == nil || m.Value != 1 {
+		t.Errorf("RecordMatrixConnectionEvent() connected gauge = %v, want 1", m)
+	}
+}
+
+func TestApplicationMetrics_RecordMatrixWriteQueue(t *testing.T) {
+	logger, err := logging.NewLogger(logging.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := NewMetricsCollector(logger, time.Second)
+	defer collector.Close()
+
+	appMetrics := NewApplicationMetrics(collector)
+
+	appMetrics.RecordMatrixWriteQueue("left", 3, "", 0)
+	appMetrics.RecordMatrixWriteQueue("left", 2, "coalesced", 4)
+	appMetrics.RecordMatrixWriteQueue("left", 2, "coalesced", 1)
+
+	metrics := collector.GetMetrics()
+
+	if m := metrics["matrix_write_queue_depth,matrix_id=left"]; m == nil || m.Value != 2 {
+		t.Errorf("RecordMatrixWriteQueue() depth gauge = %v, want 2", m)
+	}
+
+	if m := metrics["matrix_commands_dropped_total,matrix_id=left,reason=coalesced"]; m == nil || m.Value != 5 {
+		t.Errorf("RecordMatrixWriteQueue() drop counter = %v, want 5", m)
+	}
+}
+
 func TestApplicationMetrics_RecordStatsCollection(t *testing.T) {
 	logger, err := logging.NewLogger(logging.DefaultConfig())
 	if err != nil {
@@ -303,6 +303,7 @@ func (c *Collector) GetSummary() (*StatsSummary, error) {
 
 	summary := &StatsSummary{
 		CPUUsage:        stats.CPU.UsagePercent,
+		PerCoreUsage:    stats.CPU.PerCorePercent,
 		MemoryUsage:     stats.Memory.UsedPercent,
 		DiskActivity:    stats.Disk.ActivityRate,
 		NetworkActivity: stats.Network.ActivityRate,
@@ -315,17 +316,5 @@ func (c *Collector) GetSummary() (*StatsSummary, error) {
 }
 
 func (c *Collector) determineStatus(summary *StatsSummary) SystemStatus {
-	thresholds := c.GetThresholds()
-
-	if summary.CPUUsage >= thresholds.CPUCritical ||
-		summary.MemoryUsage >= thresholds.MemoryCritical {
-		return StatusCritical
-	}
-
-	if summary.CPUUsage >= thresholds.CPUWarning ||
-		summary.MemoryUsage >= thresholds.MemoryWarning {
-		return StatusWarning
-	}
-
-	return StatusNormal
+	return c.GetThresholds().Status(summary)
 }
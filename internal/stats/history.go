@@ -0,0 +1,100 @@
+package stats
+
+import "sync"
+
+// History is a fixed-size ring buffer of recent summaries. Once full, each new summary
+// replaces the oldest one. It is safe for concurrent use.
+type History struct {
+	samples []StatsSummary
+	start   int
+	count   int
+	mu      sync.RWMutex
+}
+
+// NewHistory creates a History holding up to capacity summaries. A capacity below 1 is treated as 1.
+func NewHistory(capacity int) *History {
+	return &History{
+		samples: make([]StatsSummary, max(capacity, 1)),
+	}
+}
+
+// Add records a summary, evicting the oldest one if the history is full.
+func (h *History) Add(summary StatsSummary) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.addUnsafe(summary)
+}
+
+func (h *History) addUnsafe(summary StatsSummary) {
+	if h.count < len(h.samples) {
+		h.samples[(h.start+h.count)%len(h.samples)] = summary
+		h.count++
+
+		return
+	}
+
+	h.samples[h.start] = summary
+	h.start = (h.start + 1) % len(h.samples)
+}
+
+// Samples returns a copy of the recorded summaries, oldest first.
+func (h *History) Samples() []StatsSummary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.samplesUnsafe()
+}
+
+func (h *History) samplesUnsafe() []StatsSummary {
+	samples := make([]StatsSummary, h.count)
+	for i := range samples {
+		samples[i] = h.samples[(h.start+i)%len(h.samples)]
+	}
+
+	return samples
+}
+
+// Len returns the number of recorded summaries.
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.count
+}
+
+// Capacity returns the maximum number of summaries the history holds.
+func (h *History) Capacity() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.samples)
+}
+
+// Resize changes the capacity of the history, keeping the most recent summaries that still fit.
+func (h *History) Resize(capacity int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	capacity = max(capacity, 1)
+	if capacity == len(h.samples) {
+		return
+	}
+
+	samples := h.samplesUnsafe()
+	h.samples = make([]StatsSummary, capacity)
+	h.start, h.count = 0, 0
+
+	for _, summary := range samples[max(len(samples)-capacity, 0):] {
+		h.addUnsafe(summary)
+	}
+}
+
+// Clear removes all recorded summaries.
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clear(h.samples)
+	h.start, h.count = 0, 0
+}
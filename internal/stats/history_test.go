@@ -0,0 +1,82 @@
+package stats
+
+import "testing"
+
+func historyCPU(h *History) []float64 {
+	var values []float64
+	for _, s := range h.Samples() {
+		values = append(values, s.CPUUsage)
+	}
+
+	return values
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestHistoryWrapsAround(t *testing.T) {
+	h := NewHistory(3)
+
+	if h.Len() != 0 || h.Capacity() != 3 {
+		t.Fatalf("NewHistory(3) Len = %d, Capacity = %d", h.Len(), h.Capacity())
+	}
+
+	for i := 1; i <= 5; i++ {
+		h.Add(StatsSummary{CPUUsage: float64(i)})
+	}
+
+	if got, want := historyCPU(h), []float64{3, 4, 5}; !equalFloats(got, want) {
+		t.Errorf("Samples() = %v, want %v", got, want)
+	}
+
+	if h.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", h.Len())
+	}
+}
+
+func TestHistoryResize(t *testing.T) {
+	h := NewHistory(4)
+	for i := 1; i <= 6; i++ {
+		h.Add(StatsSummary{CPUUsage: float64(i)})
+	}
+
+	h.Resize(2)
+
+	if got, want := historyCPU(h), []float64{5, 6}; !equalFloats(got, want) {
+		t.Errorf("Samples() after shrinking = %v, want %v", got, want)
+	}
+
+	h.Resize(5)
+	h.Add(StatsSummary{CPUUsage: 7})
+
+	if got, want := historyCPU(h), []float64{5, 6, 7}; !equalFloats(got, want) {
+		t.Errorf("Samples() after growing = %v, want %v", got, want)
+	}
+
+	h.Clear()
+
+	if h.Len() != 0 || h.Capacity() != 5 {
+		t.Errorf("Clear() Len = %d, Capacity = %d, want 0 and 5", h.Len(), h.Capacity())
+	}
+}
+
+func TestHistoryMinimumCapacity(t *testing.T) {
+	h := NewHistory(0)
+	h.Add(StatsSummary{CPUUsage: 1})
+	h.Add(StatsSummary{CPUUsage: 2})
+
+	if got, want := historyCPU(h), []float64{2}; !equalFloats(got, want) {
+		t.Errorf("Samples() = %v, want %v", got, want)
+	}
+}
@@ -81,6 +81,7 @@ type SystemStats struct {
 // StatsSummary contains summarized system metrics with usage percentages and overall system status.
 type StatsSummary struct {
 	Timestamp       time.Time
+	PerCoreUsage    []float64 // Usage of each logical core (0-100), if available
 	CPUUsage        float64
 	MemoryUsage     float64
 	DiskActivity    float64
@@ -132,3 +133,18 @@ func DefaultThresholds() Thresholds {
 		DiskCritical:   95.0,
 	}
 }
+
+// Status returns the system status of summary against these thresholds.
+func (t Thresholds) Status(summary *StatsSummary) SystemStatus {
+	if summary.CPUUsage >= t.CPUCritical ||
+		summary.MemoryUsage >= t.MemoryCritical {
+		return StatusCritical
+	}
+
+	if summary.CPUUsage >= t.CPUWarning ||
+		summary.MemoryUsage >= t.MemoryWarning {
+		return StatusWarning
+	}
+
+	return StatusNormal
+}
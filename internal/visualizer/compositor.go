@@ -0,0 +1,566 @@
+package visualizer
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// compositorInterval is how often blinking, animated and expiring content is redrawn.
+const compositorInterval = 50 * time.Millisecond
+
+// Layer is a level of the compositor's stack. Each layer is drawn over the ones below it.
+type Layer int
+
+// Compositor layers, lowest first.
+const (
+	// LayerBase is the display mode, drawn by the Visualizer.
+	LayerBase Layer = iota
+	// LayerOverlay shows alerts and notifications over the display mode.
+	LayerOverlay
+	// LayerSystem shows problems with the daemon itself, such as stats collection failing.
+	LayerSystem
+)
+
+// upperLayers are the layers content can be submitted to, in drawing order.
+var upperLayers = []Layer{LayerOverlay, LayerSystem}
+
+func (l Layer) String() string {
+	switch l {
+	case LayerBase:
+		return "base"
+	case LayerOverlay:
+		return "overlay"
+	case LayerSystem:
+		return "system"
+	default:
+		return fmt.Sprintf("layer(%d)", int(l))
+	}
+}
+
+// BlendMode controls how content combines with the layers below it.
+type BlendMode int
+
+// Blend modes.
+const (
+	// BlendOpaque covers the layers below.
+	BlendOpaque BlendMode = iota
+	// BlendOver draws lit pixels over the layers below and lets them show through unlit ones.
+	BlendOver
+	// BlendAdd adds to the layers below, saturating at full brightness.
+	BlendAdd
+)
+
+// Content is an image shown on a compositor layer. Render draws content that changes over
+// time, such as scrolling text; otherwise Frame is shown.
+type Content struct {
+	Frame    *matrix.Framebuffer
+	Render   func(s matrix.Surface, elapsed time.Duration)
+	Priority int           // Only the highest priority content on a layer is shown; ties go to the newest
+	TTL      time.Duration // Removed this long after it is submitted; 0 keeps it until cleared
+	Blink    time.Duration // Shown for the first half of every period; 0 is steady
+	Blend    BlendMode
+}
+
+// layerEntry is content submitted to a layer.
+type layerEntry struct {
+	added   time.Time
+	content Content
+	id      string
+	seq     uint64
+}
+
+// visible reports whether the entry is in the lit half of its blink at elapsed.
+func (e *layerEntry) visible(elapsed time.Duration) bool {
+	blink := e.content.Blink
+	if blink <= 0 {
+		return true
+	}
+
+	return elapsed%blink < blink/2
+}
+
+// draw renders the entry as it appears after elapsed time.
+func (e *layerEntry) draw(elapsed time.Duration) *matrix.Framebuffer {
+	fb := matrix.NewFramebuffer()
+
+	if e.content.Render != nil {
+		e.content.Render(fb, elapsed)
+	} else if e.content.Frame != nil {
+		fb.CopyFrom(e.content.Frame)
+	}
+
+	return fb
+}
+
+// frameDrawer is the display composed frames are pushed to.
+type frameDrawer interface {
+	DrawFrame(fb *matrix.Framebuffer) error
+}
+
+// instantFrameDrawer is a display that can skip its transition. Composed frames are redrawn on
+// every blink and scroll step, so they are pushed this way where the display allows it.
+type instantFrameDrawer interface {
+	DrawFrameNow(fb *matrix.Framebuffer) error
+}
+
+// instantCanvasDrawer is a multi-display that can draw a canvas without its transition.
+type instantCanvasDrawer interface {
+	DrawCanvasNow(canvas *matrix.Canvas) error
+}
+
+// Compositor stacks alerts, notifications and daemon errors over the display mode and decides
+// what each display shows. While no content is submitted, the base layer draws straight
+// through; otherwise the layers are composed into one frame. Base layer patterns cannot be
+// composed, so content shown over them is drawn on black, and the patterns come back when the
+// content clears.
+type Compositor struct {
+	target  frameDrawer
+	now     func() time.Time
+	layers  map[Layer][]*layerEntry
+	bases   map[string][]func() error // Base layer drawing of the latest update, per display
+	frame   *matrix.Framebuffer       // Base layer frame, or nil if the base shows something else
+	shown   *matrix.Framebuffer       // Composed frame last pushed; nil while the base layer shows
+	mu      sync.Mutex
+	seq     uint64
+	running bool // The goroutine redrawing the layers is running
+	paused  bool
+}
+
+func newCompositor(target frameDrawer) *Compositor {
+	return &Compositor{
+		target: target,
+		now:    time.Now,
+		layers: make(map[Layer][]*layerEntry),
+		bases:  make(map[string][]func() error),
+	}
+}
+
+// Submit shows content on an overlay or system layer, replacing any content submitted there
+// with the same id.
+func (c *Compositor) Submit(layer Layer, id string, content Content) error {
+	if !slices.Contains(upperLayers, layer) {
+		return fmt.Errorf("cannot submit content to the %s layer", layer)
+	}
+
+	if content.Frame == nil && content.Render == nil {
+		return fmt.Errorf("content %s has neither a frame nor a renderer", id)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeUnsafe(layer, id)
+	c.seq++
+	c.layers[layer] = append(c.layers[layer], &layerEntry{
+		added:   c.now(),
+		content: content,
+		id:      id,
+		seq:     c.seq,
+	})
+
+	c.startUnsafe()
+
+	return c.refreshUnsafe()
+}
+
+// Clear removes the content with the given id from a layer.
+func (c *Compositor) Clear(layer Layer, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.removeUnsafe(layer, id) {
+		return nil
+	}
+
+	return c.refreshUnsafe()
+}
+
+// Shows reports whether content with the given id is on a layer.
+func (c *Compositor) Shows(layer Layer, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireUnsafe(c.now())
+
+	return slices.ContainsFunc(c.layers[layer], func(e *layerEntry) bool { return e.id == id })
+}
+
+// SetPaused stops the compositor drawing, for while the displays are off or asleep. Content
+// is kept, and shown again when drawing resumes.
+func (c *Compositor) SetPaused(paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused == paused {
+		return
+	}
+
+	c.paused = paused
+	if paused {
+		return
+	}
+
+	// The display may have changed while paused, so push the composition in full
+	if c.activeUnsafe() {
+		c.shown = nil
+	}
+
+	if err := c.refreshUnsafe(); err != nil {
+		logging.Warn("failed to redraw compositor layers", "error", err)
+	}
+
+	c.startUnsafe()
+}
+
+func (c *Compositor) removeUnsafe(layer Layer, id string) bool {
+	entries := c.layers[layer]
+	c.layers[layer] = slices.DeleteFunc(entries, func(e *layerEntry) bool { return e.id == id })
+
+	return len(c.layers[layer]) != len(entries)
+}
+
+// expireUnsafe removes content whose TTL has passed.
+func (c *Compositor) expireUnsafe(now time.Time) {
+	for layer, entries := range c.layers {
+		c.layers[layer] = slices.DeleteFunc(entries, func(e *layerEntry) bool {
+			return e.content.TTL > 0 && now.Sub(e.added) >= e.content.TTL
+		})
+	}
+}
+
+// activeUnsafe reports whether any layer above the base has content.
+func (c *Compositor) activeUnsafe() bool {
+	for _, entries := range c.layers {
+		if len(entries) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topUnsafe returns the content shown on a layer: the highest priority, then the newest.
+func (c *Compositor) topUnsafe(layer Layer) *layerEntry {
+	var top *layerEntry
+
+	for _, e := range c.layers[layer] {
+		if top == nil || e.content.Priority > top.content.Priority ||
+			(e.content.Priority == top.content.Priority && e.seq > top.seq) {
+			top = e
+		}
+	}
+
+	return top
+}
+
+// composeUnsafe stacks the layers as of now, or returns nil if only the base layer has content.
+func (c *Compositor) composeUnsafe(now time.Time) *matrix.Framebuffer {
+	c.expireUnsafe(now)
+
+	if !c.activeUnsafe() {
+		return nil
+	}
+
+	fb := matrix.NewFramebuffer()
+	if c.frame != nil {
+		fb.CopyFrom(c.frame)
+	}
+
+	for _, layer := range upperLayers {
+		e := c.topUnsafe(layer)
+		if e == nil {
+			continue
+		}
+
+		elapsed := now.Sub(e.added)
+		if e.visible(elapsed) {
+			blendFrame(fb, e.draw(elapsed), e.content.Blend)
+		}
+	}
+
+	return fb
+}
+
+// blendFrame draws src over dst.
+func blendFrame(dst, src *matrix.Framebuffer, mode BlendMode) {
+	for y := range matrix.FramebufferHeight {
+		for x := range matrix.FramebufferWidth {
+			value := src.Pixel(x, y)
+
+			switch mode {
+			case BlendOver:
+				if value > 0 {
+					dst.SetPixel(x, y, value)
+				}
+			case BlendAdd:
+				dst.SetPixel(x, y, byte(min(int(dst.Pixel(x, y))+int(value), 255)))
+			default:
+				dst.SetPixel(x, y, value)
+			}
+		}
+	}
+}
+
+// refreshUnsafe pushes the composed layers if they changed, or gives the display back to the
+// base layer once no content is left.
+func (c *Compositor) refreshUnsafe() error {
+	if c.paused {
+		return nil
+	}
+
+	fb := c.composeUnsafe(c.now())
+
+	if fb == nil {
+		if c.shown == nil {
+			return nil
+		}
+
+		c.shown = nil
+
+		return c.restoreUnsafe()
+	}
+
+	if c.shown != nil && c.shown.Equal(fb) {
+		return nil
+	}
+
+	c.shown = fb
+
+	draw := c.target.DrawFrame
+	if instant, ok := c.target.(instantFrameDrawer); ok {
+		draw = instant.DrawFrameNow
+	}
+
+	if err := draw(fb); err != nil {
+		return fmt.Errorf("failed to draw composed frame: %w", err)
+	}
+
+	return nil
+}
+
+// restoreUnsafe replays the latest base layer drawing on every display.
+func (c *Compositor) restoreUnsafe() error {
+	var lastErr error
+
+	keys := make([]string, 0, len(c.bases))
+	for key := range c.bases {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		for _, draw := range c.bases[key] {
+			if err := draw(); err != nil {
+				lastErr = fmt.Errorf("failed to restore base layer: %w", err)
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// startUnsafe starts the goroutine redrawing the layers if there is content to redraw.
+func (c *Compositor) startUnsafe() {
+	if c.running || c.paused || !c.activeUnsafe() {
+		return
+	}
+
+	c.running = true
+
+	go c.run()
+}
+
+func (c *Compositor) run() {
+	ticker := time.NewTicker(compositorInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !c.step() {
+			return
+		}
+	}
+}
+
+// step redraws the layers as of now and reports whether there is still content to redraw.
+func (c *Compositor) step() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refreshUnsafe(); err != nil {
+		logging.Warn("failed to redraw compositor layers", "error", err)
+	}
+
+	if c.paused || !c.activeUnsafe() {
+		c.running = false
+
+		return false
+	}
+
+	return true
+}
+
+// beginBase starts a new base layer update on a display, forgetting what the last one drew.
+func (c *Compositor) beginBase(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.bases, key)
+
+	if key == "" {
+		c.frame = nil
+	}
+}
+
+// resetBases forgets the base layer drawing on every display, for when the displays are
+// reconfigured.
+func (c *Compositor) resetBases() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.bases)
+	c.frame = nil
+}
+
+// drawBase draws on the base layer of a display, or only records the drawing while content
+// covers it. frame is the base frame to compose content over, for the compositor's own display.
+func (c *Compositor) drawBase(key string, frame *matrix.Framebuffer, draw func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bases[key] = append(c.bases[key], draw)
+
+	if key == "" {
+		c.frame = frame
+	}
+
+	c.expireUnsafe(c.now())
+
+	if !c.activeUnsafe() {
+		c.shown = nil
+
+		return draw()
+	}
+
+	if frame != nil {
+		return c.refreshUnsafe()
+	}
+
+	return nil
+}
+
+// layeredDisplay is a display as the base layer of a Compositor draws on it.
+type layeredDisplay struct {
+	DisplayManagerInterface
+	compositor *Compositor
+	key        string
+}
+
+func (d *layeredDisplay) UpdatePercentage(key string, percent float64) error {
+	return d.compositor.drawBase(d.key, nil, func() error {
+		return d.DisplayManagerInterface.UpdatePercentage(key, percent)
+	})
+}
+
+func (d *layeredDisplay) ShowActivity(active bool) error {
+	return d.compositor.drawBase(d.key, nil, func() error {
+		return d.DisplayManagerInterface.ShowActivity(active)
+	})
+}
+
+func (d *layeredDisplay) ShowStatus(status string) error {
+	return d.compositor.drawBase(d.key, nil, func() error {
+		return d.DisplayManagerInterface.ShowStatus(status)
+	})
+}
+
+func (d *layeredDisplay) DrawBitmap(pixels [39]byte) error {
+	return d.compositor.drawBase(d.key, nil, func() error {
+		return d.DisplayManagerInterface.DrawBitmap(pixels)
+	})
+}
+
+func (d *layeredDisplay) DrawFrame(fb *matrix.Framebuffer) error {
+	return d.drawFrame(fb, d.DisplayManagerInterface.DrawFrame)
+}
+
+// DrawFrameNow draws like DrawFrame, skipping the display's transition where it has one.
+func (d *layeredDisplay) DrawFrameNow(fb *matrix.Framebuffer) error {
+	draw := d.DisplayManagerInterface.DrawFrame
+	if instant, ok := d.DisplayManagerInterface.(instantFrameDrawer); ok {
+		draw = instant.DrawFrameNow
+	}
+
+	return d.drawFrame(fb, draw)
+}
+
+func (d *layeredDisplay) drawFrame(fb *matrix.Framebuffer, draw func(*matrix.Framebuffer) error) error {
+	frame := matrix.NewFramebuffer()
+	frame.CopyFrom(fb)
+
+	// Only the compositor's own display can show content composed over its frame
+	base := frame
+	if d.key != "" {
+		base = nil
+	}
+
+	return d.compositor.drawBase(d.key, base, func() error {
+		return draw(frame)
+	})
+}
+
+// layeredMultiDisplay is a multi-display as the base layer of a Compositor draws on it.
+type layeredMultiDisplay struct {
+	MultiDisplayManagerInterface
+	compositor *Compositor
+}
+
+func (d *layeredMultiDisplay) UpdateMetric(metricName string, value float64, stats map[string]float64) error {
+	return d.compositor.drawBase("", nil, func() error {
+		return d.MultiDisplayManagerInterface.UpdateMetric(metricName, value, stats)
+	})
+}
+
+func (d *layeredMultiDisplay) UpdateActivity(active bool) error {
+	return d.compositor.drawBase("", nil, func() error {
+		return d.MultiDisplayManagerInterface.UpdateActivity(active)
+	})
+}
+
+func (d *layeredMultiDisplay) UpdateStatus(status string) error {
+	return d.compositor.drawBase("", nil, func() error {
+		return d.MultiDisplayManagerInterface.UpdateStatus(status)
+	})
+}
+
+func (d *layeredMultiDisplay) DrawCanvas(canvas *matrix.Canvas) error {
+	return d.compositor.drawBase("", nil, func() error {
+		return d.MultiDisplayManagerInterface.DrawCanvas(canvas)
+	})
+}
+
+// DrawCanvasNow draws like DrawCanvas, skipping the displays' transitions where they have them.
+func (d *layeredMultiDisplay) DrawCanvasNow(canvas *matrix.Canvas) error {
+	draw := d.MultiDisplayManagerInterface.DrawCanvas
+	if instant, ok := d.MultiDisplayManagerInterface.(instantCanvasDrawer); ok {
+		draw = instant.DrawCanvasNow
+	}
+
+	return d.compositor.drawBase("", nil, func() error {
+		return draw(canvas)
+	})
+}
+
+func (d *layeredMultiDisplay) DrawFrame(fb *matrix.Framebuffer) error {
+	frame := matrix.NewFramebuffer()
+	frame.CopyFrom(fb)
+
+	return d.compositor.drawBase("", frame, func() error {
+		return d.MultiDisplayManagerInterface.DrawFrame(frame)
+	})
+}
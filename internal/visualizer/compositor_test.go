@@ -0,0 +1,251 @@
+package visualizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// pixelContent is content lighting a single pixel.
+func pixelContent(x, y int, value byte) Content {
+	fb := matrix.NewFramebuffer()
+	fb.SetPixel(x, y, value)
+
+	return Content{Frame: fb}
+}
+
+// newLayeredVisualizer returns a visualizer in mode whose compositor runs on a clock the
+// test advances.
+func newLayeredVisualizer(t *testing.T, mode string) (*Visualizer, *MockDisplayManager, *time.Time) {
+	t.Helper()
+
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = mode
+	cfg.Display.UpdateRate = 0
+
+	display := NewMockDisplayManager()
+	v := NewVisualizer(display, cfg)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v.Compositor().now = func() time.Time { return now }
+
+	// Stop the redraw goroutine when the test ends; the test steps the compositor itself
+	t.Cleanup(func() { v.Compositor().SetPaused(true) })
+
+	return v, display, &now
+}
+
+func TestCompositorSubmitErrors(t *testing.T) {
+	c := newCompositor(NewMockDisplayManager())
+
+	if err := c.Submit(LayerBase, "mode", pixelContent(0, 0, 255)); err == nil {
+		t.Error("Submit() should not accept content for the base layer")
+	}
+
+	if err := c.Submit(LayerOverlay, "empty", Content{}); err == nil {
+		t.Error("Submit() should reject content without a frame or renderer")
+	}
+}
+
+func TestCompositorCoversAndRestoresPattern(t *testing.T) {
+	v, display, _ := newLayeredVisualizer(t, "percentage")
+	c := v.Compositor()
+
+	if err := v.UpdateDisplay(&stats.StatsSummary{CPUUsage: 40}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if err := c.Submit(LayerOverlay, "alert", pixelContent(3, 4, 255)); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if display.lastFrame == nil || display.lastFrame.Pixel(3, 4) != 255 {
+		t.Fatal("overlay content should be drawn as a frame")
+	}
+
+	// The display mode keeps updating underneath, without reaching the display
+	if err := v.UpdateDisplay(&stats.StatsSummary{CPUUsage: 70}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.callCounts["UpdatePercentage"] != 1 {
+		t.Errorf("UpdatePercentage called %d times, want the covered update held back", display.callCounts["UpdatePercentage"])
+	}
+
+	if err := c.Clear(LayerOverlay, "alert"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	if display.callCounts["UpdatePercentage"] != 2 || display.lastPercentageValue != 70 {
+		t.Errorf("clearing the overlay should redraw the latest percentage, got %d calls showing %v",
+			display.callCounts["UpdatePercentage"], display.lastPercentageValue)
+	}
+}
+
+func TestCompositorBlendsLayers(t *testing.T) {
+	v, display, _ := newLayeredVisualizer(t, "cores")
+	c := v.Compositor()
+
+	if err := v.UpdateDisplay(&stats.StatsSummary{PerCoreUsage: []float64{100}}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	over := pixelContent(0, 0, 0)
+	over.Frame.SetPixel(33, 8, 0)
+	over.Frame.SetPixel(0, 8, 100)
+	over.Blend = BlendOver
+
+	if err := c.Submit(LayerOverlay, "notice", over); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if display.lastFrame.Pixel(33, 8) != 255 || display.lastFrame.Pixel(0, 8) != 100 {
+		t.Error("blended overlay should draw its lit pixels over the cores bars and leave the rest")
+	}
+
+	// The system layer is drawn over the overlay; opaque content hides everything below
+	if err := c.Submit(LayerSystem, "error", pixelContent(5, 5, 50)); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if display.lastFrame.Pixel(33, 8) != 0 || display.lastFrame.Pixel(5, 5) != 50 {
+		t.Error("opaque system content should cover the layers below")
+	}
+
+	// A new base frame is composed under the layers instead of replacing them
+	if err := c.Clear(LayerSystem, "error"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	if err := v.UpdateDisplay(&stats.StatsSummary{PerCoreUsage: []float64{0}}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastFrame.Pixel(33, 8) != 0 || display.lastFrame.Pixel(0, 8) != 100 {
+		t.Error("overlay should stay over the new base frame")
+	}
+}
+
+func TestCompositorPriority(t *testing.T) {
+	v, display, _ := newLayeredVisualizer(t, "percentage")
+	c := v.Compositor()
+
+	low := pixelContent(0, 0, 10)
+	high := pixelContent(0, 0, 20)
+	high.Priority = 1
+
+	_ = c.Submit(LayerOverlay, "high", high)
+	_ = c.Submit(LayerOverlay, "low", low)
+
+	if display.lastFrame.Pixel(0, 0) != 20 {
+		t.Error("higher priority content should be shown even when older")
+	}
+
+	_ = c.Clear(LayerOverlay, "high")
+
+	if display.lastFrame.Pixel(0, 0) != 10 {
+		t.Error("lower priority content should be shown once the higher is cleared")
+	}
+}
+
+func TestCompositorBlinkAndTTL(t *testing.T) {
+	v, display, now := newLayeredVisualizer(t, "status")
+	c := v.Compositor()
+
+	if err := v.UpdateDisplay(&stats.StatsSummary{Status: stats.StatusWarning}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	alert := pixelContent(1, 1, 255)
+	alert.Blink = 200 * time.Millisecond
+	alert.TTL = time.Second
+
+	_ = c.Submit(LayerOverlay, "alert", alert)
+
+	*now = now.Add(150 * time.Millisecond)
+	c.step()
+
+	if display.lastFrame.Pixel(1, 1) != 0 {
+		t.Error("blinking content should be dark in the second half of its period")
+	}
+
+	*now = now.Add(100 * time.Millisecond)
+	c.step()
+
+	if display.lastFrame.Pixel(1, 1) != 255 {
+		t.Error("blinking content should be lit again in the next period")
+	}
+
+	statusCalls := display.callCounts["ShowStatus"]
+	*now = now.Add(time.Second)
+
+	if c.step() {
+		t.Error("step() should report nothing left to redraw once the content expires")
+	}
+
+	if c.Shows(LayerOverlay, "alert") || display.callCounts["ShowStatus"] != statusCalls+1 || display.lastStatus != "warning" {
+		t.Error("expired content should be removed and the status pattern shown again")
+	}
+}
+
+func TestCompositorSkipsTransitions(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.UpdateRate = 0
+
+	client := &fakeClient{}
+	display := matrix.NewDisplayManager(client)
+	display.SetTransition(matrix.Transition{Easing: matrix.EaseLinear, Duration: time.Hour})
+
+	v := NewVisualizer(display, cfg)
+	t.Cleanup(func() { v.Compositor().SetPaused(true) })
+
+	// A crossfade would only stage columns as it runs; composed frames are shown at once
+	if err := v.Compositor().Submit(LayerOverlay, "alert", pixelContent(0, 0, 255)); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if client.staged == 0 {
+		t.Error("composed frame should be drawn without a transition")
+	}
+}
+
+func TestCompositorPaused(t *testing.T) {
+	v, display, _ := newLayeredVisualizer(t, "percentage")
+	c := v.Compositor()
+
+	c.SetPaused(true)
+
+	if err := c.Submit(LayerSystem, "error", pixelContent(2, 2, 255)); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if display.lastFrame != nil {
+		t.Error("paused compositor should not draw")
+	}
+
+	c.SetPaused(false)
+
+	if display.lastFrame == nil || display.lastFrame.Pixel(2, 2) != 255 {
+		t.Error("resuming should draw the content submitted while paused")
+	}
+}
+
+func TestMultiVisualizerCompositor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.UpdateRate = 0
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+	t.Cleanup(func() { mv.Compositor().SetPaused(true) })
+
+	if err := mv.Compositor().Submit(LayerSystem, "error", pixelContent(4, 4, 255)); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	if display.lastFrame == nil || display.lastFrame.Pixel(4, 4) != 255 {
+		t.Error("system content should be drawn on every display")
+	}
+}
@@ -0,0 +1,100 @@
+package visualizer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// coreBars lays out per-core loads across width columns. With at most width cores each core
+// gets a bar barWidth columns wide; with more cores than columns, neighbouring cores are
+// averaged into one-column bars.
+func coreBars(loads []float64, width int) (bars []float64, barWidth int) {
+	n := len(loads)
+	if n == 0 || width <= 0 {
+		return nil, 0
+	}
+
+	if n <= width {
+		return loads, width / n
+	}
+
+	bars = make([]float64, width)
+
+	for i := range bars {
+		group := loads[i*n/width : (i+1)*n/width]
+
+		sum := 0.0
+		for _, load := range group {
+			sum += load
+		}
+
+		bars[i] = sum / float64(len(group))
+	}
+
+	return bars, 1
+}
+
+// RenderCores draws one bar per logical core across s, each rising from the bottom row in
+// proportion to the core's load. Bars are as wide as the surface allows and centered.
+func RenderCores(s matrix.Surface, loads []float64, brightness byte) {
+	width, height := s.Size()
+	bars, barWidth := coreBars(loads, width)
+	x := (width - len(bars)*barWidth) / 2
+
+	for _, load := range bars {
+		level := min(historyLevel(load), height)
+		for col := range barWidth {
+			for row := range level {
+				s.SetPixel(x+col, height-1-row, brightness)
+			}
+		}
+
+		x += barWidth
+	}
+}
+
+// coreLoads returns the load of each logical core in summary.
+func coreLoads(summary *stats.StatsSummary) ([]float64, error) {
+	if len(summary.PerCoreUsage) == 0 {
+		return nil, fmt.Errorf("no per-core CPU usage available")
+	}
+
+	return summary.PerCoreUsage, nil
+}
+
+func (v *Visualizer) updateCoresMode(summary *stats.StatsSummary) error {
+	loads, err := coreLoads(summary)
+	if err != nil {
+		return err
+	}
+
+	fb := matrix.NewFramebuffer()
+	RenderCores(fb, loads, 255)
+
+	if err := v.base.DrawFrame(fb); err != nil {
+		return fmt.Errorf("failed to update cores display: %w", err)
+	}
+
+	v.lastUpdate = time.Now()
+
+	return nil
+}
+
+// updateCoresMode draws the core bars on every display, or across the canvas in extended mode.
+func (mv *MultiVisualizer) updateCoresMode(summary *stats.StatsSummary) error {
+	loads, err := coreLoads(summary)
+	if err != nil {
+		return err
+	}
+
+	if err := mv.drawSurface(func(s matrix.Surface) { RenderCores(s, loads, 255) }); err != nil {
+		return fmt.Errorf("failed to update cores display: %w", err)
+	}
+
+	mv.lastUpdate = time.Now()
+
+	return nil
+}
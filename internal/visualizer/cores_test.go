@@ -0,0 +1,130 @@
+package visualizer
+
+import (
+	"testing"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+func TestCoreBars(t *testing.T) {
+	tests := []struct {
+		name     string
+		cores    int
+		bars     int
+		barWidth int
+	}{
+		{"no cores", 0, 0, 0},
+		{"quad core", 4, 4, 8},
+		{"16 cores", 16, 16, 2},
+		{"one column per core", 34, 34, 1},
+		{"more cores than columns", 64, 34, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars, barWidth := coreBars(make([]float64, tt.cores), matrix.FramebufferWidth)
+			if len(bars) != tt.bars || barWidth != tt.barWidth {
+				t.Errorf("coreBars(%d) = %d bars of width %d, want %d of width %d",
+					tt.cores, len(bars), barWidth, tt.bars, tt.barWidth)
+			}
+		})
+	}
+}
+
+func TestCoreBarsAveragesNeighbours(t *testing.T) {
+	// 68 cores into 34 columns: each column averages a pair of neighbouring cores
+	loads := make([]float64, 68)
+	loads[0], loads[1] = 100, 50
+
+	bars, _ := coreBars(loads, matrix.FramebufferWidth)
+
+	if bars[0] != 75 || bars[1] != 0 {
+		t.Errorf("coreBars() first bars = %v, %v, want 75, 0", bars[0], bars[1])
+	}
+}
+
+func TestRenderCores(t *testing.T) {
+	loads := make([]float64, 16)
+	loads[0] = 100
+	loads[15] = 50
+
+	fb := matrix.NewFramebuffer()
+	RenderCores(fb, loads, 255)
+
+	// 16 two-column bars fill 32 columns, centered with one spare column on each side
+	want := map[int]int{0: 0, 1: 9, 2: 9, 3: 0, 31: 5, 32: 5, 33: 0}
+	for x, height := range want {
+		if got := columnHeight(fb, x); got != height {
+			t.Errorf("column %d height = %d, want %d", x, got, height)
+		}
+	}
+}
+
+func TestVisualizerUpdateDisplayCoresMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "cores"
+	cfg.Display.UpdateRate = 0
+
+	mockDisplay := NewMockDisplayManager()
+	visualizer := NewVisualizer(mockDisplay, cfg)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 50}); err == nil {
+		t.Error("UpdateDisplay() should fail in cores mode without per-core usage")
+	}
+
+	summary := &stats.StatsSummary{PerCoreUsage: []float64{100, 0}}
+	if err := visualizer.UpdateDisplay(summary); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if mockDisplay.lastFrame == nil {
+		t.Fatal("cores mode should draw a frame")
+	}
+
+	if columnHeight(mockDisplay.lastFrame, 0) != 9 || columnHeight(mockDisplay.lastFrame, 17) != 0 {
+		t.Error("cores mode should draw a full bar for the busy core and none for the idle one")
+	}
+}
+
+func TestMultiVisualizerCoresMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "cores"
+	cfg.Display.UpdateRate = 0
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{PerCoreUsage: []float64{100}}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastFrame == nil || columnHeight(display.lastFrame, 33) != 9 {
+		t.Error("cores mode should draw the bars on every display")
+	}
+}
+
+func TestMultiVisualizerCoresModeExtended(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "cores"
+	cfg.Display.UpdateRate = 0
+	cfg.Matrix.DualMode = "extended"
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{PerCoreUsage: []float64{100, 0}}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastCanvas == nil || display.lastFrame != nil {
+		t.Fatal("extended cores mode should draw one canvas across both matrices")
+	}
+
+	// Two cores share the 68 columns, so each fills one matrix
+	left, right := display.lastCanvas.Slice(0), display.lastCanvas.Slice(1)
+	if columnHeight(left, 0) != 9 || columnHeight(left, 33) != 9 || columnHeight(right, 0) != 0 {
+		t.Error("core bars should be laid out across both matrices")
+	}
+}
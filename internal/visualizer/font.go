@@ -0,0 +1,249 @@
+package visualizer
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// Font is a fixed-width pixel font. Glyphs are stored as rows of '#' (lit) and '.' (unlit)
+// so they can be read and edited in source.
+type Font struct {
+	glyphs map[rune][]string
+	Name   string
+	Width  int
+	Height int
+}
+
+// Glyph returns the rows of the glyph for r. Lowercase letters use their uppercase glyph and
+// unknown characters are drawn as '?'.
+func (f *Font) Glyph(r rune) []string {
+	if glyph, ok := f.glyphs[r]; ok {
+		return glyph
+	}
+
+	if glyph, ok := f.glyphs[unicode.ToUpper(r)]; ok {
+		return glyph
+	}
+
+	return f.glyphs['?']
+}
+
+// TextWidth returns the width in pixels of text, including one column of spacing between glyphs.
+func (f *Font) TextWidth(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+
+	return n*(f.Width+1) - 1
+}
+
+// DrawText draws text onto s with its top-left corner at (x, y) and returns the x position
+// just past the last glyph. Pixels outside the surface are clipped.
+func (f *Font) DrawText(s matrix.Surface, x, y int, text string, brightness byte) int {
+	for _, r := range text {
+		for row, line := range f.Glyph(r) {
+			for col, c := range line {
+				if c == '#' {
+					s.SetPixel(x+col, y+row, brightness)
+				}
+			}
+		}
+
+		x += f.Width + 1
+	}
+
+	return x
+}
+
+// LookupFont returns the built-in font with the given name ("3x3", "3x5" or "5x7").
+func LookupFont(name string) (*Font, error) {
+	switch name {
+	case Font3x3.Name:
+		return Font3x3, nil
+	case Font3x5.Name:
+		return Font3x5, nil
+	case Font5x7.Name:
+		return Font5x7, nil
+	default:
+		return nil, fmt.Errorf("unknown font: %s", name)
+	}
+}
+
+// Font3x5 is a compact font that fits a single line with margins above and below.
+var Font3x5 = &Font{
+	Name:   "3x5",
+	Width:  3,
+	Height: 5,
+	glyphs: map[rune][]string{
+		' ':  {"...", "...", "...", "...", "..."},
+		'0':  {"###", "#.#", "#.#", "#.#", "###"},
+		'1':  {".#.", "##.", ".#.", ".#.", "###"},
+		'2':  {"###", "..#", "###", "#..", "###"},
+		'3':  {"###", "..#", "###", "..#", "###"},
+		'4':  {"#.#", "#.#", "###", "..#", "..#"},
+		'5':  {"###", "#..", "###", "..#", "###"},
+		'6':  {"###", "#..", "###", "#.#", "###"},
+		'7':  {"###", "..#", "..#", ".#.", ".#."},
+		'8':  {"###", "#.#", "###", "#.#", "###"},
+		'9':  {"###", "#.#", "###", "..#", "###"},
+		'A':  {".#.", "#.#", "###", "#.#", "#.#"},
+		'B':  {"##.", "#.#", "##.", "#.#", "##."},
+		'C':  {".##", "#..", "#..", "#..", ".##"},
+		'D':  {"##.", "#.#", "#.#", "#.#", "##."},
+		'E':  {"###", "#..", "##.", "#..", "###"},
+		'F':  {"###", "#..", "##.", "#..", "#.."},
+		'G':  {".##", "#..", "#.#", "#.#", ".##"},
+		'H':  {"#.#", "#.#", "###", "#.#", "#.#"},
+		'I':  {"###", ".#.", ".#.", ".#.", "###"},
+		'J':  {"..#", "..#", "..#", "#.#", ".#."},
+		'K':  {"#.#", "#.#", "##.", "#.#", "#.#"},
+		'L':  {"#..", "#..", "#..", "#..", "###"},
+		'M':  {"#.#", "###", "#.#", "#.#", "#.#"},
+		'N':  {"###", "#.#", "#.#", "#.#", "#.#"},
+		'O':  {".#.", "#.#", "#.#", "#.#", ".#."},
+		'P':  {"##.", "#.#", "##.", "#..", "#.."},
+		'Q':  {".#.", "#.#", "#.#", "##.", ".##"},
+		'R':  {"##.", "#.#", "##.", "#.#", "#.#"},
+		'S':  {".##", "#..", ".#.", "..#", "##."},
+		'T':  {"###", ".#.", ".#.", ".#.", ".#."},
+		'U':  {"#.#", "#.#", "#.#", "#.#", "###"},
+		'V':  {"#.#", "#.#", "#.#", "#.#", ".#."},
+		'W':  {"#.#", "#.#", "#.#", "###", "#.#"},
+		'X':  {"#.#", "#.#", ".#.", "#.#", "#.#"},
+		'Y':  {"#.#", "#.#", ".#.", ".#.", ".#."},
+		'Z':  {"###", "..#", ".#.", "#..", "###"},
+		'.':  {"...", "...", "...", "...", ".#."},
+		',':  {"...", "...", "...", ".#.", "#.."},
+		':':  {"...", ".#.", "...", ".#.", "..."},
+		'-':  {"...", "...", "###", "...", "..."},
+		'_':  {"...", "...", "...", "...", "###"},
+		'+':  {"...", ".#.", "###", ".#.", "..."},
+		'=':  {"...", "###", "...", "###", "..."},
+		'*':  {"...", "#.#", ".#.", "#.#", "..."},
+		'/':  {"..#", "..#", ".#.", "#..", "#.."},
+		'%':  {"#.#", "..#", ".#.", "#..", "#.#"},
+		'!':  {".#.", ".#.", ".#.", "...", ".#."},
+		'?':  {"##.", "..#", ".#.", "...", ".#."},
+		'\'': {".#.", ".#.", "...", "...", "..."},
+		'(':  {".#.", "#..", "#..", "#..", ".#."},
+		')':  {".#.", "..#", "..#", "..#", ".#."},
+	},
+}
+
+// Font5x7 is the classic 5x7 dot matrix font, readable at a glance but only 5 glyphs fit at once.
+var Font5x7 = &Font{
+	Name:   "5x7",
+	Width:  5,
+	Height: 7,
+	glyphs: map[rune][]string{
+		' ':  {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+		'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+		'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+		'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+		'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+		'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+		'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+		'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+		'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+		'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+		'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+		'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+		'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+		'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+		'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###.."},
+		'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+		'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+		'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+		'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+		'I':  {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+		'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+		'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+		'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+		'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+		'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+		'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+		'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+		'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+		'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+		'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+		'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+		'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+		'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+		'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+		'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+		'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+		'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+		'.':  {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+		',':  {".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+		':':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+		'-':  {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+		'_':  {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+		'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+		'=':  {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+		'*':  {".....", "..#..", "#.#.#", ".###.", "#.#.#", "..#..", "....."},
+		'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+		'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+		'!':  {"..#..", "..#..", "..#..", "..#..", "..#..", ".....", "..#.."},
+		'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+		'\'': {"..#..", "..#..", ".#...", ".....", ".....", ".....", "....."},
+		'(':  {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+		')':  {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	},
+}
+
+// Font3x3 is a tiny font for labels and numbers in zones only three rows tall. Some glyphs
+// share a shape, such as 'O' and '0', so it suits short, familiar text.
+var Font3x3 = &Font{
+	Name:   "3x3",
+	Width:  3,
+	Height: 3,
+	glyphs: map[rune][]string{
+		' ': {"...", "...", "..."},
+		'0': {"###", "#.#", "###"},
+		'1': {"##.", ".#.", "###"},
+		'2': {"##.", ".#.", ".##"},
+		'3': {"###", ".##", "###"},
+		'4': {"#.#", "###", "..#"},
+		'5': {".##", ".#.", "##."},
+		'6': {"#..", "###", "###"},
+		'7': {"###", "..#", "..#"},
+		'8': {"###", "###", "###"},
+		'9': {"###", "###", "..#"},
+		'A': {".#.", "###", "#.#"},
+		'B': {"##.", "###", "###"},
+		'C': {"###", "#..", "###"},
+		'D': {"##.", "#.#", "##."},
+		'E': {"###", "##.", "###"},
+		'F': {"###", "##.", "#.."},
+		'G': {"##.", "#.#", "###"},
+		'H': {"#.#", "###", "#.#"},
+		'I': {"###", ".#.", "###"},
+		'J': {"..#", "#.#", "###"},
+		'K': {"#.#", "##.", "#.#"},
+		'L': {"#..", "#..", "###"},
+		'M': {"###", "###", "#.#"},
+		'N': {"##.", "#.#", "#.#"},
+		'O': {"###", "#.#", "###"},
+		'P': {"###", "###", "#.."},
+		'Q': {".#.", "#.#", ".##"},
+		'R': {"##.", "##.", "#.#"},
+		'S': {".##", ".#.", "##."},
+		'T': {"###", ".#.", ".#."},
+		'U': {"#.#", "#.#", "###"},
+		'V': {"#.#", "#.#", ".#."},
+		'W': {"#.#", "###", "###"},
+		'X': {"#.#", ".#.", "#.#"},
+		'Y': {"#.#", ".#.", ".#."},
+		'Z': {"##.", ".#.", ".##"},
+		'.': {"...", "...", ".#."},
+		':': {".#.", "...", ".#."},
+		'-': {"...", "###", "..."},
+		'+': {".#.", "###", ".#."},
+		'%': {"#.#", "..#", "#.."},
+		'!': {".#.", ".#.", "..."},
+		'?': {"##.", ".#.", "..."},
+	},
+}
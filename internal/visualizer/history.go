@@ -0,0 +1,116 @@
+package visualizer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// HistoryLevels is the number of heights a history column can take, one per matrix row.
+const HistoryLevels = matrix.FramebufferHeight
+
+// historyLevel maps a percentage onto the number of lit rows in a history column. Any non-zero
+// value lights at least one row so that light load is still visible.
+func historyLevel(percent float64) int {
+	if percent <= 0 || math.IsNaN(percent) {
+		return 0
+	}
+
+	level := int(math.Round(percent / 100 * HistoryLevels))
+
+	return min(max(level, 1), HistoryLevels)
+}
+
+// RenderHistory draws values (oldest first, as percentages) as a column graph on s, one column
+// per sample rising from the bottom row. The newest sample is in the rightmost column, so the
+// graph scrolls left as samples are added; samples older than the surface is wide are dropped.
+func RenderHistory(s matrix.Surface, values []float64, brightness byte) {
+	width, height := s.Size()
+	values = values[max(len(values)-width, 0):]
+	x := width - len(values)
+
+	for i, value := range values {
+		for row := range min(historyLevel(value), height) {
+			s.SetPixel(x+i, height-1-row, brightness)
+		}
+	}
+}
+
+// SetHistory sets the sample history graphed in "history" mode. The caller owns the history
+// and records a summary in it on each collection.
+func (v *Visualizer) SetHistory(history *stats.History) {
+	v.history = history
+}
+
+// historyValues returns the configured history metric of each recorded summary, oldest first,
+// using value to convert a summary into a percentage.
+func historyValues(
+	history *stats.History, display config.DisplayConfig, value func(*stats.StatsSummary, string) float64,
+) ([]float64, error) {
+	if history == nil {
+		return nil, fmt.Errorf("no history available")
+	}
+
+	metric := display.History.Metric
+	if metric == "" {
+		metric = display.PrimaryMetric
+	}
+
+	samples := history.Samples()
+	values := make([]float64, len(samples))
+
+	for i := range samples {
+		values[i] = value(&samples[i], metric)
+	}
+
+	return values, nil
+}
+
+func (v *Visualizer) updateHistoryMode(_ *stats.StatsSummary) error {
+	values, err := historyValues(v.history, v.config.Display, v.metricValue)
+	if err != nil {
+		return err
+	}
+
+	fb := matrix.NewFramebuffer()
+	RenderHistory(fb, values, 255)
+
+	if err := v.base.DrawFrame(fb); err != nil {
+		return fmt.Errorf("failed to update history display: %w", err)
+	}
+
+	v.lastUpdate = time.Now()
+
+	return nil
+}
+
+// SetHistory sets the sample history graphed in "history" mode, on all displays and on each
+// matrix in independent mode.
+func (mv *MultiVisualizer) SetHistory(history *stats.History) {
+	mv.history = history
+
+	for _, m := range mv.independent {
+		m.visualizer.SetHistory(history)
+	}
+}
+
+// updateHistoryMode graphs the history on every display, or across the canvas in extended mode
+// so the graph continues from one matrix to the next.
+func (mv *MultiVisualizer) updateHistoryMode(_ *stats.StatsSummary) error {
+	values, err := historyValues(mv.history, mv.config.Display, mv.metricValue)
+	if err != nil {
+		return err
+	}
+
+	if err := mv.drawSurface(func(s matrix.Surface) { RenderHistory(s, values, 255) }); err != nil {
+		return fmt.Errorf("failed to update history display: %w", err)
+	}
+
+	mv.lastUpdate = time.Now()
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package visualizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// columnHeight returns the number of lit pixels in column x of fb.
+func columnHeight(fb *matrix.Framebuffer, x int) int {
+	height := 0
+
+	for y := range matrix.FramebufferHeight {
+		if fb.Pixel(x, y) != 0 {
+			height++
+		}
+	}
+
+	return height
+}
+
+func TestHistoryLevel(t *testing.T) {
+	tests := []struct {
+		percent float64
+		want    int
+	}{
+		{0, 0},
+		{-5, 0},
+		{1, 1},
+		{50, 5},
+		{100, 9},
+		{150, 9},
+	}
+
+	for _, tt := range tests {
+		if got := historyLevel(tt.percent); got != tt.want {
+			t.Errorf("historyLevel(%v) = %d, want %d", tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHistory(t *testing.T) {
+	fb := matrix.NewFramebuffer()
+	RenderHistory(fb, []float64{0, 50, 100, 5}, 255)
+
+	// The newest sample is in the rightmost column
+	want := map[int]int{30: 0, 31: 5, 32: 9, 33: 1}
+	for x, height := range want {
+		if got := columnHeight(fb, x); got != height {
+			t.Errorf("column %d height = %d, want %d", x, got, height)
+		}
+	}
+
+	if columnHeight(fb, 29) != 0 {
+		t.Error("columns left of the oldest sample should be dark")
+	}
+
+	// Columns rise from the bottom row
+	if fb.Pixel(33, 8) == 0 || fb.Pixel(33, 7) != 0 {
+		t.Error("history columns should be drawn from the bottom up")
+	}
+}
+
+func TestRenderHistoryDropsOldSamples(t *testing.T) {
+	values := make([]float64, 40)
+	values[5] = 100 // Too old to fit on the 34 column matrix
+	values[6] = 100 // The oldest sample shown, in column 0
+
+	fb := matrix.NewFramebuffer()
+	RenderHistory(fb, values, 255)
+
+	if got := columnHeight(fb, 0); got != 9 {
+		t.Errorf("column 0 height = %d, want 9", got)
+	}
+
+	lit := 0
+
+	for x := range matrix.FramebufferWidth {
+		lit += columnHeight(fb, x)
+	}
+
+	if lit != 9 {
+		t.Errorf("lit pixels = %d, want only the oldest visible sample", lit)
+	}
+}
+
+func TestVisualizerUpdateDisplayHistoryMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "history"
+	cfg.Display.UpdateRate = 0
+	cfg.Display.History.Metric = "memory"
+
+	mockDisplay := NewMockDisplayManager()
+	visualizer := NewVisualizer(mockDisplay, cfg)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{}); err == nil {
+		t.Error("UpdateDisplay() should fail in history mode without a history")
+	}
+
+	history := stats.NewHistory(cfg.Display.History.Window)
+	history.Add(stats.StatsSummary{CPUUsage: 100, MemoryUsage: 100})
+	history.Add(stats.StatsSummary{CPUUsage: 100, MemoryUsage: 30})
+	visualizer.SetHistory(history)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if mockDisplay.lastFrame == nil {
+		t.Fatal("history mode should draw a frame")
+	}
+
+	if got := columnHeight(mockDisplay.lastFrame, 32); got != 9 {
+		t.Errorf("column 32 height = %d, want 9", got)
+	}
+
+	if got := columnHeight(mockDisplay.lastFrame, 33); got != 3 {
+		t.Errorf("column 33 height = %d, want 3 for 30%% memory", got)
+	}
+}
+
+func TestVisualizerHistoryModeStagesColumns(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "history"
+	cfg.Display.UpdateRate = time.Millisecond
+
+	client := &fakeClient{}
+	display := matrix.NewDisplayManager(client)
+	visualizer := NewVisualizer(display, cfg)
+
+	history := stats.NewHistory(cfg.Display.History.Window)
+	history.Add(stats.StatsSummary{CPUUsage: 50})
+	visualizer.SetHistory(history)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if client.staged == 0 || len(client.patterns) != 0 {
+		t.Errorf("history mode staged %d columns and sent patterns %v, want column staging only", client.staged, client.patterns)
+	}
+}
+
+func TestMultiVisualizerHistoryMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "history"
+	cfg.Display.UpdateRate = 0
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	history := stats.NewHistory(cfg.Display.History.Window)
+	history.Add(stats.StatsSummary{CPUUsage: 100})
+	mv.SetHistory(history)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastFrame == nil || columnHeight(display.lastFrame, 33) != 9 {
+		t.Error("history mode should draw the graph on every display")
+	}
+}
+
+func TestMultiVisualizerHistoryModeExtended(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "history"
+	cfg.Display.UpdateRate = 0
+	cfg.Display.History.Window = 2 * config.MaxHistoryWindow
+	cfg.Matrix.DualMode = "extended"
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	history := stats.NewHistory(cfg.Display.History.Window)
+	for i := range cfg.Display.History.Window {
+		history.Add(stats.StatsSummary{CPUUsage: float64(100 * (i % 2))})
+	}
+
+	mv.SetHistory(history)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastCanvas == nil || display.lastFrame != nil {
+		t.Fatal("extended history mode should draw one canvas across both matrices")
+	}
+
+	// The oldest sample is in the left matrix's first column and the newest in the right's last
+	left, right := display.lastCanvas.Slice(0), display.lastCanvas.Slice(1)
+	if columnHeight(left, 0) != 0 || columnHeight(left, 1) != 9 || columnHeight(right, 33) != 9 {
+		t.Error("history graph should continue from the left matrix to the right")
+	}
+}
@@ -1,15 +1,15 @@
 // Package visualizer provides visualization components that convert system metrics to LED patterns.
 // It supports both single and multi-matrix configurations with various display modes including
-// percentage, gradient, activity, and status visualization modes.
+// percentage, gradient, activity, status, custom, history, per-core and multi-zone visualization modes.
 package visualizer
 
 import (
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/logging"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
 )
 
@@ -19,6 +19,8 @@ type DisplayManagerInterface interface {
 	ShowActivity(active bool) error
 	ShowStatus(status string) error
 	SetBrightness(level byte) error
+	DrawBitmap(pixels [39]byte) error
+	DrawFrame(fb *matrix.Framebuffer) error
 	GetCurrentState() map[string]interface{}
 	SetUpdateRate(rate time.Duration)
 }
@@ -30,45 +32,91 @@ type MultiDisplayManagerInterface interface {
 	UpdateStatus(status string) error
 	SetBrightness(level byte) error
 	SetUpdateRate(rate time.Duration)
+	SetLayout(layout string)
+	NewCanvas() (*matrix.Canvas, error)
+	DrawFrame(fb *matrix.Framebuffer) error
+	GetDisplayManager(name string) *matrix.DisplayManager
+	DrawCanvas(canvas *matrix.Canvas) error
 	HasMultipleDisplays() bool
 }
 
 // Visualizer converts system metrics into visual patterns for single LED matrix displays.
 type Visualizer struct {
-	display    DisplayManagerInterface
-	config     *config.Config
-	lastUpdate time.Time
+	display     DisplayManagerInterface
+	base        DisplayManagerInterface // display as the compositor's base layer draws on it
+	compositor  *Compositor
+	config      *config.Config
+	custom      CustomPattern
+	history     *stats.History
+	zones       *Layout
+	lastUpdate  time.Time
+	customStart time.Time
+	customName  string
+	layerKey    string // Names the display in a compositor shared with other visualizers
 }
 
 // MultiVisualizer converts system metrics into visual patterns for multiple LED matrix displays.
 type MultiVisualizer struct {
 	multiDisplay MultiDisplayManagerInterface
+	base         MultiDisplayManagerInterface // multiDisplay as the compositor's base layer draws on it
+	compositor   *Compositor
 	config       *config.Config
+	independent  map[string]*independentMatrix
+	history      *stats.History
+	zones        *Layout
 	lastUpdate   time.Time
 }
 
+// independentMatrix is the per-matrix visualizer used in independent dual mode.
+type independentMatrix struct {
+	visualizer *Visualizer
+	thresholds *stats.Thresholds
+}
+
 // NewVisualizer creates a new Visualizer with the specified display manager and configuration.
 func NewVisualizer(display DisplayManagerInterface, cfg *config.Config) *Visualizer {
+	compositor := newCompositor(display)
+
 	return &Visualizer{
-		display: display,
-		config:  cfg,
+		display:    display,
+		base:       &layeredDisplay{DisplayManagerInterface: display, compositor: compositor},
+		compositor: compositor,
+		config:     cfg,
 	}
 }
 
 // NewMultiVisualizer creates a new MultiVisualizer with the specified multi-display manager and configuration.
 func NewMultiVisualizer(multiDisplay MultiDisplayManagerInterface, cfg *config.Config) *MultiVisualizer {
+	compositor := newCompositor(multiDisplay)
+
 	return &MultiVisualizer{
 		multiDisplay: multiDisplay,
+		base:         &layeredMultiDisplay{MultiDisplayManagerInterface: multiDisplay, compositor: compositor},
+		compositor:   compositor,
 		config:       cfg,
+		independent:  make(map[string]*independentMatrix),
 	}
 }
 
+// Compositor returns the compositor that stacks alerts and errors over the display mode.
+func (v *Visualizer) Compositor() *Compositor {
+	return v.compositor
+}
+
+// Compositor returns the compositor that stacks alerts and errors over the display mode on
+// every display.
+func (mv *MultiVisualizer) Compositor() *Compositor {
+	return mv.compositor
+}
+
 // UpdateDisplay updates the LED matrix display based on the current system statistics and configured display mode.
 func (v *Visualizer) UpdateDisplay(summary *stats.StatsSummary) error {
 	if time.Since(v.lastUpdate) < v.config.Display.UpdateRate {
 		return nil
 	}
 
+	v.compositor.beginBase(v.layerKey)
+
 	switch v.config.Display.Mode {
 	case "percentage":
 		return v.updatePercentageMode(summary)
@@ -80,28 +128,21 @@ func (v *Visualizer) UpdateDisplay(summary *stats.StatsSummary) error {
 		return v.updateStatusMode(summary)
 	case "custom":
 		return v.updateCustomMode(summary)
+	case "history":
+		return v.updateHistoryMode(summary)
+	case "cores":
+		return v.updateCoresMode(summary)
+	case "zones":
+		return v.updateZonesMode(summary)
 	default:
 		return fmt.Errorf("unknown display mode: %s", v.config.Display.Mode)
 	}
 }
 
 func (v *Visualizer) updatePercentageMode(summary *stats.StatsSummary) error {
-	var value float64
-
-	switch v.config.Display.PrimaryMetric {
-	case "cpu":
-		value = summary.CPUUsage
-	case "memory":
-		value = summary.MemoryUsage
-	case "disk":
-		value = v.normalizeActivity(summary.DiskActivity)
-	case "network":
-		value = v.normalizeActivity(summary.NetworkActivity)
-	default:
-		value = summary.CPUUsage
-	}
+	value := v.metricValue(summary, v.config.Display.PrimaryMetric)
 
-	if err := v.display.UpdatePercentage(v.config.Display.PrimaryMetric, value); err != nil {
+	if err := v.base.UpdatePercentage(v.config.Display.PrimaryMetric, value); err != nil {
 		return fmt.Errorf("failed to update percentage display: %w", err)
 	}
 
@@ -111,7 +152,7 @@ func (v *Visualizer) updatePercentageMode(summary *stats.StatsSummary) error {
 }
 
 func (v *Visualizer) updateGradientMode(_ *stats.StatsSummary) error {
-	if err := v.display.ShowStatus("normal"); err != nil {
+	if err := v.base.ShowStatus("normal"); err != nil {
 		return fmt.Errorf("failed to show gradient: %w", err)
 	}
 
@@ -123,7 +164,7 @@ func (v *Visualizer) updateGradientMode(_ *stats.StatsSummary) error {
 func (v *Visualizer) updateActivityMode(summary *stats.StatsSummary) error {
 	isActive := v.isSystemActive(summary)
 
-	if err := v.display.ShowActivity(isActive); err != nil {
+	if err := v.base.ShowActivity(isActive); err != nil {
 		return fmt.Errorf("failed to update activity display: %w", err)
 	}
 
@@ -135,7 +176,7 @@ func (v *Visualizer) updateActivityMode(summary *stats.StatsSummary) error {
 func (v *Visualizer) updateStatusMode(summary *stats.StatsSummary) error {
 	status := summary.Status.String()
 
-	if err := v.display.ShowStatus(status); err != nil {
+	if err := v.base.ShowStatus(status); err != nil {
 		return fmt.Errorf("failed to update status display: %w", err)
 	}
 
@@ -145,7 +186,46 @@ func (v *Visualizer) updateStatusMode(summary *stats.StatsSummary) error {
 }
 
 func (v *Visualizer) updateCustomMode(summary *stats.StatsSummary) error {
-	return fmt.Errorf("custom mode not yet implemented")
+	name, patternCfg, ok := v.config.Display.ActiveCustomPattern()
+	if !ok {
+		return fmt.Errorf("no custom pattern selected")
+	}
+
+	if v.custom == nil || v.customName != name {
+		pattern, err := NewCustomPattern(patternCfg, v.config.Display.PrimaryMetric)
+		if err != nil {
+			return fmt.Errorf("failed to create custom pattern %s: %w", name, err)
+		}
+
+		v.custom = pattern
+		v.customName = name
+		v.customStart = time.Now()
+	}
+
+	fb := matrix.NewFramebuffer()
+	v.custom.Render(fb, v.metricValue(summary, v.custom.Metric()), time.Since(v.customStart))
+
+	if err := v.base.DrawFrame(fb); err != nil {
+		return fmt.Errorf("failed to update custom display: %w", err)
+	}
+
+	v.lastUpdate = time.Now()
+
+	return nil
+}
+
+// metricValue returns the named metric from summary as a percentage.
+func (v *Visualizer) metricValue(summary *stats.StatsSummary, metric string) float64 {
+	switch metric {
+	case "memory":
+		return summary.MemoryUsage
+	case "disk":
+		return v.normalizeActivity(summary.DiskActivity)
+	case "network":
+		return v.normalizeActivity(summary.NetworkActivity)
+	default:
+		return summary.CPUUsage
+	}
 }
 
 func (v *Visualizer) normalizeActivity(activity float64) float64 {
@@ -193,31 +273,37 @@ func (v *Visualizer) isSystemActive(summary *stats.StatsSummary) bool {
 	return false
 }
 
-// CreateCustomPattern creates a custom LED pattern from normalized float data with specified dimensions.
+// CreateCustomPattern creates a packed black and white bitmap from normalized float data with specified
+// dimensions. Data is row-major and anchored at the top-left of the matrix; values of 0.5 or more light a pixel.
 func (v *Visualizer) CreateCustomPattern(width, height int, data []float64) ([39]byte, error) {
-	var pixels [39]byte
+	var bitmap matrix.Bitmap
 
-	if len(data) != width*height {
-		return pixels, fmt.Errorf("data length mismatch: expected %d, got %d", width*height, len(data))
+	if width < 0 || height < 0 || width > matrix.FramebufferWidth || height > matrix.FramebufferHeight {
+		return [39]byte{}, fmt.Errorf("pattern size %dx%d exceeds matrix size %dx%d",
+			width, height, matrix.FramebufferWidth, matrix.FramebufferHeight)
 	}
 
-	// Use explicit bounds to satisfy gosec linter
-	maxLen := len(pixels)
-	if len(data) < maxLen {
-		maxLen = len(data)
+	if len(data) != width*height {
+		return [39]byte{}, fmt.Errorf("data length mismatch: expected %d, got %d", width*height, len(data))
 	}
 
-	for i := 0; i < maxLen; i++ {
-		normalized := math.Max(0, math.Min(1, data[i]))
-		pixels[i] = byte(normalized * 255)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bitmap[y][x] = data[y*width+x] >= 0.5
+		}
 	}
 
-	return pixels, nil
+	return matrix.PackBitmap(bitmap), nil
 }
 
-// DrawCustomBitmap displays a custom bitmap pattern on the LED matrix (not yet implemented).
+// DrawCustomBitmap displays a packed black and white bitmap, as produced by CreateCustomPattern or
+// matrix.PackBitmap, on the LED matrix.
 func (v *Visualizer) DrawCustomBitmap(pixels [39]byte) error {
-	return fmt.Errorf("custom bitmap drawing not yet implemented")
+	if err := v.base.DrawBitmap(pixels); err != nil {
+		return fmt.Errorf("failed to draw custom bitmap: %w", err)
+	}
+
+	return nil
 }
 
 // CreateProgressBar creates a progress bar pattern with the specified percentage and width.
@@ -248,10 +334,17 @@ func (v *Visualizer) GetCurrentState() map[string]interface{} {
 
 // UpdateDisplay updates multiple LED matrix displays based on system statistics and dual mode configuration.
 func (mv *MultiVisualizer) UpdateDisplay(summary *stats.StatsSummary) error {
+	// Each matrix rate limits itself in independent mode
+	if mv.config.Matrix.DualMode == "independent" {
+		return mv.updateIndependentMode(summary)
+	}
+
 	if time.Since(mv.lastUpdate) < mv.config.Display.UpdateRate {
 		return nil
 	}
 
+	mv.compositor.beginBase("")
+
 	switch mv.config.Display.Mode {
 	case "percentage":
 		return mv.updatePercentageMode(summary)
@@ -261,11 +354,53 @@ func (mv *MultiVisualizer) UpdateDisplay(summary *stats.StatsSummary) error {
 		return mv.updateActivityMode(summary)
 	case "status":
 		return mv.updateStatusMode(summary)
+	case "history":
+		return mv.updateHistoryMode(summary)
+	case "cores":
+		return mv.updateCoresMode(summary)
+	case "zones":
+		return mv.updateZonesMode(summary)
 	default:
 		return mv.updatePercentageMode(summary)
 	}
 }
 
+// drawSurface renders a frame-based mode. In extended mode it renders onto one canvas spanning
+// both matrices; otherwise the same frame is drawn on every display.
+func (mv *MultiVisualizer) drawSurface(render func(s matrix.Surface)) error {
+	if mv.config.Matrix.DualMode != "extended" {
+		fb := matrix.NewFramebuffer()
+		render(fb)
+
+		return mv.base.DrawFrame(fb)
+	}
+
+	canvas, err := mv.multiDisplay.NewCanvas()
+	if err != nil {
+		return fmt.Errorf("failed to create canvas: %w", err)
+	}
+
+	render(canvas)
+
+	return mv.base.DrawCanvas(canvas)
+}
+
+// surfaceSize returns the size of the surface drawSurface renders onto.
+func (mv *MultiVisualizer) surfaceSize() (width, height int, err error) {
+	if mv.config.Matrix.DualMode != "extended" {
+		return matrix.FramebufferWidth, matrix.FramebufferHeight, nil
+	}
+
+	canvas, err := mv.multiDisplay.NewCanvas()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create canvas: %w", err)
+	}
+
+	width, height = canvas.Size()
+
+	return width, height, nil
+}
+
 func (mv *MultiVisualizer) updatePercentageMode(summary *stats.StatsSummary) error {
 	// Create stats map for all metrics
 	statsMap := map[string]float64{
@@ -279,7 +414,7 @@ func (mv *MultiVisualizer) updatePercentageMode(summary *stats.StatsSummary) err
 	var lastErr error
 
 	for metric, value := range statsMap {
-		if err := mv.multiDisplay.UpdateMetric(metric, value, statsMap); err != nil {
+		if err := mv.base.UpdateMetric(metric, value, statsMap); err != nil {
 			lastErr = err
 		}
 	}
@@ -290,7 +425,7 @@ func (mv *MultiVisualizer) updatePercentageMode(summary *stats.StatsSummary) err
 }
 
 func (mv *MultiVisualizer) updateGradientMode(_ *stats.StatsSummary) error {
-	if err := mv.multiDisplay.UpdateStatus("normal"); err != nil {
+	if err := mv.base.UpdateStatus("normal"); err != nil {
 		return fmt.Errorf("failed to show gradient: %w", err)
 	}
 
@@ -302,7 +437,7 @@ func (mv *MultiVisualizer) updateGradientMode(_ *stats.StatsSummary) error {
 func (mv *MultiVisualizer) updateActivityMode(summary *stats.StatsSummary) error {
 	active := mv.isSystemActive(summary)
 
-	if err := mv.multiDisplay.UpdateActivity(active); err != nil {
+	if err := mv.base.UpdateActivity(active); err != nil {
 		return fmt.Errorf("failed to update activity: %w", err)
 	}
 
@@ -314,7 +449,7 @@ func (mv *MultiVisualizer) updateActivityMode(summary *stats.StatsSummary) error
 func (mv *MultiVisualizer) updateStatusMode(summary *stats.StatsSummary) error {
 	status := mv.determineSystemStatus(summary)
 
-	if err := mv.multiDisplay.UpdateStatus(status); err != nil {
+	if err := mv.base.UpdateStatus(status); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
 
@@ -323,6 +458,73 @@ func (mv *MultiVisualizer) updateStatusMode(summary *stats.StatsSummary) error {
 	return nil
 }
 
+func (mv *MultiVisualizer) updateIndependentMode(summary *stats.StatsSummary) error {
+	var lastErr error
+
+	for _, matrixConfig := range mv.config.ConvertMatrices() {
+		im := mv.independentMatrix(matrixConfig)
+		if im == nil {
+			continue
+		}
+
+		matrixSummary := *summary
+		if im.thresholds != nil {
+			matrixSummary.Status = im.thresholds.Status(&matrixSummary)
+		}
+
+		if err := im.visualizer.UpdateDisplay(&matrixSummary); err != nil {
+			lastErr = fmt.Errorf("failed to update matrix %s: %w", matrixConfig.Name, err)
+			logging.Error("failed to update independent display", "matrix", matrixConfig.Name, "error", err)
+		}
+	}
+
+	mv.lastUpdate = time.Now()
+
+	return lastErr
+}
+
+// independentMatrix returns the visualizer for a matrix in independent mode, creating it on first
+// use with the matrix's own display settings. It returns nil if the matrix is not connected.
+func (mv *MultiVisualizer) independentMatrix(matrixConfig config.SingleMatrixConfig) *independentMatrix {
+	if im, ok := mv.independent[matrixConfig.Name]; ok {
+		return im
+	}
+
+	display := mv.multiDisplay.GetDisplayManager(matrixConfig.Name)
+	if display == nil {
+		return nil
+	}
+
+	cfg := *mv.config
+	cfg.Display = mv.config.DisplayConfigFor(matrixConfig)
+
+	display.SetUpdateRate(cfg.Display.UpdateRate)
+
+	im := &independentMatrix{}
+
+	if matrixConfig.Thresholds != nil {
+		cfg.Stats.Thresholds = *matrixConfig.Thresholds
+		im.thresholds = &stats.Thresholds{
+			CPUWarning:     matrixConfig.Thresholds.CPUWarning,
+			CPUCritical:    matrixConfig.Thresholds.CPUCritical,
+			MemoryWarning:  matrixConfig.Thresholds.MemoryWarning,
+			MemoryCritical: matrixConfig.Thresholds.MemoryCritical,
+			DiskWarning:    matrixConfig.Thresholds.DiskWarning,
+			DiskCritical:   matrixConfig.Thresholds.DiskCritical,
+		}
+	}
+
+	// Share the compositor, so alerts and errors cover every matrix
+	im.visualizer = NewVisualizer(display, &cfg)
+	im.visualizer.compositor = mv.compositor
+	im.visualizer.base = &layeredDisplay{DisplayManagerInterface: display, compositor: mv.compositor, key: matrixConfig.Name}
+	im.visualizer.layerKey = matrixConfig.Name
+	im.visualizer.SetHistory(mv.history)
+	mv.independent[matrixConfig.Name] = im
+
+	return im
+}
+
 func (mv *MultiVisualizer) normalizeActivity(activity float64) float64 {
 	if activity <= 0 {
 		return 0.0
@@ -338,6 +540,20 @@ func (mv *MultiVisualizer) normalizeActivity(activity float64) float64 {
 	return normalized
 }
 
+// metricValue returns the named metric from summary as a percentage.
+func (mv *MultiVisualizer) metricValue(summary *stats.StatsSummary, metric string) float64 {
+	switch metric {
+	case "memory":
+		return summary.MemoryUsage
+	case "disk":
+		return mv.normalizeActivity(summary.DiskActivity)
+	case "network":
+		return mv.normalizeActivity(summary.NetworkActivity)
+	default:
+		return summary.CPUUsage
+	}
+}
+
 func (mv *MultiVisualizer) isSystemActive(summary *stats.StatsSummary) bool {
 	activityThreshold := 1024.0
 
@@ -372,7 +588,11 @@ func (mv *MultiVisualizer) determineSystemStatus(summary *stats.StatsSummary) st
 // UpdateConfig updates the multi-visualizer configuration at runtime.
 func (mv *MultiVisualizer) UpdateConfig(cfg *config.Config) {
 	mv.config = cfg
+	mv.independent = make(map[string]*independentMatrix)
+	mv.zones = nil
+	mv.compositor.resetBases()
 	mv.multiDisplay.SetUpdateRate(cfg.Display.UpdateRate)
+	mv.multiDisplay.SetLayout(cfg.Matrix.Layout)
 
 	if cfg.Matrix.Brightness != 0 {
 		if err := mv.multiDisplay.SetBrightness(cfg.Matrix.Brightness); err != nil {
@@ -384,6 +604,8 @@ func (mv *MultiVisualizer) UpdateConfig(cfg *config.Config) {
 // UpdateConfig updates the visualizer configuration and applies new settings including update rate and brightness.
 func (v *Visualizer) UpdateConfig(cfg *config.Config) {
 	v.config = cfg
+	v.custom = nil
+	v.zones = nil
 	v.display.SetUpdateRate(cfg.Display.UpdateRate)
 
 	if cfg.Matrix.Brightness != 0 {
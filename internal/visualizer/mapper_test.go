@@ -2,11 +2,13 @@ package visualizer
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
 	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
 )
 
@@ -26,6 +28,8 @@ type MockDisplayManager struct {
 	lastStatus          string
 	updateRate          time.Duration
 	lastPercentageValue float64
+	lastFrame           *matrix.Framebuffer
+	lastBitmap          [39]byte
 	lastActivity        bool
 	lastBrightness      byte
 }
@@ -87,6 +91,31 @@ func (m *MockDisplayManager) SetBrightness(level byte) error {
 	return nil
 }
 
+func (m *MockDisplayManager) DrawBitmap(pixels [39]byte) error {
+	m.callCounts["DrawBitmap"]++
+	if m.updateError != nil {
+		return m.updateError
+	}
+
+	m.lastBitmap = pixels
+	m.currentState["bitmap"] = true
+
+	return nil
+}
+
+func (m *MockDisplayManager) DrawFrame(fb *matrix.Framebuffer) error {
+	m.callCounts["DrawFrame"]++
+	if m.updateError != nil {
+		return m.updateError
+	}
+
+	m.lastFrame = matrix.NewFramebuffer()
+	m.lastFrame.CopyFrom(fb)
+	m.currentState["frame"] = true
+
+	return nil
+}
+
 func (m *MockDisplayManager) GetCurrentState() map[string]interface{} {
 	m.callCounts["GetCurrentState"]++
 
@@ -120,6 +149,97 @@ func (m *MockDisplayManager) Reset() {
 	m.lastActivity = false
 	m.lastStatus = ""
 	m.lastBrightness = 0
+	m.lastBitmap = [39]byte{}
+	m.lastFrame = nil
+}
+
+// mockMultiDisplay implements MultiDisplayManagerInterface over real DisplayManagers and records
+// the last canvas and frame drawn.
+type mockMultiDisplay struct {
+	displays      map[string]*matrix.DisplayManager
+	lastCanvas    *matrix.Canvas
+	lastFrame     *matrix.Framebuffer
+	layout        string
+	canvasSkipped bool // Whether the last canvas was drawn without a transition
+}
+
+func (m *mockMultiDisplay) UpdateMetric(string, float64, map[string]float64) error { return nil }
+func (m *mockMultiDisplay) UpdateActivity(bool) error                              { return nil }
+func (m *mockMultiDisplay) UpdateStatus(string) error                              { return nil }
+func (m *mockMultiDisplay) SetBrightness(byte) error                               { return nil }
+func (m *mockMultiDisplay) SetUpdateRate(time.Duration)                            {}
+func (m *mockMultiDisplay) SetLayout(layout string)                                { m.layout = layout }
+func (m *mockMultiDisplay) NewCanvas() (*matrix.Canvas, error)                     { return matrix.NewCanvas(m.layout) }
+func (m *mockMultiDisplay) HasMultipleDisplays() bool                              { return true }
+
+func (m *mockMultiDisplay) GetDisplayManager(name string) *matrix.DisplayManager {
+	return m.displays[name]
+}
+
+func (m *mockMultiDisplay) DrawCanvas(canvas *matrix.Canvas) error {
+	m.lastCanvas = canvas
+	m.canvasSkipped = false
+
+	return nil
+}
+
+func (m *mockMultiDisplay) DrawCanvasNow(canvas *matrix.Canvas) error {
+	m.lastCanvas = canvas
+	m.canvasSkipped = true
+
+	return nil
+}
+
+func (m *mockMultiDisplay) DrawFrame(fb *matrix.Framebuffer) error {
+	m.lastFrame = fb
+
+	return nil
+}
+
+// fakeClient records the built-in patterns and columns a matrix.DisplayManager sends to its module.
+type fakeClient struct {
+	columns  map[byte][34]byte // Last pixels staged for each column
+	patterns []string
+	staged   int
+}
+
+func (c *fakeClient) ShowPercentage(percent byte) error {
+	c.patterns = append(c.patterns, fmt.Sprintf("percentage:%d", percent))
+
+	return nil
+}
+
+func (c *fakeClient) ShowZigZag() error {
+	c.patterns = append(c.patterns, "zigzag")
+
+	return nil
+}
+
+func (c *fakeClient) ShowGradient() error {
+	c.patterns = append(c.patterns, "gradient")
+
+	return nil
+}
+
+func (c *fakeClient) ShowFullBright() error {
+	c.patterns = append(c.patterns, "fullbright")
+
+	return nil
+}
+
+func (c *fakeClient) SetBrightness(byte) error  { return nil }
+func (c *fakeClient) DrawBitmap([39]byte) error { return nil }
+func (c *fakeClient) FlushColumns() error       { return nil }
+
+func (c *fakeClient) StageColumn(col byte, pixels [34]byte) error {
+	if c.columns == nil {
+		c.columns = make(map[byte][34]byte)
+	}
+
+	c.columns[col] = pixels
+	c.staged++
+
+	return nil
 }
 
 func TestNewVisualizer(t *testing.T) {
@@ -382,19 +502,49 @@ func TestVisualizerUpdateDisplayCustomMode(t *testing.T) {
 
 	visualizer := NewVisualizer(mockDisplay, cfg)
 
-	summary := &stats.StatsSummary{}
+	summary := &stats.StatsSummary{CPUUsage: 50, MemoryUsage: 25}
 
 	time.Sleep(2 * time.Millisecond)
 
 	err := visualizer.UpdateDisplay(summary)
 	if err == nil {
-		t.Error("UpdateDisplay() with custom mode should return error (not implemented)")
+		t.Fatal("UpdateDisplay() with custom mode and no patterns should return error")
 	}
 
-	expectedError := "custom mode not yet implemented"
+	expectedError := "no custom pattern selected"
 	if err.Error() != expectedError {
 		t.Errorf("UpdateDisplay() error = %v, want %v", err.Error(), expectedError)
 	}
+
+	cfg.Display.CustomPatterns["mem"] = config.PatternConfig{
+		Pattern:    "bar",
+		Parameters: map[string]interface{}{"metric": "memory"},
+	}
+	visualizer.UpdateConfig(cfg)
+
+	time.Sleep(2 * time.Millisecond)
+
+	if err := visualizer.UpdateDisplay(summary); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if mockDisplay.GetCallCount("DrawFrame") != 1 {
+		t.Fatalf("UpdateDisplay() DrawFrame calls = %d, want 1", mockDisplay.GetCallCount("DrawFrame"))
+	}
+
+	// 25% memory fills 8.5 of 34 columns
+	frame := mockDisplay.lastFrame
+	if frame.Pixel(7, 4) != 255 || frame.Pixel(9, 4) != 0 {
+		t.Error("custom bar pattern should be bound to the memory metric")
+	}
+
+	mockDisplay.SetUpdateError(errors.New("display error"))
+
+	time.Sleep(2 * time.Millisecond)
+
+	if err := visualizer.UpdateDisplay(summary); err == nil {
+		t.Error("UpdateDisplay() should return error when display fails")
+	}
 }
 
 func TestVisualizerUpdateDisplayInvalidMode(t *testing.T) {
@@ -630,16 +780,35 @@ func TestVisualizerCreateCustomPattern(t *testing.T) {
 	tests := []struct {
 		name      string
 		data      []float64
+		lit       [][2]int
 		width     int
 		height    int
 		expectErr bool
 	}{
 		{
-			name:      "valid pattern",
-			width:     3,
-			height:    13, // 3*13 = 39
-			data:      make([]float64, 39),
-			expectErr: false,
+			name:   "small pattern anchored top-left",
+			width:  3,
+			height: 2,
+			data: []float64{
+				1.0, 0.0, 0.6,
+				0.2, 0.5, 0.49,
+			},
+			lit: [][2]int{{0, 0}, {2, 0}, {1, 1}},
+		},
+		{
+			name:   "full matrix corners",
+			width:  34,
+			height: 9,
+			data: func() []float64 {
+				data := make([]float64, 34*9)
+				data[0] = 1
+				data[33] = 1
+				data[8*34] = 1
+				data[8*34+33] = 1
+
+				return data
+			}(),
+			lit: [][2]int{{0, 0}, {33, 0}, {0, 8}, {33, 8}},
 		},
 		{
 			name:      "data length mismatch",
@@ -649,21 +818,22 @@ func TestVisualizerCreateCustomPattern(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "empty data",
-			width:     0,
-			height:    0,
-			data:      []float64{},
-			expectErr: false,
+			name:      "pattern taller than matrix",
+			width:     3,
+			height:    13,
+			data:      make([]float64, 39),
+			expectErr: true,
+		},
+		{
+			name:   "empty data",
+			width:  0,
+			height: 0,
+			data:   []float64{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Fill data with test values
-			for i := range tt.data {
-				tt.data[i] = float64(i) / float64(len(tt.data)) // 0.0 to 1.0
-			}
-
 			pixels, err := visualizer.CreateCustomPattern(tt.width, tt.height, tt.data)
 
 			if (err != nil) != tt.expectErr {
@@ -672,26 +842,17 @@ func TestVisualizerCreateCustomPattern(t *testing.T) {
 				return
 			}
 
-			if !tt.expectErr {
-				expectedLen := 39
-				if len(pixels) != expectedLen {
-					t.Errorf("CreateCustomPattern() pixels length = %d, want %d", len(pixels), expectedLen)
-				}
-
-				// Verify pixel values are properly normalized to 0-255
-				maxDataLen := len(tt.data)
-				if maxDataLen > expectedLen {
-					maxDataLen = expectedLen
-				}
-
-				for i := 0; i < maxDataLen; i++ {
-					expected := byte(tt.data[i] * 255)
-					if pixels[i] != expected {
-						t.Errorf("CreateCustomPattern() pixels[%d] = %d, want %d", i, pixels[i], expected)
-
-						break // Only show first mismatch
-					}
-				}
+			if tt.expectErr {
+				return
+			}
+
+			var expected matrix.Bitmap
+			for _, p := range tt.lit {
+				expected[p[1]][p[0]] = true
+			}
+
+			if got := matrix.UnpackBitmap(pixels); got != expected {
+				t.Errorf("CreateCustomPattern() unpacked to %v, want %v", got, expected)
 			}
 		})
 	}
@@ -872,19 +1033,27 @@ func TestVisualizerDrawCustomBitmap(t *testing.T) {
 	cfg := config.DefaultConfig()
 	visualizer := NewVisualizer(mockDisplay, cfg)
 
-	pixels := [39]byte{}
-	for i := range pixels {
-		pixels[i] = byte(i)
+	pixels, err := visualizer.CreateCustomPattern(2, 1, []float64{1, 1})
+	if err != nil {
+		t.Fatalf("CreateCustomPattern() error = %v", err)
 	}
 
-	err := visualizer.DrawCustomBitmap(pixels)
-	if err == nil {
-		t.Error("DrawCustomBitmap() should return error (not implemented)")
+	if err := visualizer.DrawCustomBitmap(pixels); err != nil {
+		t.Fatalf("DrawCustomBitmap() error = %v", err)
 	}
 
-	expectedError := "custom bitmap drawing not yet implemented"
-	if err.Error() != expectedError {
-		t.Errorf("DrawCustomBitmap() error = %v, want %v", err.Error(), expectedError)
+	if mockDisplay.GetCallCount("DrawBitmap") != 1 {
+		t.Errorf("DrawCustomBitmap() DrawBitmap calls = %d, want 1", mockDisplay.GetCallCount("DrawBitmap"))
+	}
+
+	if mockDisplay.lastBitmap != pixels {
+		t.Errorf("DrawCustomBitmap() sent %v, want %v", mockDisplay.lastBitmap, pixels)
+	}
+
+	mockDisplay.SetUpdateError(errors.New("display error"))
+
+	if err := visualizer.DrawCustomBitmap(pixels); err == nil {
+		t.Error("DrawCustomBitmap() should return error when display fails")
 	}
 }
 
@@ -949,3 +1118,53 @@ func BenchmarkVisualizerIsSystemActive(b *testing.B) {
 		visualizer.isSystemActive(summary)
 	}
 }
+
+func TestMultiVisualizerIndependentMode(t *testing.T) {
+	left, right := &fakeClient{}, &fakeClient{}
+	display := &mockMultiDisplay{displays: map[string]*matrix.DisplayManager{
+		"left":  matrix.NewDisplayManager(left),
+		"right": matrix.NewDisplayManager(right),
+	}}
+
+	cfg := config.DefaultConfig()
+	cfg.Matrix.DualMode = "independent"
+	cfg.Display.UpdateRate = time.Millisecond
+	cfg.Matrix.Matrices = []map[string]interface{}{
+		{"name": "left", "mode": "percentage", "primary_metric": "memory", "update_rate": "1ms"},
+		{"name": "right", "mode": "status", "update_rate": "1h", "thresholds": map[string]interface{}{"cpu_warning": 20}},
+		{"name": "missing", "mode": "activity"},
+	}
+
+	mv := NewMultiVisualizer(display, cfg)
+	summary := &stats.StatsSummary{CPUUsage: 30, MemoryUsage: 40, Status: stats.StatusNormal}
+
+	if err := mv.UpdateDisplay(summary); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(left.patterns, []string{"percentage:40"}) {
+		t.Errorf("left matrix patterns = %v, want memory percentage", left.patterns)
+	}
+
+	// CPU at 30% is above the right matrix's own warning threshold of 20%
+	if !reflect.DeepEqual(right.patterns, []string{"zigzag"}) {
+		t.Errorf("right matrix patterns = %v, want warning status", right.patterns)
+	}
+
+	// Each matrix rate limits itself: the left one updates again, the right one waits an hour
+	time.Sleep(2 * time.Millisecond)
+
+	summary.MemoryUsage = 80
+
+	if err := mv.UpdateDisplay(summary); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if len(left.patterns) != 2 || left.patterns[1] != "percentage:80" {
+		t.Errorf("left matrix patterns = %v, want a second update", left.patterns)
+	}
+
+	if len(right.patterns) != 1 {
+		t.Errorf("right matrix patterns = %v, want no second update within its update rate", right.patterns)
+	}
+}
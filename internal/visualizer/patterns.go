@@ -0,0 +1,263 @@
+package visualizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// CustomPattern draws one configured custom pattern for the current value of its bound metric.
+// Patterns may keep state between frames, such as a history of values.
+type CustomPattern interface {
+	Metric() string
+	Render(fb *matrix.Framebuffer, value float64, elapsed time.Duration)
+}
+
+// PatternFactory builds a CustomPattern from its configuration. defaultMetric is used when the
+// pattern does not set its own "metric" parameter.
+type PatternFactory func(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error)
+
+// patternRegistry maps each config.PatternSchemas type to the factory that renders it.
+var patternRegistry = map[string]PatternFactory{
+	config.PatternBar:       newBarPattern,
+	config.PatternSparkline: newSparklinePattern,
+	config.PatternText:      newTextPattern,
+	config.PatternIcon:      newIconPattern,
+	config.PatternHeatmap:   newHeatmapPattern,
+}
+
+// NewCustomPattern builds the renderer for a configured custom pattern.
+func NewCustomPattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	factory, ok := patternRegistry[cfg.Pattern]
+	if !ok {
+		return nil, fmt.Errorf("unknown custom pattern type: %s", cfg.Pattern)
+	}
+
+	return factory(cfg, defaultMetric)
+}
+
+// patternBase holds the parameters shared by every custom pattern.
+type patternBase struct {
+	metric     string
+	max        float64
+	brightness byte
+}
+
+func newPatternBase(cfg config.PatternConfig, defaultMetric string) patternBase {
+	return patternBase{
+		metric:     cfg.String("metric", defaultMetric),
+		max:        cfg.Number("max", 100),
+		brightness: byte(cfg.Number("brightness", 255)),
+	}
+}
+
+func (p patternBase) Metric() string {
+	return p.metric
+}
+
+// fraction scales value against max and clamps it to 0..1.
+func (p patternBase) fraction(value float64) float64 {
+	if p.max <= 0 {
+		return 0
+	}
+
+	f := value / p.max
+
+	switch {
+	case f < 0:
+		return 0
+	case f > 1:
+		return 1
+	default:
+		return f
+	}
+}
+
+// history is a fixed-length window of recent values, one per matrix column.
+type history struct {
+	values []float64
+}
+
+func (h *history) add(value float64) {
+	h.values = append(h.values, value)
+	if len(h.values) > matrix.FramebufferWidth {
+		h.values = h.values[len(h.values)-matrix.FramebufferWidth:]
+	}
+}
+
+// barPattern fills the matrix in proportion to the metric, with the leading edge dimmed for partial steps.
+type barPattern struct {
+	patternBase
+	vertical bool
+}
+
+func newBarPattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	return &barPattern{
+		patternBase: newPatternBase(cfg, defaultMetric),
+		vertical:    cfg.String("orientation", "horizontal") == "vertical",
+	}, nil
+}
+
+func (p *barPattern) Render(fb *matrix.Framebuffer, value float64, _ time.Duration) {
+	size := matrix.FramebufferWidth
+	if p.vertical {
+		size = matrix.FramebufferHeight
+	}
+
+	filled := p.fraction(value) * float64(size)
+	full := int(filled)
+	edge := byte((filled - float64(full)) * float64(p.brightness))
+
+	if p.vertical {
+		fb.FillRect(0, size-full, matrix.FramebufferWidth, full, p.brightness)
+
+		if full < size {
+			fb.FillRect(0, size-full-1, matrix.FramebufferWidth, 1, edge)
+		}
+
+		return
+	}
+
+	fb.FillRect(0, 0, full, matrix.FramebufferHeight, p.brightness)
+
+	if full < size {
+		fb.FillRect(full, 0, 1, matrix.FramebufferHeight, edge)
+	}
+}
+
+// sparklinePattern plots the recent history of the metric as a line, newest value on the right.
+type sparklinePattern struct {
+	patternBase
+	history history
+	fill    bool
+}
+
+func newSparklinePattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	return &sparklinePattern{
+		patternBase: newPatternBase(cfg, defaultMetric),
+		fill:        cfg.Bool("fill", false),
+	}, nil
+}
+
+func (p *sparklinePattern) Render(fb *matrix.Framebuffer, value float64, _ time.Duration) {
+	p.history.add(value)
+
+	start := matrix.FramebufferWidth - len(p.history.values)
+	bottom := matrix.FramebufferHeight - 1
+	prevX, prevY := -1, 0
+
+	for i, v := range p.history.values {
+		x := start + i
+		y := bottom - int(p.fraction(v)*float64(bottom)+0.5)
+
+		if p.fill {
+			fb.Line(x, y, x, bottom, p.brightness)
+		}
+
+		if prevX >= 0 {
+			fb.Line(prevX, prevY, x, y, p.brightness)
+		} else {
+			fb.SetPixel(x, y, p.brightness)
+		}
+
+		prevX, prevY = x, y
+	}
+}
+
+// textPattern renders text with "{value}" replaced by the metric, scrolling when it does not fit.
+type textPattern struct {
+	patternBase
+	renderer *TextRenderer
+	format   string
+}
+
+func newTextPattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	font, err := LookupFont(cfg.String("font", Font3x5.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &textPattern{
+		patternBase: newPatternBase(cfg, defaultMetric),
+		format:      cfg.String("text", "{value}%"),
+		renderer:    NewTextRenderer(font, "", cfg.Number("speed", DefaultScrollSpeed)),
+	}
+	p.renderer.SetBrightness(p.brightness)
+
+	return p, nil
+}
+
+func (p *textPattern) Render(fb *matrix.Framebuffer, value float64, elapsed time.Duration) {
+	p.renderer.SetText(strings.ReplaceAll(p.format, "{value}", strconv.FormatFloat(value, 'f', 0, 64)))
+	p.renderer.Render(fb, elapsed)
+}
+
+// iconPattern draws a fixed icon centered on the matrix while the metric is at or above threshold.
+type iconPattern struct {
+	patternBase
+	sprite    [][]byte
+	threshold float64
+}
+
+func newIconPattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	rows := cfg.StringList("pixels")
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("icon pattern requires pixels")
+	}
+
+	p := &iconPattern{
+		patternBase: newPatternBase(cfg, defaultMetric),
+		threshold:   cfg.Number("threshold", 0),
+	}
+
+	for _, row := range rows {
+		line := make([]byte, len(row))
+
+		for i, c := range row {
+			if c == '#' {
+				line[i] = p.brightness
+			}
+		}
+
+		p.sprite = append(p.sprite, line)
+	}
+
+	return p, nil
+}
+
+func (p *iconPattern) Render(fb *matrix.Framebuffer, value float64, _ time.Duration) {
+	if value < p.threshold {
+		return
+	}
+
+	width := 0
+	for _, row := range p.sprite {
+		width = max(width, len(row))
+	}
+
+	fb.Blit((matrix.FramebufferWidth-width)/2, (matrix.FramebufferHeight-len(p.sprite))/2, p.sprite)
+}
+
+// heatmapPattern shows the recent history of the metric as columns of greyscale intensity.
+type heatmapPattern struct {
+	patternBase
+	history history
+}
+
+func newHeatmapPattern(cfg config.PatternConfig, defaultMetric string) (CustomPattern, error) {
+	return &heatmapPattern{patternBase: newPatternBase(cfg, defaultMetric)}, nil
+}
+
+func (p *heatmapPattern) Render(fb *matrix.Framebuffer, value float64, _ time.Duration) {
+	p.history.add(value)
+
+	start := matrix.FramebufferWidth - len(p.history.values)
+
+	for i, v := range p.history.values {
+		fb.FillRect(start+i, 0, 1, matrix.FramebufferHeight, byte(p.fraction(v)*float64(p.brightness)))
+	}
+}
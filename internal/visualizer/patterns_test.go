@@ -0,0 +1,182 @@
+package visualizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func renderPattern(t *testing.T, cfg config.PatternConfig, values ...float64) *matrix.Framebuffer {
+	t.Helper()
+
+	pattern, err := NewCustomPattern(cfg, "cpu")
+	if err != nil {
+		t.Fatalf("NewCustomPattern() error = %v", err)
+	}
+
+	fb := matrix.NewFramebuffer()
+
+	for _, value := range values {
+		fb.Clear()
+		pattern.Render(fb, value, 0)
+	}
+
+	return fb
+}
+
+func TestPatternRegistryCoversSchemas(t *testing.T) {
+	for _, name := range config.PatternTypes() {
+		if _, ok := patternRegistry[name]; !ok {
+			t.Errorf("pattern type %s has a config schema but no renderer", name)
+		}
+	}
+
+	for name := range patternRegistry {
+		if _, ok := config.PatternSchemas[name]; !ok {
+			t.Errorf("pattern type %s has a renderer but no config schema", name)
+		}
+	}
+}
+
+func TestNewCustomPatternErrors(t *testing.T) {
+	if _, err := NewCustomPattern(config.PatternConfig{Pattern: "spiral"}, "cpu"); err == nil {
+		t.Error("NewCustomPattern() should reject unknown pattern types")
+	}
+
+	if _, err := NewCustomPattern(config.PatternConfig{Pattern: "icon"}, "cpu"); err == nil {
+		t.Error("NewCustomPattern() should reject an icon without pixels")
+	}
+}
+
+func TestPatternMetricBinding(t *testing.T) {
+	pattern, err := NewCustomPattern(config.PatternConfig{Pattern: "bar"}, "disk")
+	if err != nil {
+		t.Fatalf("NewCustomPattern() error = %v", err)
+	}
+
+	if pattern.Metric() != "disk" {
+		t.Errorf("Metric() = %s, want default disk", pattern.Metric())
+	}
+
+	pattern, _ = NewCustomPattern(config.PatternConfig{
+		Pattern:    "bar",
+		Parameters: map[string]interface{}{"metric": "network"},
+	}, "disk")
+
+	if pattern.Metric() != "network" {
+		t.Errorf("Metric() = %s, want network", pattern.Metric())
+	}
+}
+
+func TestBarPattern(t *testing.T) {
+	fb := renderPattern(t, config.PatternConfig{Pattern: "bar"}, 50)
+
+	if fb.Pixel(16, 0) != 255 || fb.Pixel(16, 8) != 255 {
+		t.Error("50% horizontal bar should fill column 16")
+	}
+
+	if fb.Pixel(17, 0) != 0 {
+		t.Error("50% horizontal bar should not fill column 17")
+	}
+
+	fb = renderPattern(t, config.PatternConfig{
+		Pattern:    "bar",
+		Parameters: map[string]interface{}{"orientation": "vertical", "max": 9, "brightness": 100},
+	}, 3)
+
+	if fb.Pixel(0, 6) != 100 || fb.Pixel(33, 8) != 100 || fb.Pixel(0, 5) != 0 {
+		t.Error("vertical bar at 3/9 should fill the bottom three rows")
+	}
+}
+
+func TestSparklinePattern(t *testing.T) {
+	fb := renderPattern(t, config.PatternConfig{Pattern: "sparkline"}, 0, 100)
+
+	if fb.Pixel(32, 8) == 0 {
+		t.Error("sparkline should plot the older value at the bottom")
+	}
+
+	if fb.Pixel(33, 0) == 0 {
+		t.Error("sparkline should plot the newest value at the top right")
+	}
+
+	fb = renderPattern(t, config.PatternConfig{
+		Pattern:    "sparkline",
+		Parameters: map[string]interface{}{"fill": true},
+	}, 100)
+
+	if fb.Pixel(33, 8) == 0 {
+		t.Error("filled sparkline should light the column below the line")
+	}
+}
+
+func TestTextPattern(t *testing.T) {
+	fb := renderPattern(t, config.PatternConfig{
+		Pattern:    "text",
+		Parameters: map[string]interface{}{"text": "{value}", "font": "5x7"},
+	}, 7.4)
+
+	expected := NewTextRenderer(Font5x7, "7", 0).Frame(0)
+	if !fb.Equal(expected) {
+		t.Error("text pattern should substitute the rounded metric value")
+	}
+}
+
+func TestIconPattern(t *testing.T) {
+	cfg := config.PatternConfig{
+		Pattern: "icon",
+		Parameters: map[string]interface{}{
+			"pixels":    []interface{}{"#.#", ".#.", "#.#"},
+			"threshold": 80,
+		},
+	}
+
+	if fb := renderPattern(t, cfg, 50); !fb.Equal(matrix.NewFramebuffer()) {
+		t.Error("icon should be hidden below threshold")
+	}
+
+	fb := renderPattern(t, cfg, 90)
+
+	// 3x3 icon is centered at (15, 3)
+	if fb.Pixel(15, 3) != 255 || fb.Pixel(16, 3) != 0 || fb.Pixel(16, 4) != 255 {
+		t.Error("icon should be drawn centered at or above threshold")
+	}
+}
+
+func TestHeatmapPattern(t *testing.T) {
+	fb := renderPattern(t, config.PatternConfig{Pattern: "heatmap"}, 100, 50)
+
+	if fb.Pixel(32, 4) != 255 {
+		t.Errorf("heatmap previous column = %d, want 255", fb.Pixel(32, 4))
+	}
+
+	if fb.Pixel(33, 4) != 127 {
+		t.Errorf("heatmap newest column = %d, want 127", fb.Pixel(33, 4))
+	}
+
+	if fb.Pixel(31, 4) != 0 {
+		t.Error("heatmap should leave columns without history dark")
+	}
+}
+
+func TestTextPatternScrollsWithElapsedTime(t *testing.T) {
+	pattern, err := NewCustomPattern(config.PatternConfig{
+		Pattern:    "text",
+		Parameters: map[string]interface{}{"text": "LOAD {value} PERCENT", "speed": 10},
+	}, "cpu")
+	if err != nil {
+		t.Fatalf("NewCustomPattern() error = %v", err)
+	}
+
+	first := matrix.NewFramebuffer()
+	pattern.Render(first, 10, 0)
+
+	later := matrix.NewFramebuffer()
+	pattern.Render(later, 10, time.Second)
+
+	if first.Equal(later) {
+		t.Error("long text pattern should scroll over time")
+	}
+}
@@ -0,0 +1,134 @@
+package visualizer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+// DefaultScrollSpeed is the scroll speed in columns per second used when none is configured.
+const DefaultScrollSpeed = 8.0
+
+// TextRenderer renders a line of text onto the 34x9 matrix. Text that fits is centered;
+// wider text scrolls right to left at a fixed speed and wraps around with a gap.
+// Frames are a pure function of elapsed time so they can be tested without hardware.
+type TextRenderer struct {
+	font       *Font
+	text       string
+	speed      float64
+	gap        int
+	brightness byte
+}
+
+// NewTextRenderer creates a TextRenderer for text in the given font, scrolling at speed columns per second.
+func NewTextRenderer(font *Font, text string, speed float64) *TextRenderer {
+	if font == nil {
+		font = Font3x5
+	}
+
+	return &TextRenderer{
+		font:       font,
+		text:       text,
+		speed:      speed,
+		gap:        font.Width + 1,
+		brightness: 255,
+	}
+}
+
+// SetText replaces the rendered text.
+func (r *TextRenderer) SetText(text string) {
+	r.text = text
+}
+
+// SetBrightness sets the greyscale level of lit pixels.
+func (r *TextRenderer) SetBrightness(level byte) {
+	r.brightness = level
+}
+
+// Scrolls reports whether the text is too wide for the matrix and will scroll.
+func (r *TextRenderer) Scrolls() bool {
+	return r.scrolls(matrix.FramebufferWidth)
+}
+
+// Offset returns the scroll position in columns after elapsed time. It is always 0 for text that fits.
+func (r *TextRenderer) Offset(elapsed time.Duration) int {
+	return r.offset(matrix.FramebufferWidth, elapsed)
+}
+
+func (r *TextRenderer) scrolls(width int) bool {
+	return r.font.TextWidth(r.text) > width
+}
+
+func (r *TextRenderer) offset(width int, elapsed time.Duration) int {
+	if !r.scrolls(width) || r.speed <= 0 || elapsed <= 0 {
+		return 0
+	}
+
+	cycle := r.font.TextWidth(r.text) + r.gap
+	columns := int(elapsed.Seconds() * r.speed)
+
+	return columns % cycle
+}
+
+// Frame renders the text as it appears after elapsed time.
+func (r *TextRenderer) Frame(elapsed time.Duration) *matrix.Framebuffer {
+	fb := matrix.NewFramebuffer()
+	r.Render(fb, elapsed)
+
+	return fb
+}
+
+// Render draws the text onto s as it appears after elapsed time. The text is vertically centered
+// and scrolls only if it is wider than s, so a Canvas spanning two modules scrolls seamlessly across both.
+func (r *TextRenderer) Render(s matrix.Surface, elapsed time.Duration) {
+	surfaceWidth, surfaceHeight := s.Size()
+	width := r.font.TextWidth(r.text)
+	y := (surfaceHeight - r.font.Height) / 2
+
+	if !r.scrolls(surfaceWidth) {
+		r.font.DrawText(s, (surfaceWidth-width)/2, y, r.text, r.brightness)
+
+		return
+	}
+
+	x := -r.offset(surfaceWidth, elapsed)
+	r.font.DrawText(s, x, y, r.text, r.brightness)
+	r.font.DrawText(s, x+width+r.gap, y, r.text, r.brightness)
+}
+
+// DrawText renders the text as it appears after elapsed time and pushes it to the matrix. Each
+// scroll step is drawn at once rather than crossfaded from the last.
+func (v *Visualizer) DrawText(r *TextRenderer, elapsed time.Duration) error {
+	draw := v.base.DrawFrame
+	if instant, ok := v.base.(instantFrameDrawer); ok {
+		draw = instant.DrawFrameNow
+	}
+
+	if err := draw(r.Frame(elapsed)); err != nil {
+		return fmt.Errorf("failed to draw text: %w", err)
+	}
+
+	return nil
+}
+
+// DrawText renders the text across all displays as one canvas and pushes each display its slice.
+func (mv *MultiVisualizer) DrawText(r *TextRenderer, elapsed time.Duration) error {
+	canvas, err := mv.multiDisplay.NewCanvas()
+	if err != nil {
+		return fmt.Errorf("failed to create canvas: %w", err)
+	}
+
+	r.Render(canvas, elapsed)
+
+	draw := mv.base.DrawCanvas
+	if instant, ok := mv.base.(instantCanvasDrawer); ok {
+		draw = instant.DrawCanvasNow
+	}
+
+	if err := draw(canvas); err != nil {
+		return fmt.Errorf("failed to draw text: %w", err)
+	}
+
+	return nil
+}
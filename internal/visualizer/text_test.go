@@ -0,0 +1,208 @@
+package visualizer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+)
+
+func TestFontGlyphs(t *testing.T) {
+	for _, font := range []*Font{Font3x3, Font3x5, Font5x7} {
+		for r, glyph := range font.glyphs {
+			if len(glyph) != font.Height {
+				t.Errorf("%s glyph %q has %d rows, want %d", font.Name, r, len(glyph), font.Height)
+			}
+
+			for _, row := range glyph {
+				if len(row) != font.Width {
+					t.Errorf("%s glyph %q row %q has width %d, want %d", font.Name, r, row, len(row), font.Width)
+				}
+			}
+		}
+
+		if font.Glyph('a')[0] != font.Glyph('A')[0] {
+			t.Errorf("%s lowercase should fall back to uppercase", font.Name)
+		}
+
+		if len(font.Glyph('€')) != font.Height {
+			t.Errorf("%s unknown rune should fall back to '?'", font.Name)
+		}
+	}
+}
+
+func TestFontTextWidth(t *testing.T) {
+	tests := []struct {
+		font *Font
+		text string
+		want int
+	}{
+		{Font3x5, "", 0},
+		{Font3x5, "A", 3},
+		{Font3x5, "CPU", 11},
+		{Font5x7, "42%", 17},
+	}
+
+	for _, tt := range tests {
+		if got := tt.font.TextWidth(tt.text); got != tt.want {
+			t.Errorf("%s TextWidth(%q) = %d, want %d", tt.font.Name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFontDrawText(t *testing.T) {
+	fb := matrix.NewFramebuffer()
+
+	next := Font3x5.DrawText(fb, 0, 0, "1", 200)
+	if next != 4 {
+		t.Errorf("DrawText() returned %d, want 4", next)
+	}
+
+	// '1' is ".#.", "##.", ".#.", ".#.", "###"
+	if fb.Pixel(1, 0) != 200 || fb.Pixel(0, 0) != 0 || fb.Pixel(0, 4) != 200 {
+		t.Error("DrawText() did not render glyph '1' correctly")
+	}
+}
+
+func TestLookupFont(t *testing.T) {
+	if font, err := LookupFont("5x7"); err != nil || font != Font5x7 {
+		t.Errorf("LookupFont(5x7) = %v, %v", font, err)
+	}
+
+	if _, err := LookupFont("8x8"); err == nil {
+		t.Error("LookupFont() should return error for unknown font")
+	}
+}
+
+func TestTextRendererCentersShortText(t *testing.T) {
+	r := NewTextRenderer(Font3x5, "HI", DefaultScrollSpeed)
+
+	if r.Scrolls() {
+		t.Fatal("short text should not scroll")
+	}
+
+	first := r.Frame(0)
+	later := r.Frame(10 * time.Second)
+
+	if !first.Equal(later) {
+		t.Error("short text should render the same frame at any time")
+	}
+
+	// "HI" is 7 pixels wide, so it starts at column 13 on row 2
+	if first.Pixel(13, 2) == 0 || first.Pixel(12, 2) != 0 {
+		t.Error("short text should be centered horizontally and vertically")
+	}
+}
+
+func TestTextRendererScrolls(t *testing.T) {
+	text := "CPU 42% MEM 73%"
+	r := NewTextRenderer(Font3x5, text, 4)
+
+	if !r.Scrolls() {
+		t.Fatal("long text should scroll")
+	}
+
+	if got := r.Offset(0); got != 0 {
+		t.Errorf("Offset(0) = %d, want 0", got)
+	}
+
+	if got := r.Offset(time.Second); got != 4 {
+		t.Errorf("Offset(1s) = %d, want 4", got)
+	}
+
+	// After one second the frame equals the text drawn 4 columns to the left
+	expected := matrix.NewFramebuffer()
+	Font3x5.DrawText(expected, -4, 2, text, 255)
+
+	if !r.Frame(time.Second).Equal(expected) {
+		t.Error("Frame(1s) should show the text shifted by 4 columns")
+	}
+
+	// A full cycle brings the text back to the start
+	cycle := Font3x5.TextWidth(text) + Font3x5.Width + 1
+	if !r.Frame(time.Duration(cycle) * time.Second / 4).Equal(r.Frame(0)) {
+		t.Error("scrolling should wrap around after a full cycle")
+	}
+}
+
+func TestTextRendererZeroSpeed(t *testing.T) {
+	r := NewTextRenderer(Font5x7, "SCROLLING", 0)
+
+	if got := r.Offset(time.Minute); got != 0 {
+		t.Errorf("Offset() with zero speed = %d, want 0", got)
+	}
+}
+
+func TestVisualizerDrawText(t *testing.T) {
+	mockDisplay := NewMockDisplayManager()
+	visualizer := NewVisualizer(mockDisplay, config.DefaultConfig())
+	r := NewTextRenderer(Font5x7, "OK", DefaultScrollSpeed)
+
+	if err := visualizer.DrawText(r, 0); err != nil {
+		t.Fatalf("DrawText() error = %v", err)
+	}
+
+	if mockDisplay.lastFrame == nil || !mockDisplay.lastFrame.Equal(r.Frame(0)) {
+		t.Error("DrawText() should push the rendered frame")
+	}
+
+	mockDisplay.SetUpdateError(errors.New("display error"))
+
+	if err := visualizer.DrawText(r, 0); err == nil {
+		t.Error("DrawText() should return error when display fails")
+	}
+}
+
+func TestVisualizerDrawTextSkipsTransition(t *testing.T) {
+	client := &fakeClient{}
+	display := matrix.NewDisplayManager(client)
+	display.SetTransition(matrix.Transition{Easing: matrix.EaseLinear, Duration: time.Hour})
+
+	visualizer := NewVisualizer(display, config.DefaultConfig())
+	r := NewTextRenderer(Font3x5, "SCROLLING TEXT", DefaultScrollSpeed)
+
+	// An hour-long crossfade would barely have started; each scroll step is shown at once
+	if err := visualizer.DrawText(r, time.Second); err != nil {
+		t.Fatalf("DrawText() error = %v", err)
+	}
+
+	frame := r.Frame(time.Second)
+
+	for y := range matrix.FramebufferHeight {
+		for x := range matrix.FramebufferWidth {
+			if got := client.columns[byte(y)][x]; got != frame.Pixel(x, y) {
+				t.Fatalf("pixel (%d, %d) = %d, want %d drawn without a transition", x, y, got, frame.Pixel(x, y))
+			}
+		}
+	}
+}
+
+func TestMultiVisualizerDrawTextSpansCanvas(t *testing.T) {
+	display := &mockMultiDisplay{}
+	cfg := config.DefaultConfig()
+	mv := NewMultiVisualizer(display, cfg)
+
+	cfg.Matrix.Layout = matrix.LayoutHorizontal
+	mv.UpdateConfig(cfg)
+
+	// 40 columns wide: scrolls on one module but fits centered on the 68x9 canvas
+	text := "0123456789"
+	r := NewTextRenderer(Font3x5, text, DefaultScrollSpeed)
+
+	if err := mv.DrawText(r, time.Second); err != nil {
+		t.Fatalf("DrawText() error = %v", err)
+	}
+
+	expected, _ := matrix.NewCanvas(matrix.LayoutHorizontal)
+	Font3x5.DrawText(expected, 14, 2, text, 255)
+
+	if !expected.Slice(0).Equal(display.lastCanvas.Slice(0)) || !expected.Slice(1).Equal(display.lastCanvas.Slice(1)) {
+		t.Error("DrawText() should center text that fits the combined canvas across both modules")
+	}
+
+	if !display.canvasSkipped {
+		t.Error("DrawText() should draw the canvas without a transition")
+	}
+}
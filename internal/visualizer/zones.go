@@ -0,0 +1,309 @@
+package visualizer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+// zoneFonts are the fonts tried for zone labels and numbers, tallest first.
+var zoneFonts = []*Font{Font5x7, Font3x5, Font3x3}
+
+// fontForHeight returns the tallest font that fits in height rows, or the smallest font if none does.
+func fontForHeight(height int) *Font {
+	for _, font := range zoneFonts {
+		if font.Height <= height {
+			return font
+		}
+	}
+
+	return zoneFonts[len(zoneFonts)-1]
+}
+
+// region is a rectangular window onto a surface. Drawing outside the window is clipped.
+type region struct {
+	surface       matrix.Surface
+	x, y          int
+	width, height int
+}
+
+func (r region) Size() (int, int) {
+	return r.width, r.height
+}
+
+func (r region) SetPixel(x, y int, value byte) {
+	if x < 0 || x >= r.width || y < 0 || y >= r.height {
+		return
+	}
+
+	r.surface.SetPixel(r.x+x, r.y+y, value)
+}
+
+// inset returns the part of r right of its first left columns.
+func (r region) inset(left int) region {
+	left = min(left, r.width)
+
+	return region{surface: r.surface, x: r.x + left, y: r.y, width: r.width - left, height: r.height}
+}
+
+// zone is one area of a Layout and the state its renderer keeps between frames.
+type zone struct {
+	metric    string
+	renderer  string
+	label     string
+	sprite    [][]byte
+	threshold float64
+	history   history
+
+	x, y          int
+	width, height int
+}
+
+// Layout splits the matrix into zones, each showing one metric with its own renderer.
+// Sparkline zones keep a history of values, so the same Layout should render every frame.
+type Layout struct {
+	zones []*zone
+}
+
+// NewLayout builds a Layout from the configured zones for a surface of width by height pixels.
+// defaultMetric is used for zones that do not set their own metric.
+func NewLayout(zones []config.ZoneConfig, defaultMetric string, width, height int) (*Layout, error) {
+	layout := &Layout{}
+
+	for i, cfg := range zones {
+		top, bottom, err := config.ParseZoneRange(cfg.Rows, height)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rows for zone %d: %w", i, err)
+		}
+
+		left, right, err := config.ParseZoneRange(cfg.Columns, width)
+		if err != nil {
+			return nil, fmt.Errorf("invalid columns for zone %d: %w", i, err)
+		}
+
+		z := &zone{
+			metric:    cfg.Metric,
+			renderer:  cfg.Renderer,
+			label:     cfg.Label,
+			threshold: cfg.Threshold,
+			x:         left,
+			y:         top,
+			width:     right - left + 1,
+			height:    bottom - top + 1,
+		}
+
+		if z.metric == "" {
+			z.metric = defaultMetric
+		}
+
+		switch z.renderer {
+		case "":
+			z.renderer = config.ZoneBar
+		case config.ZoneBar, config.ZoneSparkline, config.ZoneNumber:
+		case config.ZoneIcon:
+			if len(cfg.Pixels) == 0 {
+				return nil, fmt.Errorf("icon zone %d requires pixels", i)
+			}
+
+			z.sprite = iconSprite(cfg.Pixels)
+		default:
+			return nil, fmt.Errorf("unknown zone renderer: %s", cfg.Renderer)
+		}
+
+		layout.zones = append(layout.zones, z)
+	}
+
+	return layout, nil
+}
+
+// iconSprite converts rows of '#' and '.' into a mask, 1 for each lit pixel.
+func iconSprite(rows []string) [][]byte {
+	sprite := make([][]byte, 0, len(rows))
+
+	for _, row := range rows {
+		line := make([]byte, 0, len(row))
+		for _, c := range row {
+			if c == '#' {
+				line = append(line, 1)
+			} else {
+				line = append(line, 0)
+			}
+		}
+
+		sprite = append(sprite, line)
+	}
+
+	return sprite
+}
+
+// Render draws every zone onto s. value returns the current percentage of a metric.
+func (l *Layout) Render(s matrix.Surface, value func(metric string) float64, brightness byte) {
+	for _, z := range l.zones {
+		z.render(s, value(z.metric), brightness)
+	}
+}
+
+func (z *zone) render(s matrix.Surface, value float64, brightness byte) {
+	area := region{surface: s, x: z.x, y: z.y, width: z.width, height: z.height}
+
+	if z.label != "" {
+		font := fontForHeight(z.height)
+		font.DrawText(area, 0, (z.height-font.Height)/2, z.label, brightness)
+		area = area.inset(font.Width + 1)
+	}
+
+	switch z.renderer {
+	case config.ZoneSparkline:
+		z.history.add(value)
+		renderZoneSparkline(area, z.history.values, brightness)
+	case config.ZoneNumber:
+		renderZoneNumber(area, value, brightness)
+	case config.ZoneIcon:
+		if value >= z.threshold {
+			renderZoneIcon(area, z.sprite, brightness)
+		}
+	default:
+		renderZoneBar(area, value, brightness)
+	}
+}
+
+// zoneFraction scales a percentage to 0..1.
+func zoneFraction(value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+
+	return min(max(value/100, 0), 1)
+}
+
+// renderZoneBar fills r from the left in proportion to value, dimming the leading column for a partial step.
+func renderZoneBar(r region, value float64, brightness byte) {
+	filled := zoneFraction(value) * float64(r.width)
+	full := int(filled)
+	edge := byte((filled - float64(full)) * float64(brightness))
+
+	for x := range r.width {
+		level := brightness
+
+		switch {
+		case x == full:
+			level = edge
+		case x > full:
+			continue
+		}
+
+		for y := range r.height {
+			r.SetPixel(x, y, level)
+		}
+	}
+}
+
+// renderZoneSparkline plots values as a line one column per sample, newest on the right.
+// Steps between neighbouring samples are joined vertically so the line stays unbroken.
+func renderZoneSparkline(r region, values []float64, brightness byte) {
+	values = values[max(len(values)-r.width, 0):]
+	start := r.width - len(values)
+	bottom := r.height - 1
+	prev := -1
+
+	for i, value := range values {
+		y := bottom - int(math.Round(zoneFraction(value)*float64(bottom)))
+
+		from, to := y, y
+		if prev >= 0 {
+			from, to = min(y, prev), max(y, prev)
+		}
+
+		for row := from; row <= to; row++ {
+			r.SetPixel(start+i, row, brightness)
+		}
+
+		prev = y
+	}
+}
+
+// renderZoneNumber writes value as a whole number, right-aligned in the tallest font that fits.
+func renderZoneNumber(r region, value float64, brightness byte) {
+	if math.IsNaN(value) {
+		value = 0
+	}
+
+	text := strconv.Itoa(int(math.Round(value)))
+	font := fontForHeight(r.height)
+
+	font.DrawText(r, r.width-font.TextWidth(text), (r.height-font.Height)/2, text, brightness)
+}
+
+// renderZoneIcon draws sprite centered in r.
+func renderZoneIcon(r region, sprite [][]byte, brightness byte) {
+	width := 0
+	for _, row := range sprite {
+		width = max(width, len(row))
+	}
+
+	left, top := (r.width-width)/2, (r.height-len(sprite))/2
+
+	for y, row := range sprite {
+		for x, lit := range row {
+			if lit != 0 {
+				r.SetPixel(left+x, top+y, brightness)
+			}
+		}
+	}
+}
+
+func (v *Visualizer) updateZonesMode(summary *stats.StatsSummary) error {
+	if v.zones == nil {
+		layout, err := NewLayout(v.config.Display.Zones, v.config.Display.PrimaryMetric,
+			matrix.FramebufferWidth, matrix.FramebufferHeight)
+		if err != nil {
+			return fmt.Errorf("failed to create zone layout: %w", err)
+		}
+
+		v.zones = layout
+	}
+
+	fb := matrix.NewFramebuffer()
+	v.zones.Render(fb, func(metric string) float64 { return v.metricValue(summary, metric) }, 255)
+
+	if err := v.base.DrawFrame(fb); err != nil {
+		return fmt.Errorf("failed to update zones display: %w", err)
+	}
+
+	v.lastUpdate = time.Now()
+
+	return nil
+}
+
+// updateZonesMode draws the zones on every display, or lays them out across the canvas in
+// extended mode.
+func (mv *MultiVisualizer) updateZonesMode(summary *stats.StatsSummary) error {
+	if mv.zones == nil {
+		width, height, err := mv.surfaceSize()
+		if err != nil {
+			return err
+		}
+
+		layout, err := NewLayout(mv.config.Display.Zones, mv.config.Display.PrimaryMetric, width, height)
+		if err != nil {
+			return fmt.Errorf("failed to create zone layout: %w", err)
+		}
+
+		mv.zones = layout
+	}
+
+	value := func(metric string) float64 { return mv.metricValue(summary, metric) }
+
+	if err := mv.drawSurface(func(s matrix.Surface) { mv.zones.Render(s, value, 255) }); err != nil {
+		return fmt.Errorf("failed to update zones display: %w", err)
+	}
+
+	mv.lastUpdate = time.Now()
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package visualizer
+
+import (
+	"testing"
+
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/config"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/matrix"
+	"github.com/timfallmk/framework-led-matrix-daemon/internal/stats"
+)
+
+func metricValues(values map[string]float64) func(string) float64 {
+	return func(metric string) float64 { return values[metric] }
+}
+
+func TestNewLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		zone config.ZoneConfig
+	}{
+		{"unknown renderer", config.ZoneConfig{Renderer: "dial"}},
+		{"icon without pixels", config.ZoneConfig{Renderer: config.ZoneIcon}},
+		{"rows out of range", config.ZoneConfig{Rows: "6-9"}},
+		{"columns reversed", config.ZoneConfig{Columns: "10-2"}},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewLayout([]config.ZoneConfig{tt.zone}, "cpu", matrix.FramebufferWidth, matrix.FramebufferHeight); err == nil {
+			t.Errorf("NewLayout() with %s should return an error", tt.name)
+		}
+	}
+}
+
+func TestLayoutDefaultZones(t *testing.T) {
+	layout, err := NewLayout(config.DefaultZones(), "cpu", matrix.FramebufferWidth, matrix.FramebufferHeight)
+	if err != nil {
+		t.Fatalf("NewLayout() error = %v", err)
+	}
+
+	fb := matrix.NewFramebuffer()
+	layout.Render(fb, metricValues(map[string]float64{"cpu": 50, "memory": 100}), 255)
+
+	// 'C' in the 3x3 font is "###", "#..", "###"
+	if fb.Pixel(0, 0) != 255 || fb.Pixel(1, 1) != 0 || fb.Pixel(2, 2) != 255 {
+		t.Error("cpu zone should be labelled 'C'")
+	}
+
+	// The bar starts after the label and its gap: 30 columns, half of them lit for 50%
+	if fb.Pixel(3, 1) != 0 || fb.Pixel(4, 1) != 255 || fb.Pixel(18, 2) != 255 || fb.Pixel(19, 0) != 0 {
+		t.Error("cpu zone should fill columns 4-18")
+	}
+
+	if fb.Pixel(33, 3) != 255 || fb.Pixel(33, 5) != 255 {
+		t.Error("memory zone should be full at 100%")
+	}
+
+	for x := 4; x < matrix.FramebufferWidth; x++ {
+		if fb.Pixel(x, 7) != 0 {
+			t.Fatalf("network zone should be empty at 0%%, column %d is lit", x)
+		}
+	}
+}
+
+func TestLayoutNumberAndIcon(t *testing.T) {
+	layout, err := NewLayout([]config.ZoneConfig{
+		{Metric: "cpu", Renderer: config.ZoneNumber, Rows: "0-4"},
+		{Metric: "disk", Renderer: config.ZoneIcon, Rows: "6-8", Columns: "0-2", Pixels: []string{"#.#"}, Threshold: 80},
+	}, "cpu", matrix.FramebufferWidth, matrix.FramebufferHeight)
+	if err != nil {
+		t.Fatalf("NewLayout() error = %v", err)
+	}
+
+	fb := matrix.NewFramebuffer()
+	layout.Render(fb, metricValues(map[string]float64{"cpu": 41.6, "disk": 50}), 200)
+
+	// "42" is right-aligned in the 3x5 font, so '2' ("###" on top) ends at column 33
+	if fb.Pixel(31, 0) != 200 || fb.Pixel(33, 0) != 200 || fb.Pixel(27, 0) != 200 {
+		t.Error("number zone should show 42 right-aligned")
+	}
+
+	if fb.Pixel(0, 7) != 0 {
+		t.Error("icon zone should stay dark below its threshold")
+	}
+
+	fb.Clear()
+	layout.Render(fb, metricValues(map[string]float64{"disk": 80}), 200)
+
+	if fb.Pixel(0, 7) != 200 || fb.Pixel(1, 7) != 0 || fb.Pixel(2, 7) != 200 {
+		t.Error("icon zone should be drawn centered at its threshold")
+	}
+}
+
+func TestLayoutSparklineKeepsHistory(t *testing.T) {
+	layout, err := NewLayout([]config.ZoneConfig{{Renderer: config.ZoneSparkline, Rows: "0-2"}}, "memory",
+		matrix.FramebufferWidth, matrix.FramebufferHeight)
+	if err != nil {
+		t.Fatalf("NewLayout() error = %v", err)
+	}
+
+	fb := matrix.NewFramebuffer()
+	layout.Render(fb, metricValues(map[string]float64{"memory": 0}), 255)
+
+	fb.Clear()
+	layout.Render(fb, metricValues(map[string]float64{"memory": 100}), 255)
+
+	if fb.Pixel(32, 2) != 255 || fb.Pixel(32, 0) != 0 {
+		t.Error("previous sample should be plotted on the bottom row of the zone")
+	}
+
+	for y := range 3 {
+		if fb.Pixel(33, y) != 255 {
+			t.Errorf("rise to the newest sample should be joined, row %d is dark", y)
+		}
+	}
+
+	if fb.Pixel(33, 3) != 0 {
+		t.Error("sparkline should not draw outside its zone")
+	}
+}
+
+func TestVisualizerUpdateDisplayZonesMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "zones"
+	cfg.Display.UpdateRate = 0
+
+	mockDisplay := NewMockDisplayManager()
+	visualizer := NewVisualizer(mockDisplay, cfg)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{CPUUsage: 100}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if mockDisplay.lastFrame == nil {
+		t.Fatal("zones mode should draw a frame")
+	}
+
+	if mockDisplay.lastFrame.Pixel(33, 1) == 0 || mockDisplay.lastFrame.Pixel(33, 4) != 0 {
+		t.Error("zones mode should show a full cpu bar and an empty memory bar")
+	}
+
+	cfg.Display.Zones = []config.ZoneConfig{{Renderer: "dial"}}
+	visualizer.UpdateConfig(cfg)
+
+	if err := visualizer.UpdateDisplay(&stats.StatsSummary{}); err == nil {
+		t.Error("UpdateDisplay() should rebuild the layout after UpdateConfig and report invalid zones")
+	}
+}
+
+func TestMultiVisualizerZonesMode(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "zones"
+	cfg.Display.UpdateRate = 0
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{MemoryUsage: 100}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastFrame == nil || display.lastFrame.Pixel(33, 4) == 0 {
+		t.Error("zones mode should draw the layout on every display")
+	}
+}
+
+func TestMultiVisualizerZonesModeExtended(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Display.Mode = "zones"
+	cfg.Display.UpdateRate = 0
+	cfg.Display.Zones = []config.ZoneConfig{{Metric: "cpu", Columns: "34-67"}}
+	cfg.Matrix.DualMode = "extended"
+
+	display := &mockMultiDisplay{}
+	mv := NewMultiVisualizer(display, cfg)
+
+	if err := mv.UpdateDisplay(&stats.StatsSummary{CPUUsage: 100}); err != nil {
+		t.Fatalf("UpdateDisplay() error = %v", err)
+	}
+
+	if display.lastCanvas == nil || display.lastFrame != nil {
+		t.Fatal("extended zones mode should draw one canvas across both matrices")
+	}
+
+	left, right := display.lastCanvas.Slice(0), display.lastCanvas.Slice(1)
+	if left.Pixel(33, 4) != 0 || right.Pixel(0, 4) == 0 || right.Pixel(33, 4) == 0 {
+		t.Error("zone columns should be placed on the canvas, filling the right matrix")
+	}
+}